@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type ApiKeyApi struct {
+	controller controllers.ApiKeyController
+}
+
+func NewApiKeyApi(controller controllers.ApiKeyController) *ApiKeyApi {
+	return &ApiKeyApi{
+		controller: controller,
+	}
+}
+
+func (api *ApiKeyApi) GetApiKeys(ctx *gin.Context) {
+	apiKeys, err := api.controller.GetApiKeys(ctx)
+	toolbox.HandleError(ctx, err, apiKeys)
+}
+
+func (api *ApiKeyApi) CreateApiKey(ctx *gin.Context) {
+	apiKey, err := api.controller.CreateApiKey(ctx)
+	toolbox.HandleError(ctx, err, apiKey)
+}
+
+func (api *ApiKeyApi) DeleteApiKey(ctx *gin.Context) {
+	err := api.controller.DeleteApiKey(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Api key deleted"})
+}
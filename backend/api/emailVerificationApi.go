@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type EmailVerificationApi struct {
+	controller controllers.EmailVerificationController
+}
+
+func NewEmailVerificationApi(controller controllers.EmailVerificationController) *EmailVerificationApi {
+	return &EmailVerificationApi{
+		controller: controller,
+	}
+}
+
+func (api *EmailVerificationApi) Verify(ctx *gin.Context) {
+	err := api.controller.Verify(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Email verified"})
+}
+
+func (api *EmailVerificationApi) ResendVerification(ctx *gin.Context) {
+	err := api.controller.ResendVerification(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Verification mail sent"})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,12 @@ func (api *GithubApi) RedirectToGithub(ctx *gin.Context, path string) {
 }
 
 func (api *GithubApi) HandleGithubTokenCallback(ctx *gin.Context, path string) {
-	if github_token, err := api.controller.ServiceGithubCallback(ctx, path); err != nil {
+	if github_token, err := api.controller.ServiceGithubCallback(ctx, path); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    github_token,
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	} else {
 		ctx.JSON(http.StatusOK, gin.H{"access_token": github_token})
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type GitlabApi struct {
+	controller controllers.GitlabController
+}
+
+func NewGitlabApi(controller controllers.GitlabController) *GitlabApi {
+	return &GitlabApi{
+		controller: controller,
+	}
+}
+
+func (api *GitlabApi) RedirectToGitlab(ctx *gin.Context, path string) {
+	if authURL, err := api.controller.RedirectionToGitlabService(ctx, path); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	} else {
+		ctx.JSON(http.StatusOK, schemas.OAuthConnectionResponse{
+			ServiceAuthenticationUrl: authURL,
+		})
+	}
+}
+
+func (api *GitlabApi) HandleGitlabTokenCallback(ctx *gin.Context, path string) {
+	if gitlabToken, err := api.controller.ServiceGitlabCallback(ctx, path); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    gitlabToken,
+		})
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"access_token": gitlabToken})
+	}
+}
+
+func (api *GitlabApi) ReceiveWebhook(ctx *gin.Context) {
+	err := api.controller.ReceiveWebhook(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "GitLab event received"})
+}
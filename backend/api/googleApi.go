@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,12 @@ func (api *GoogleApi) RedirectToGoogle(ctx *gin.Context, path string) {
 }
 
 func (api *GoogleApi) HandleGoogleTokenCallback(ctx *gin.Context, path string) {
-	if google_token, err := api.controller.ServiceGoogleCallback(ctx, path); err != nil {
+	if google_token, err := api.controller.ServiceGoogleCallback(ctx, path); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    google_token,
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	} else {
 		ctx.JSON(http.StatusOK, gin.H{"access_token": google_token})
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type MfaApi struct {
+	controller controllers.MfaController
+}
+
+func NewMfaApi(controller controllers.MfaController) *MfaApi {
+	return &MfaApi{
+		controller: controller,
+	}
+}
+
+func (api *MfaApi) Enroll(ctx *gin.Context) {
+	enrollment, err := api.controller.Enroll(ctx)
+	toolbox.HandleError(ctx, err, enrollment)
+}
+
+func (api *MfaApi) Confirm(ctx *gin.Context) {
+	recoveryCodes, err := api.controller.Confirm(ctx)
+	toolbox.HandleError(ctx, err, recoveryCodes)
+}
+
+func (api *MfaApi) Disable(ctx *gin.Context) {
+	err := api.controller.Disable(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Two-factor authentication disabled"})
+}
+
+func (api *MfaApi) Login(ctx *gin.Context) {
+	token, err := api.controller.Login(ctx)
+	toolbox.HandleError(ctx, err, schemas.JWT{Token: token})
+}
+
+func (api *MfaApi) Reset(ctx *gin.Context) {
+	err := api.controller.Reset(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Two-factor authentication reset"})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,12 @@ func (api *MicrosoftApi) RedirectToMicrosoft(ctx *gin.Context, path string) {
 }
 
 func (api *MicrosoftApi) HandleMicrosoftTokenCallback(ctx *gin.Context, path string) {
-	if microsoft_token, err := api.controller.ServiceMicrosoftCallback(ctx, path); err != nil {
+	if microsoft_token, err := api.controller.ServiceMicrosoftCallback(ctx, path); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    microsoft_token,
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 	} else {
 		ctx.JSON(http.StatusOK, gin.H{"access_token": microsoft_token})
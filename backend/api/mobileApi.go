@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -20,7 +21,12 @@ func NewMobileApi(controller controllers.MobileController) *MobileApi {
 }
 
 func (api *MobileApi) StoreMobileToken(ctx *gin.Context) {
-	if token, err := api.controller.StoreMobileToken(ctx); err != nil {
+	if token, err := api.controller.StoreMobileToken(ctx); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    token,
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusNotFound, schemas.BasicResponse{Message: err.Error()})
 	} else {
 		ctx.JSON(http.StatusOK, gin.H{"token": token})
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type PasswordRecoveryApi struct {
+	controller controllers.PasswordRecoveryController
+}
+
+func NewPasswordRecoveryApi(controller controllers.PasswordRecoveryController) *PasswordRecoveryApi {
+	return &PasswordRecoveryApi{
+		controller: controller,
+	}
+}
+
+func (api *PasswordRecoveryApi) ForgotPassword(ctx *gin.Context) {
+	err := api.controller.ForgotPassword(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "If an account uses this email, a recovery code was sent"})
+}
+
+func (api *PasswordRecoveryApi) ResetPassword(ctx *gin.Context) {
+	err := api.controller.ResetPassword(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Password reset"})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,12 @@ func (api *SpotifyApi) RedirectToSpotify(ctx *gin.Context, path string) {
 }
 
 func (api *SpotifyApi) HandleSpotifyTokenCallback(ctx *gin.Context, path string) {
-	if spotify_token, err := api.controller.ServiceSpotifyCallback(ctx, path); err != nil {
+	if spotify_token, err := api.controller.ServiceSpotifyCallback(ctx, path); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    spotify_token,
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	} else {
 		ctx.JSON(http.StatusOK, gin.H{"access_token": spotify_token})
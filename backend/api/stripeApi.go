@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type StripeApi struct {
+	controller controllers.StripeController
+}
+
+func NewStripeApi(controller controllers.StripeController) *StripeApi {
+	return &StripeApi{
+		controller: controller,
+	}
+}
+
+func (api *StripeApi) ReceiveWebhook(ctx *gin.Context) {
+	err := api.controller.ReceiveWebhook(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Stripe event received"})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +25,16 @@ func NewUserApi(controller controllers.UserController) *UserApi {
 }
 
 func (api *UserApi) Login(ctx *gin.Context) {
-	if token, err := api.userController.Login(ctx); err != nil {
+	if token, err := api.userController.Login(ctx); errors.Is(err, schemas.ErrMfaRequired) {
+		ctx.JSON(http.StatusOK, &schemas.MfaPendingResponse{
+			MfaRequired: true,
+			MfaToken:    token,
+		})
+	} else if errors.Is(err, schemas.ErrLoginThrottled) {
+		ctx.JSON(http.StatusTooManyRequests, &schemas.BasicResponse{
+			Message: err.Error(),
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusUnauthorized, &schemas.BasicResponse{
 			Message: err.Error(),
 		})
@@ -69,7 +79,11 @@ func (api *UserApi) GetWorkflows(ctx *gin.Context) {
 }
 
 func (api *UserApi) LogoutService(ctx *gin.Context) {
-	if err := api.userController.LogoutService(ctx); err != nil {
+	if err := api.userController.LogoutService(ctx); errors.Is(err, schemas.ErrApiKeyForbidden) {
+		ctx.JSON(http.StatusForbidden, &schemas.BasicResponse{
+			Message: err.Error(),
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusNotFound, &schemas.BasicResponse{
 			Message: err.Error(),
 		})
@@ -81,7 +95,11 @@ func (api *UserApi) LogoutService(ctx *gin.Context) {
 }
 
 func (api *UserApi) DeleteAccount(ctx *gin.Context) {
-	if err := api.userController.DeleteAccount(ctx); err != nil {
+	if err := api.userController.DeleteAccount(ctx); errors.Is(err, schemas.ErrApiKeyForbidden) {
+		ctx.JSON(http.StatusForbidden, &schemas.BasicResponse{
+			Message: err.Error(),
+		})
+	} else if err != nil {
 		ctx.JSON(http.StatusBadRequest, &schemas.BasicResponse{
 			Message: err.Error(),
 		})
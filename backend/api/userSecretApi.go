@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type UserSecretApi struct {
+	controller controllers.UserSecretController
+}
+
+func NewUserSecretApi(controller controllers.UserSecretController) *UserSecretApi {
+	return &UserSecretApi{
+		controller: controller,
+	}
+}
+
+func (api *UserSecretApi) GetSecrets(ctx *gin.Context) {
+	secrets, err := api.controller.GetSecrets(ctx)
+	toolbox.HandleError(ctx, err, secrets)
+}
+
+func (api *UserSecretApi) SaveSecret(ctx *gin.Context) {
+	err := api.controller.SaveSecret(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Secret saved"})
+}
+
+func (api *UserSecretApi) DeleteSecret(ctx *gin.Context) {
+	err := api.controller.DeleteSecret(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Secret deleted"})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/controllers"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type WebhookApi struct {
+	controller controllers.WebhookController
+}
+
+func NewWebhookApi(controller controllers.WebhookController) *WebhookApi {
+	return &WebhookApi{
+		controller: controller,
+	}
+}
+
+func (api *WebhookApi) ReceiveWebhook(ctx *gin.Context) {
+	err := api.controller.ReceiveWebhook(ctx)
+	toolbox.HandleError(ctx, err, schemas.BasicResponse{Message: "Webhook received"})
+}
+
+func (api *WebhookApi) GetWebhook(ctx *gin.Context) {
+	webhook, err := api.controller.GetWebhook(ctx)
+	toolbox.HandleError(ctx, err, webhook)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+type ApiKeyController interface {
+	GetApiKeys(ctx *gin.Context) ([]schemas.ApiKey, error)
+	CreateApiKey(ctx *gin.Context) (schemas.ApiKeyCreated, error)
+	DeleteApiKey(ctx *gin.Context) error
+}
+
+type apiKeyController struct {
+	service     services.ApiKeyService
+	userService services.UserService
+}
+
+func NewApiKeyController(
+	service services.ApiKeyService,
+	userService services.UserService,
+) ApiKeyController {
+	return &apiKeyController{
+		service:     service,
+		userService: userService,
+	}
+}
+
+// getUser only accepts sessions: a leaked key must not be able to mint
+// new keys or outlive its own deletion.
+func (controller *apiKeyController) getUser(ctx *gin.Context) (schemas.User, error) {
+	tokenString, err := toolbox.GetBearerToken(ctx)
+	if err != nil {
+		return schemas.User{}, err
+	}
+	if services.IsApiKey(tokenString) {
+		return schemas.User{}, schemas.ErrApiKeyForbidden
+	}
+	user, err := controller.userService.GetUserInfos(tokenString)
+	if err != nil || user.Id == 0 {
+		return schemas.User{}, schemas.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (controller *apiKeyController) GetApiKeys(ctx *gin.Context) ([]schemas.ApiKey, error) {
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return controller.service.GetApiKeys(user.Id), nil
+}
+
+func (controller *apiKeyController) CreateApiKey(ctx *gin.Context) (schemas.ApiKeyCreated, error) {
+	var creation schemas.ApiKeyCreation
+	err := ctx.ShouldBind(&creation)
+	if err != nil {
+		return schemas.ApiKeyCreated{}, schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return schemas.ApiKeyCreated{}, err
+	}
+	return controller.service.CreateApiKey(user.Id, creation)
+}
+
+func (controller *apiKeyController) DeleteApiKey(ctx *gin.Context) error {
+	var apiKeyName schemas.ApiKeyName
+	err := ctx.ShouldBind(&apiKeyName)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	return controller.service.DeleteApiKey(user.Id, apiKeyName.Name)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+type EmailVerificationController interface {
+	Verify(ctx *gin.Context) error
+	ResendVerification(ctx *gin.Context) error
+}
+
+type emailVerificationController struct {
+	service     services.EmailVerificationService
+	userService services.UserService
+}
+
+func NewEmailVerificationController(
+	service services.EmailVerificationService,
+	userService services.UserService,
+) EmailVerificationController {
+	return &emailVerificationController{
+		service:     service,
+		userService: userService,
+	}
+}
+
+func (controller *emailVerificationController) Verify(ctx *gin.Context) error {
+	return controller.service.Verify(ctx.Query("token"))
+}
+
+func (controller *emailVerificationController) ResendVerification(ctx *gin.Context) error {
+	tokenString, err := toolbox.GetBearerToken(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := controller.userService.GetUserInfos(tokenString)
+	if err != nil || user.Id == 0 {
+		return schemas.ErrUserNotFound
+	}
+	return controller.service.ResendVerification(user)
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -52,7 +55,15 @@ func (controller *githubController) RedirectionToGithubService(ctx *gin.Context,
 
 	ctx.SetCookie("latestCSRFToken", state, 3600, "/", "localhost", false, true)
 	redirectUri := fmt.Sprintf("%s%s/callback", appAdressHost, appPort)
-	authUrl := fmt.Sprintf("https://github.com/login/oauth/authorize?client_id=%s&response_type=code&scope=repo&redirect_uri=%s&state=%s", clientId, redirectUri, state)
+	oauth := controller.servicesService.GetRegistration(schemas.Github).OAuth
+	authUrl := fmt.Sprintf(
+		"%s?client_id=%s&response_type=code&scope=%s&redirect_uri=%s&state=%s",
+		oauth.AuthorizationUrl,
+		clientId,
+		url.QueryEscape(strings.Join(oauth.Scopes, " ")),
+		redirectUri,
+		state,
+	)
 	return authUrl, nil
 }
 
@@ -93,9 +104,8 @@ func (controller *githubController) ServiceGithubCallback(ctx *gin.Context, path
 			if err != nil {
 				return "", err
 			}
-			newSessionToken, _ := controller.userService.Login(user, controller.servicesService.FindByName(schemas.Github))
 			ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
-			return newSessionToken, nil
+			return token, nil
 		}
 	}
 	githubService := controller.servicesService.FindByName(schemas.Github)
@@ -221,7 +231,10 @@ func (controller *githubController) ServiceGithubCallback(ctx *gin.Context, path
 	}
 
 	if isAlreadyRegistered {
-		token, _ := controller.userService.Login(newUser, githubService)
+		token, err := controller.userService.Login(newUser, githubService)
+		if errors.Is(err, schemas.ErrMfaRequired) {
+			return token, err
+		}
 		ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
 		return token, nil
 	} else {
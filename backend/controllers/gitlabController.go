@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"area51/database"
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+type GitlabController interface {
+	RedirectionToGitlabService(ctx *gin.Context, path string) (string, error)
+	ServiceGitlabCallback(ctx *gin.Context, path string) (string, error)
+	ReceiveWebhook(ctx *gin.Context) error
+}
+
+type gitlabController struct {
+	service         services.GitlabService
+	userService     services.UserService
+	serviceToken    services.TokenService
+	servicesService services.ServicesService
+}
+
+func NewGitlabController(
+	service services.GitlabService,
+	userService services.UserService,
+	serviceToken services.TokenService,
+	servicesService services.ServicesService,
+) GitlabController {
+	return &gitlabController{
+		service:         service,
+		userService:     userService,
+		serviceToken:    serviceToken,
+		servicesService: servicesService,
+	}
+}
+
+func (controller *gitlabController) RedirectionToGitlabService(ctx *gin.Context, path string) (string, error) {
+	clientId := toolbox.GetInEnv("GITLAB_CLIENT_ID")
+	appPort := toolbox.GetInEnv("FRONTEND_PORT")
+	appAdressHost := toolbox.GetInEnv("APP_HOST_ADDRESS")
+
+	state, err := toolbox.GenerateCSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	ctx.SetCookie("latestCSRFToken", state, 3600, "/", "localhost", false, true)
+	redirectUri := fmt.Sprintf("%s%s%s", appAdressHost, appPort, path)
+	oauth := controller.servicesService.GetRegistration(schemas.Gitlab).OAuth
+	authUrl := fmt.Sprintf(
+		"%s?client_id=%s&response_type=code&scope=%s&redirect_uri=%s&state=%s",
+		oauth.AuthorizationUrl,
+		clientId,
+		url.QueryEscape(strings.Join(oauth.Scopes, " ")),
+		url.QueryEscape(redirectUri),
+		state,
+	)
+	return authUrl, nil
+}
+
+func (controller *gitlabController) ServiceGitlabCallback(ctx *gin.Context, path string) (string, error) {
+	var codeCredentials schemas.OAuth2CodeCredentials
+	err := json.NewDecoder(ctx.Request.Body).Decode(&codeCredentials)
+	if err != nil {
+		return "", err
+	}
+	if codeCredentials.Code == "" {
+		return "", nil
+	}
+	if codeCredentials.State == "" {
+		return "", nil
+	}
+	gitlabTokenResponse, err := controller.service.AuthGetServiceAccessToken(codeCredentials.Code, path)
+	if err != nil {
+		return "", err
+	}
+	gitlabService := controller.servicesService.FindByName(schemas.Gitlab)
+	newGitlabToken := schemas.ServiceToken{
+		Token:        gitlabTokenResponse.AccessToken,
+		RefreshToken: gitlabTokenResponse.RefreshToken,
+		Scope:        gitlabTokenResponse.Scope,
+		ExpireAt:     services.GitlabTokenExpiry(gitlabTokenResponse),
+		Service:      gitlabService,
+		ServiceId:    gitlabService.Id,
+	}
+	authHeader := ctx.GetHeader("Authorization")
+
+	if authHeader != "" && len(authHeader) >= len("Bearer ") {
+		token := authHeader[len("Bearer "):]
+		user, err := controller.userService.GetUserInfos(token)
+		if err != nil {
+			return "", err
+		}
+		if user.Username != "" {
+			err = controller.saveGitlabToken(user, newGitlabToken)
+			if err != nil {
+				return "", err
+			}
+			ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
+			return token, nil
+		}
+	}
+
+	servicesUserInfos := schemas.ServicesUserInfos{}
+	userInfos := controller.servicesService.GetUserInfosByToken(gitlabTokenResponse.AccessToken, schemas.Gitlab)
+	userInfos(&servicesUserInfos)
+	userInfo := servicesUserInfos.GitlabUserInfos
+	if userInfo == nil || userInfo.Username == "" {
+		return "", schemas.ErrUserNotFound
+	}
+
+	actualUser, err := controller.findGitlabUser(*userInfo, gitlabService)
+	if err != nil {
+		return "", err
+	}
+
+	if actualUser.Id == 0 {
+		var email *string
+		if userInfo.Email != "" {
+			email = &userInfo.Email
+		}
+		password, err := database.HashPassword(toolbox.GetInEnv("DEFAULT_PASSWORD"))
+		if err != nil {
+			return "", fmt.Errorf("unable to hash password because %w", err)
+		}
+		err = controller.userService.CreateUser(schemas.User{
+			Username: userInfo.Username,
+			Email:    email,
+			Password: &password,
+		})
+		if err != nil {
+			return "", fmt.Errorf("unable to create user because %w", err)
+		}
+		actualUser = controller.userService.GetUserByUsername(userInfo.Username)
+	}
+	err = controller.saveGitlabToken(actualUser, newGitlabToken)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := controller.userService.Login(actualUser, gitlabService)
+	if errors.Is(err, schemas.ErrMfaRequired) {
+		return token, err
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to login user because %w", err)
+	}
+	ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
+	return token, nil
+}
+
+// findGitlabUser matches an account by the email GitLab confirmed. Without
+// one, it only gets back the account a previous GitLab login created: no
+// email and already a GitLab token. Any other account using the username is
+// refused rather than linked, since a GitLab username proves nothing about it.
+func (controller *gitlabController) findGitlabUser(userInfo schemas.GitlabUserInfo, gitlabService schemas.Service) (schemas.User, error) {
+	if userInfo.Email != "" {
+		user := controller.userService.GetUserByEmail(&userInfo.Email)
+		if user.Id != 0 {
+			return user, nil
+		}
+	}
+	user := controller.userService.GetUserByUsername(userInfo.Username)
+	if user.Id == 0 {
+		return schemas.User{}, nil
+	}
+	existingToken, _ := controller.serviceToken.GetTokenByUserIdAndServiceId(user.Id, gitlabService.Id)
+	if userInfo.Email != "" || user.Email != nil || existingToken.Id == 0 {
+		return schemas.User{}, schemas.ErrorAlreadyExistingRessource
+	}
+	return user, nil
+}
+
+// saveGitlabToken replaces the GitLab token of the user or adds one.
+func (controller *gitlabController) saveGitlabToken(user schemas.User, gitlabToken schemas.ServiceToken) error {
+	gitlabToken.UserId = user.Id
+	gitlabToken.User = user
+	existingToken, _ := controller.serviceToken.GetTokenByUserIdAndServiceId(user.Id, gitlabToken.ServiceId)
+	if existingToken.Id != 0 {
+		gitlabToken.Id = existingToken.Id
+		err := controller.serviceToken.Update(gitlabToken)
+		if err != nil {
+			return fmt.Errorf("unable to update token because %w", err)
+		}
+		return nil
+	}
+	err := controller.userService.AddServiceToUser(user, gitlabToken)
+	if err != nil {
+		return fmt.Errorf("unable to add service to user because %w", err)
+	}
+	return nil
+}
+
+func (controller *gitlabController) ReceiveWebhook(ctx *gin.Context) error {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, webhookBodyLimit))
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	return controller.service.ReceiveWebhook(ctx.Param("token"), ctx.Request.Header, body, ctx.ClientIP())
+}
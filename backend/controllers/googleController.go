@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -51,10 +53,14 @@ func (controller *googleController) RedirectionToGoogleService(ctx *gin.Context,
 	}
 	redirectUri := fmt.Sprintf("%s%s/callback", appAdressHost, appPort)
 
-	scopes := "openid https://www.googleapis.com/auth/userinfo.email https://www.googleapis.com/auth/userinfo.profile https://www.googleapis.com/auth/gmail.readonly https://www.googleapis.com/auth/gmail.labels https://www.googleapis.com/auth/gmail.modify https://www.googleapis.com/auth/gmail.metadata https://www.googleapis.com/auth/calendar https://www.googleapis.com/auth/calendar.events"
+	oauth := controller.servicesService.GetRegistration(schemas.Google).OAuth
+	scopes := strings.Join(controller.requestedScopes(ctx, oauth.Scopes), " ")
 
+	// include_granted_scopes keeps the scopes granted before, so a connected
+	// user is only asked for the ones the stored token lacks.
 	authUrl := fmt.Sprintf(
-		"https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&response_type=code&scope=%s&redirect_uri=%s&state=%s",
+		"%s?client_id=%s&response_type=code&access_type=offline&include_granted_scopes=true&scope=%s&redirect_uri=%s&state=%s",
+		oauth.AuthorizationUrl,
 		clientId,
 		url.QueryEscape(scopes),
 		url.QueryEscape(redirectUri),
@@ -63,6 +69,30 @@ func (controller *googleController) RedirectionToGoogleService(ctx *gin.Context,
 	return authUrl, nil
 }
 
+// requestedScopes returns the scopes missing from the Google token of the
+// connected user, or every scope when there is no such token or it is
+// complete, for instance to log in again.
+func (controller *googleController) requestedScopes(ctx *gin.Context, scopes []string) []string {
+	authHeader := ctx.GetHeader("Authorization")
+	if len(authHeader) <= len("Bearer ") {
+		return scopes
+	}
+	user, err := controller.userService.GetUserInfos(authHeader[len("Bearer "):])
+	if err != nil {
+		return scopes
+	}
+	googleService := controller.servicesService.FindByName(schemas.Google)
+	serviceToken, err := controller.serviceToken.GetTokenByUserIdAndServiceId(user.Id, googleService.Id)
+	if err != nil || serviceToken.Scope == "" {
+		return scopes
+	}
+	missing := toolbox.MissingScopes(serviceToken.Scope, scopes)
+	if len(missing) == 0 {
+		return scopes
+	}
+	return missing
+}
+
 func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path string) (string, error) {
 	var isAlreadyRegistered bool = false
 	var codeCredentials schemas.OAuth2CodeCredentials
@@ -89,19 +119,19 @@ func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path
 			return "", err
 		}
 		if user.Username != "" {
-			err := controller.userService.AddServiceToUser(user, schemas.ServiceToken{
-				Token:     googleServiceToken.AccessToken,
-				Service:   controller.servicesService.FindByName(schemas.Google),
-				UserId:    user.Id,
-				User:      user,
-				ServiceId: controller.servicesService.FindByName(schemas.Google).Id,
+			googleService := controller.servicesService.FindByName(schemas.Google)
+			err := controller.saveGoogleToken(user, schemas.ServiceToken{
+				Token:        googleServiceToken.AccessToken,
+				RefreshToken: googleServiceToken.RefreshToken,
+				Scope:        googleServiceToken.Scope,
+				Service:      googleService,
+				ServiceId:    googleService.Id,
 			})
 			if err != nil {
 				return "", err
 			}
-			newSessionToken, _ := controller.userService.Login(user, controller.servicesService.FindByName(schemas.Google))
 			ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
-			return newSessionToken, nil
+			return token, nil
 		}
 	}
 	googleService := controller.servicesService.FindByName(schemas.Google)
@@ -127,6 +157,7 @@ func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path
 		newGoogleToken = schemas.ServiceToken{
 			Id:        serviceToken.Id,
 			Token:     googleServiceToken.AccessToken,
+			Scope:     googleServiceToken.Scope,
 			Service:   googleService,
 			UserId:    actualUser.Id,
 			User:      actualUser,
@@ -152,6 +183,7 @@ func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path
 		newGoogleToken = schemas.ServiceToken{
 			Token:        googleServiceToken.AccessToken,
 			RefreshToken: googleServiceToken.RefreshToken,
+			Scope:        googleServiceToken.Scope,
 			Service:      googleService,
 			UserId:       actualUser.Id,
 			User:         actualUser,
@@ -214,7 +246,10 @@ func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path
 		}
 	}
 	if isAlreadyRegistered {
-		token, _ := controller.userService.Login(newUser, googleService)
+		token, err := controller.userService.Login(newUser, googleService)
+		if errors.Is(err, schemas.ErrMfaRequired) {
+			return token, err
+		}
 		ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
 		return token, nil
 	} else {
@@ -225,3 +260,24 @@ func (controller *googleController) ServiceGoogleCallback(ctx *gin.Context, path
 		return token, nil
 	}
 }
+
+// saveGoogleToken replaces the Google token of the user or adds one, so the
+// scopes granted by an incremental authorization land on the token in use.
+func (controller *googleController) saveGoogleToken(user schemas.User, googleToken schemas.ServiceToken) error {
+	googleToken.UserId = user.Id
+	googleToken.User = user
+	existingToken, _ := controller.serviceToken.GetTokenByUserIdAndServiceId(user.Id, googleToken.ServiceId)
+	if existingToken.Id != 0 {
+		googleToken.Id = existingToken.Id
+		err := controller.serviceToken.Update(googleToken)
+		if err != nil {
+			return fmt.Errorf("unable to update token because %w", err)
+		}
+		return nil
+	}
+	err := controller.userService.AddServiceToUser(user, googleToken)
+	if err != nil {
+		return fmt.Errorf("unable to add service to user because %w", err)
+	}
+	return nil
+}
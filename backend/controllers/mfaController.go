@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+type MfaController interface {
+	Enroll(ctx *gin.Context) (schemas.MfaEnrollment, error)
+	Confirm(ctx *gin.Context) (schemas.MfaRecoveryCodes, error)
+	Disable(ctx *gin.Context) error
+	Login(ctx *gin.Context) (string, error)
+	Reset(ctx *gin.Context) error
+}
+
+type mfaController struct {
+	service     services.MfaService
+	userService services.UserService
+}
+
+func NewMfaController(
+	service services.MfaService,
+	userService services.UserService,
+) MfaController {
+	return &mfaController{
+		service:     service,
+		userService: userService,
+	}
+}
+
+// getUser only accepts sessions, an API key must not be enough to change
+// the second factor of its owner.
+func (controller *mfaController) getUser(ctx *gin.Context) (schemas.User, error) {
+	tokenString, err := toolbox.GetBearerToken(ctx)
+	if err != nil {
+		return schemas.User{}, err
+	}
+	if services.IsApiKey(tokenString) {
+		return schemas.User{}, schemas.ErrMfaForbidden
+	}
+	user, err := controller.userService.GetUserInfos(tokenString)
+	if err != nil || user.Id == 0 {
+		return schemas.User{}, schemas.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (controller *mfaController) Enroll(ctx *gin.Context) (schemas.MfaEnrollment, error) {
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return schemas.MfaEnrollment{}, err
+	}
+	return controller.service.Enroll(user)
+}
+
+func (controller *mfaController) Confirm(ctx *gin.Context) (schemas.MfaRecoveryCodes, error) {
+	var code schemas.MfaCode
+	err := ctx.ShouldBind(&code)
+	if err != nil {
+		return schemas.MfaRecoveryCodes{}, schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return schemas.MfaRecoveryCodes{}, err
+	}
+	return controller.service.Confirm(user, code.Code)
+}
+
+func (controller *mfaController) Disable(ctx *gin.Context) error {
+	var code schemas.MfaCode
+	err := ctx.ShouldBind(&code)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	return controller.service.Disable(user, code.Code)
+}
+
+func (controller *mfaController) Login(ctx *gin.Context) (string, error) {
+	var credentials schemas.MfaLoginCredentials
+	err := ctx.ShouldBind(&credentials)
+	if err != nil {
+		return "", schemas.ErrorBadParameter
+	}
+	return controller.service.Login(credentials)
+}
+
+func (controller *mfaController) Reset(ctx *gin.Context) error {
+	var reset schemas.MfaReset
+	err := ctx.ShouldBind(&reset)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	admin, err := controller.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	return controller.service.Reset(admin, reset.UserId)
+}
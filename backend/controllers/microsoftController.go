@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -49,7 +52,15 @@ func (controller *microsoftController) RedirectionToMicrosoftService(ctx *gin.Co
 		return "", err
 	}
 	redirectUri := appAdressHost + appPort + path
-	authUrl := fmt.Sprintf("https://login.microsoftonline.com/common/oauth2/v2.0/authorize?client_id=%s&response_type=code&scope=openid profile Calendars.Read Calendars.ReadWrite Calendars.ReadWrite.Shared Calendars.Read.Shared Chat.Read Mail.Send https://graph.microsoft.com/User.Read&redirect_uri=%s&state=%s", clientId, redirectUri, state)
+	oauth := controller.servicesService.GetRegistration(schemas.Microsoft).OAuth
+	authUrl := fmt.Sprintf(
+		"%s?client_id=%s&response_type=code&scope=%s&redirect_uri=%s&state=%s",
+		oauth.AuthorizationUrl,
+		clientId,
+		url.QueryEscape(strings.Join(oauth.Scopes, " ")),
+		redirectUri,
+		state,
+	)
 	return authUrl, nil
 }
 
@@ -89,9 +100,8 @@ func (controller *microsoftController) ServiceMicrosoftCallback(ctx *gin.Context
 			if err != nil {
 				return "", err
 			}
-			newSessionToken, _ := controller.userService.Login(user, controller.servicesService.FindByName(schemas.Microsoft))
 			ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
-			return newSessionToken, nil
+			return token, nil
 		}
 	}
 	microsoftService := controller.servicesService.FindByName(schemas.Microsoft)
@@ -188,7 +198,10 @@ func (controller *microsoftController) ServiceMicrosoftCallback(ctx *gin.Context
 	}
 
 	if isAlreadyRegistered {
-		token, _ := controller.userService.Login(newUser, microsoftService)
+		token, err := controller.userService.Login(newUser, microsoftService)
+		if errors.Is(err, schemas.ErrMfaRequired) {
+			return token, err
+		}
 		ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
 		return token, nil
 	} else {
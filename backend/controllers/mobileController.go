@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -70,6 +71,11 @@ func (controller *mobileController) StoreMobileToken(ctx *gin.Context) (string,
 			Login: servicesUserInfos.MicrosoftUserInfos.DisplayName,
 			Email: servicesUserInfos.MicrosoftUserInfos.Mail,
 		}
+	case schemas.Gitlab:
+		infos = schemas.MobileUsefulInfos{
+			Login: servicesUserInfos.GitlabUserInfos.Username,
+			Email: servicesUserInfos.GitlabUserInfos.Email,
+		}
 	}
 
 	var actualUser schemas.User
@@ -103,8 +109,7 @@ func (controller *mobileController) StoreMobileToken(ctx *gin.Context) (string,
 			if err != nil {
 				return "", err
 			}
-			newSessionToken, _ := controller.userService.Login(user, controller.servicesService.FindByName(result.Service))
-			return newSessionToken, nil
+			return token, nil
 		}
 	}
 	var newGithubToken schemas.ServiceToken
@@ -201,7 +206,10 @@ func (controller *mobileController) StoreMobileToken(ctx *gin.Context) (string,
 	}
 
 	if isAlreadyRegistered {
-		token, _ := controller.userService.Login(newUser, githubService)
+		token, err := controller.userService.Login(newUser, githubService)
+		if errors.Is(err, schemas.ErrMfaRequired) {
+			return token, err
+		}
 		return token, nil
 	} else {
 		token, err := controller.userService.Register(newUser)
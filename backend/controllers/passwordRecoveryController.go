@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+)
+
+type PasswordRecoveryController interface {
+	ForgotPassword(ctx *gin.Context) error
+	ResetPassword(ctx *gin.Context) error
+}
+
+type passwordRecoveryController struct {
+	service services.PasswordRecoveryService
+}
+
+func NewPasswordRecoveryController(service services.PasswordRecoveryService) PasswordRecoveryController {
+	return &passwordRecoveryController{
+		service: service,
+	}
+}
+
+func (controller *passwordRecoveryController) ForgotPassword(ctx *gin.Context) error {
+	var credentials schemas.ForgotPasswordCredentials
+	err := ctx.ShouldBind(&credentials)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	return controller.service.RequestRecovery(credentials.Email)
+}
+
+func (controller *passwordRecoveryController) ResetPassword(ctx *gin.Context) error {
+	var credentials schemas.ResetPasswordCredentials
+	err := ctx.ShouldBind(&credentials)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	return controller.service.ResetPassword(credentials)
+}
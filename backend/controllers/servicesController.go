@@ -30,15 +30,15 @@ func NewServiceController(
 }
 
 func (controller *servicesController) AboutJson(*gin.Context) (allServicesJson []schemas.ServiceJson, err error) {
-	allServices := controller.service.FindAll()
-	for _, oneService := range allServices {
+	for _, registration := range controller.service.GetRegistrations() {
+		oneService := controller.service.FindByName(registration.Service.Name)
 		allServicesJson = append(allServicesJson, schemas.ServiceJson{
-			Name:        schemas.ServiceName(oneService.Name),
-			Description: oneService.Description,
+			Name:        registration.Service.Name,
+			Description: registration.Service.Description,
 			Action:      controller.serviceAction.GetAllServicesByServiceId(oneService.Id),
 			Reaction:    controller.serviceReaction.GetAllServicesByServiceId(oneService.Id),
-			Image:       oneService.Image,
-			IsOAuth:     oneService.IsOAuth,
+			Image:       registration.Service.Image,
+			IsOAuth:     registration.Service.IsOAuth,
 		})
 	}
 	return allServicesJson, nil
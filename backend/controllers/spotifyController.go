@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -52,16 +53,11 @@ func (controller *spotifyController) RedirectionToSpotifyService(ctx *gin.Contex
 	}
 	ctx.SetCookie("latestCSRFToken", state, 3600, "/", "localhost", false, true)
 	redirectUri := fmt.Sprintf("%s%s/callback", appAddressHost, appPort)
-	scopes := []string{
-		"playlist-read-private",
-		"playlist-modify-public",
-		"playlist-modify-private",
-		"user-read-private",
-		"user-read-email",
-	}
-	scope := strings.Join(scopes, " ")
+	oauth := controller.servicesService.GetRegistration(schemas.Spotify).OAuth
+	scope := strings.Join(oauth.Scopes, " ")
 	authUrl := fmt.Sprintf(
-		"https://accounts.spotify.com/authorize?client_id=%s&redirect_uri=%s&state=%s&response_type=code&scope=%s",
+		"%s?client_id=%s&redirect_uri=%s&state=%s&response_type=code&scope=%s",
+		oauth.AuthorizationUrl,
 		clientId,
 		redirectUri,
 		state,
@@ -105,9 +101,8 @@ func (controller *spotifyController) ServiceSpotifyCallback(ctx *gin.Context, pa
 			if err != nil {
 				return "", err
 			}
-			newSessionToken, _ := controller.userService.Login(user, controller.servicesService.FindByName(schemas.Spotify))
 			ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
-			return newSessionToken, nil
+			return token, nil
 		}
 	}
 
@@ -201,7 +196,10 @@ func (controller *spotifyController) ServiceSpotifyCallback(ctx *gin.Context, pa
 	}
 
 	if isAlreadyRegistered {
-		token, _ := controller.userService.Login(newUser, spotifyService)
+		token, err := controller.userService.Login(newUser, spotifyService)
+		if errors.Is(err, schemas.ErrMfaRequired) {
+			return token, err
+		}
 		ctx.Redirect(http.StatusFound, "http://localhost:8081/callback?code="+codeCredentials.Code+"&state="+codeCredentials.State)
 		return token, nil
 	} else {
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+)
+
+type StripeController interface {
+	ReceiveWebhook(ctx *gin.Context) error
+}
+
+type stripeController struct {
+	service services.StripeService
+}
+
+func NewStripeController(service services.StripeService) StripeController {
+	return &stripeController{
+		service: service,
+	}
+}
+
+// ReceiveWebhook reads the raw body, the Stripe-Signature is computed on it.
+func (controller *stripeController) ReceiveWebhook(ctx *gin.Context) error {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, webhookBodyLimit))
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	return controller.service.ReceiveWebhook(ctx.Param("token"), ctx.Request.Header, body, ctx.ClientIP())
+}
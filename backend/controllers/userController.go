@@ -3,6 +3,10 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -30,6 +34,10 @@ type userController struct {
 	workflowService services.WorkflowService
 	googleService   services.GoogleService
 	githubService   services.GithubService
+	verification    services.EmailVerificationService
+	// An IP tries many usernames, so it gets more attempts than a username.
+	ipThrottle       *toolbox.LoginThrottle
+	usernameThrottle *toolbox.LoginThrottle
 }
 
 func NewUserController(
@@ -42,17 +50,21 @@ func NewUserController(
 	workflowService services.WorkflowService,
 	googleService services.GoogleService,
 	githubService services.GithubService,
+	verification services.EmailVerificationService,
 ) UserController {
 	return &userController{
-		userService:     userService,
-		jWtService:      jWtService,
-		servicesService: servicesService,
-		reactionService: reactionService,
-		actionService:   actionService,
-		serviceToken:    serviceToken,
-		workflowService: workflowService,
-		googleService:   googleService,
-		githubService:   githubService,
+		userService:      userService,
+		jWtService:       jWtService,
+		servicesService:  servicesService,
+		reactionService:  reactionService,
+		actionService:    actionService,
+		serviceToken:     serviceToken,
+		workflowService:  workflowService,
+		googleService:    googleService,
+		githubService:    githubService,
+		verification:     verification,
+		ipThrottle:       toolbox.NewLoginThrottle(10, 50, 30*time.Second, 15*time.Minute),
+		usernameThrottle: toolbox.NewLoginThrottle(3, 10, 30*time.Second, 15*time.Minute),
 	}
 }
 
@@ -62,14 +74,26 @@ func (controller *userController) Login(ctx *gin.Context) (string, error) {
 		return "", err
 	}
 
+	ip := ctx.ClientIP()
+	username := strings.ToLower(credentials.Username)
+	retryAfter := max(controller.ipThrottle.RetryAfter(ip), controller.usernameThrottle.RetryAfter(username))
+	if retryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		return "", schemas.ErrLoginThrottled
+	}
+
 	token, err := controller.userService.Login(schemas.User{
 		Username: credentials.Username,
 		Password: &credentials.Password,
 	}, schemas.Service{})
-	if err != nil {
+	// The mfa pending token comes with ErrMfaRequired.
+	if err != nil && !errors.Is(err, schemas.ErrMfaRequired) {
+		controller.ipThrottle.Failure(ip)
+		controller.usernameThrottle.Failure(username)
 		return "", err
 	}
-	return token, nil
+	controller.usernameThrottle.Success(username)
+	return token, err
 }
 
 func (controller *userController) Register(ctx *gin.Context) (string, error) {
@@ -89,13 +113,19 @@ func (controller *userController) Register(ctx *gin.Context) (string, error) {
 	}
 
 	token, err := controller.userService.Register(schemas.User{
-		Username: credentials.Username,
-		Email:    &credentials.Email,
-		Password: &credentials.Password,
+		Username:                 credentials.Username,
+		Email:                    &credentials.Email,
+		Password:                 &credentials.Password,
+		EmailVerificationPending: true,
 	})
 	if err != nil {
 		return "", err
 	}
+	// A failed mail does not undo the registration, the link can be resent.
+	err = controller.verification.SendVerification(controller.userService.GetUserByUsername(credentials.Username))
+	if err != nil {
+		fmt.Println("Error sending verification mail:", err)
+	}
 	return token, nil
 }
 
@@ -104,10 +134,11 @@ func (controller *userController) GetAllServices(ctx *gin.Context) ([]schemas.Se
 	if err != nil {
 		return []schemas.Service{}, err
 	}
-	userId, err := controller.jWtService.GetUserIdFromToken(bearer)
+	user, err := controller.userService.GetUserInfos(bearer)
 	if err != nil {
 		return nil, err
 	}
+	userId := user.Id
 	services, err := controller.userService.GetAllServices(userId)
 	if len(services) == 0 {
 		return nil, fmt.Errorf("no services found")
@@ -124,7 +155,8 @@ func (controller *userController) GetAllServices(ctx *gin.Context) ([]schemas.Se
 
 func (controller *userController) GetAllWorkflows(ctx *gin.Context) ([]schemas.WorkflowJson, error) {
 	bearer, _ := toolbox.GetBearerToken(ctx)
-	userId, err := controller.jWtService.GetUserIdFromToken(bearer)
+	user, err := controller.userService.GetUserInfos(bearer)
+	userId := user.Id
 	if err != nil || userId == 0 {
 		return nil, err
 	}
@@ -166,10 +198,14 @@ func (controller *userController) LogoutService(ctx *gin.Context) error {
 	if err != nil {
 		return err
 	}
-	userId, err := controller.jWtService.GetUserIdFromToken(bearer)
+	if services.IsApiKey(bearer) {
+		return schemas.ErrApiKeyForbidden
+	}
+	user, err := controller.userService.GetUserInfos(bearer)
 	if err != nil {
 		return err
 	}
+	userId := user.Id
 	actualService := controller.servicesService.FindByName(schemas.ServiceName(credentials.ServiceName))
 	if actualService.Id == 0 {
 		return fmt.Errorf("service not found")
@@ -198,9 +234,13 @@ func (controller *userController) DeleteAccount(ctx *gin.Context) error {
 	if err != nil {
 		return err
 	}
-	userId, err := controller.jWtService.GetUserIdFromToken(bearer)
+	if services.IsApiKey(bearer) {
+		return schemas.ErrApiKeyForbidden
+	}
+	user, err := controller.userService.GetUserInfos(bearer)
 	if err != nil {
 		return err
 	}
+	userId := user.Id
 	return controller.userService.DeleteUser(userId)
 }
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+type UserSecretController interface {
+	GetSecrets(ctx *gin.Context) ([]schemas.UserSecret, error)
+	SaveSecret(ctx *gin.Context) error
+	DeleteSecret(ctx *gin.Context) error
+}
+
+type userSecretController struct {
+	service     services.UserSecretService
+	userService services.UserService
+}
+
+func NewUserSecretController(
+	service services.UserSecretService,
+	userService services.UserService,
+) UserSecretController {
+	return &userSecretController{
+		service:     service,
+		userService: userService,
+	}
+}
+
+// getUser only accepts sessions: the secrets sign webhooks and hold the keys
+// of other services, a script key must not read their names or replace them.
+func (controller *userSecretController) getUser(ctx *gin.Context) (schemas.User, error) {
+	tokenString, err := toolbox.GetBearerToken(ctx)
+	if err != nil {
+		return schemas.User{}, err
+	}
+	if services.IsApiKey(tokenString) {
+		return schemas.User{}, schemas.ErrApiKeyForbidden
+	}
+	user, err := controller.userService.GetUserInfos(tokenString)
+	if err != nil || user.Id == 0 {
+		return schemas.User{}, schemas.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (controller *userSecretController) GetSecrets(ctx *gin.Context) ([]schemas.UserSecret, error) {
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return controller.service.GetSecretNames(user.Id), nil
+}
+
+func (controller *userSecretController) SaveSecret(ctx *gin.Context) error {
+	var credentials schemas.UserSecretCredentials
+	err := ctx.ShouldBind(&credentials)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	return controller.service.SaveSecret(user.Id, credentials.Name, credentials.Value)
+}
+
+func (controller *userSecretController) DeleteSecret(ctx *gin.Context) error {
+	var secretName schemas.UserSecretName
+	err := ctx.ShouldBind(&secretName)
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	user, err := controller.getUser(ctx)
+	if err != nil {
+		return err
+	}
+	return controller.service.DeleteSecret(user.Id, secretName.Name)
+}
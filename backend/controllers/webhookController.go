@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"area51/schemas"
+	"area51/services"
+	"area51/toolbox"
+)
+
+const webhookBodyLimit = 1 << 20
+
+type WebhookController interface {
+	ReceiveWebhook(ctx *gin.Context) error
+	GetWebhook(ctx *gin.Context) (schemas.WebhookJson, error)
+}
+
+type webhookController struct {
+	service     services.WebhookService
+	userService services.UserService
+}
+
+func NewWebhookController(
+	service services.WebhookService,
+	userService services.UserService,
+) WebhookController {
+	return &webhookController{
+		service:     service,
+		userService: userService,
+	}
+}
+
+func (controller *webhookController) ReceiveWebhook(ctx *gin.Context) error {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, webhookBodyLimit))
+	if err != nil {
+		return schemas.ErrorBadParameter
+	}
+	return controller.service.ReceiveWebhook(ctx.Param("token"), ctx.Request.Header, body, ctx.ClientIP())
+}
+
+func (controller *webhookController) GetWebhook(ctx *gin.Context) (schemas.WebhookJson, error) {
+	workflowId, err := strconv.ParseUint(ctx.Query("workflow_id"), 10, 64)
+	if err != nil {
+		return schemas.WebhookJson{}, schemas.ErrorBadParameter
+	}
+	tokenString, err := toolbox.GetBearerToken(ctx)
+	if err != nil {
+		return schemas.WebhookJson{}, err
+	}
+	user, err := controller.userService.GetUserInfos(tokenString)
+	if err != nil {
+		return schemas.WebhookJson{}, schemas.ErrUserNotFound
+	}
+	return controller.service.GetWebhook(user.Id, workflowId)
+}
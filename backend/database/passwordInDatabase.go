@@ -1,14 +1,59 @@
 package database
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
 type Password interface {
 	HashPassword(password string) (string, error)
 	CompareHashAndPassword(hashedPassword, password string) bool
 }
 
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// argon2id parameters recommended by OWASP: 19 MiB, 2 iterations, 1 thread.
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+// passwordHashConfig reads PASSWORD_HASH (bcrypt by default, or argon2id)
+// and BCRYPT_COST (bcrypt.DefaultCost by default).
+func passwordHashConfig() (algorithm string, bcryptCost int) {
+	algorithm = strings.ToLower(os.Getenv("PASSWORD_HASH"))
+	if algorithm != PasswordHashArgon2id {
+		algorithm = PasswordHashBcrypt
+	}
+	bcryptCost, err := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil || bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	return algorithm, bcryptCost
+}
+
 func HashPassword(password string) (string, error) {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	algorithm, bcryptCost := passwordHashConfig()
+	if algorithm == PasswordHashArgon2id {
+		return hashArgon2id(password)
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 
 	return string(passwordHash), err
 }
@@ -17,7 +62,72 @@ func CompareHashAndPassword(hashedPassword, password *string) bool {
 	if hashedPassword == nil || password == nil {
 		return false
 	}
+	if strings.HasPrefix(*hashedPassword, "$argon2id$") {
+		return compareArgon2id(*hashedPassword, *password)
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(*hashedPassword), []byte(*password))
 
 	return err == nil
 }
+
+// NeedsRehash reports whether a stored hash uses another algorithm or weaker
+// parameters than the configured ones, so it is replaced at the next login.
+func NeedsRehash(hashedPassword string) bool {
+	algorithm, bcryptCost := passwordHashConfig()
+	if algorithm == PasswordHashArgon2id {
+		memory, time, threads, _, _, err := parseArgon2id(hashedPassword)
+		return err != nil || memory < argon2Memory || time < argon2Time || threads < argon2Threads
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost < bcryptCost
+}
+
+// hashArgon2id encodes the hash in the PHC string format,
+// $argon2id$v=19$m=...,t=...,p=...$salt$hash.
+func hashArgon2id(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key),
+	), nil
+}
+
+func parseArgon2id(hashedPassword string) (memory uint32, time uint32, threads uint8, salt []byte, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return 0, 0, 0, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return 0, 0, 0, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	if err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+	salt, err = argon2Encoding.DecodeString(parts[4])
+	if err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+	key, err = argon2Encoding.DecodeString(parts[5])
+	if err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+	return memory, time, threads, salt, key, nil
+}
+
+func compareArgon2id(hashedPassword string, password string) bool {
+	memory, time, threads, salt, key, err := parseArgon2id(hashedPassword)
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	computed := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
@@ -6,6 +6,7 @@ toolchain go1.23.3
 
 require (
 	github.com/google/go-github/v67 v67.0.0
+	github.com/jackc/pgx/v5 v5.7.2
 	gorm.io/gorm v1.25.12
 )
 
@@ -13,7 +14,6 @@ require (
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	github.com/jackc/pgx/v5 v5.7.2 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/knz/go-libedit v1.10.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+	"strings"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,6 +19,12 @@ import (
 func setupRouter() *gin.Engine {
 
 	router := gin.Default()
+	// ClientIP keys the login throttle, so X-Forwarded-For is only believed
+	// from the proxies listed in TRUSTED_PROXIES, none by default.
+	err := router.SetTrustedProxies(trustedProxies())
+	if err != nil {
+		panic(err)
+	}
 	fullCors := cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
@@ -34,18 +43,32 @@ func setupRouter() *gin.Engine {
 			mobile.POST("/token", mobileApi.StoreMobileToken)
 		}
 
-		user := apiRoutes.Group("/user", middlewares.Authorization())
+		user := apiRoutes.Group("/user", middlewares.Authorization(userService, apiKeyService))
 		{
 			user.GET("services", userApi.GetServices)
 			user.GET("workflows", userApi.GetWorkflows)
 			user.PUT("service/logout", userApi.LogoutService)
 			user.DELETE("account", userApi.DeleteAccount)
+			user.GET("secrets", userSecretApi.GetSecrets)
+			user.POST("secrets", userSecretApi.SaveSecret)
+			user.DELETE("secrets", userSecretApi.DeleteSecret)
+			user.GET("api-keys", apiKeyApi.GetApiKeys)
+			user.POST("api-keys", apiKeyApi.CreateApiKey)
+			user.DELETE("api-keys", apiKeyApi.DeleteApiKey)
+			user.POST("mfa/enroll", mfaApi.Enroll)
+			user.POST("mfa/confirm", mfaApi.Confirm)
+			user.DELETE("mfa", mfaApi.Disable)
 		}
 
 		auth := apiRoutes.Group("/auth")
 		{
 			auth.POST("/login", userApi.Login)
 			auth.POST("/register", userApi.Register)
+			auth.POST("/login/mfa", mfaApi.Login)
+			auth.POST("/forgot-password", passwordRecoveryApi.ForgotPassword)
+			auth.POST("/reset-password", passwordRecoveryApi.ResetPassword)
+			auth.GET("/verify", emailVerificationApi.Verify)
+			auth.POST("/verify/resend", emailVerificationApi.ResendVerification)
 		}
 
 		github := apiRoutes.Group("/github")
@@ -57,7 +80,7 @@ func setupRouter() *gin.Engine {
 				githubApi.HandleGithubTokenCallback(ctx, github.BasePath()+"/callback")
 			})
 		}
-		workflow := apiRoutes.Group("/workflow", middlewares.Authorization())
+		workflow := apiRoutes.Group("/workflow", middlewares.Authorization(userService, apiKeyService))
 		{
 			workflow.POST("", workflowApi.CreateWorkflow)
 			workflow.PUT("/activation", workflowApi.ActivateWorkflow)
@@ -65,6 +88,29 @@ func setupRouter() *gin.Engine {
 			workflow.DELETE("", workflowApi.DeleteWorkflow)
 			workflow.GET("/reaction/latest/", workflowApi.GetMostRecentReaction)
 			workflow.GET("/reactions", workflowApi.GetAllReactionsForAWorkflow)
+			workflow.GET("/hook", webhookApi.GetWebhook)
+		}
+
+		admin := apiRoutes.Group("/admin", middlewares.Authorization(userService, apiKeyService))
+		{
+			admin.DELETE("/user/mfa", mfaApi.Reset)
+		}
+
+		hooks := apiRoutes.Group("/hooks")
+		{
+			hooks.POST("/:token", webhookApi.ReceiveWebhook)
+			hooks.POST("/stripe/:token", stripeApi.ReceiveWebhook)
+			hooks.POST("/gitlab/:token", gitlabApi.ReceiveWebhook)
+		}
+
+		gitlab := apiRoutes.Group("/gitlab")
+		{
+			gitlab.GET("/auth", func(ctx *gin.Context) {
+				gitlabApi.RedirectToGitlab(ctx, "/callback")
+			})
+			gitlab.POST("/callback", func(ctx *gin.Context) {
+				gitlabApi.HandleGitlabTokenCallback(ctx, "/callback")
+			})
 		}
 
 		spotify := apiRoutes.Group("/spotify")
@@ -116,45 +162,96 @@ var (
 	reactionResponseDataRepository repository.ReactionResponseDataRepository = repository.NewReactionResponseDataRepository(databaseConnection)
 	spotifyRepository              repository.SpotifyRepository              = repository.NewSpotifyRepository(databaseConnection)
 	googleRepository               repository.GoogleRepository               = repository.NewGoogleRepository(databaseConnection)
+	userSecretRepository           repository.UserSecretRepository           = repository.NewUserSecretRepository(databaseConnection)
+	webhookRepository              repository.WebhookRepository              = repository.NewWebhookRepository(databaseConnection)
+	apiKeyRepository               repository.ApiKeyRepository               = repository.NewApiKeyRepository(databaseConnection)
+	passwordRecoveryRepository     repository.PasswordRecoveryRepository     = repository.NewPasswordRecoveryRepository(databaseConnection)
+	emailVerificationRepository    repository.EmailVerificationRepository    = repository.NewEmailVerificationRepository(databaseConnection)
+	mfaRepository                  repository.MfaRepository                  = repository.NewMfaRepository(databaseConnection)
 
 	// Services
 	jwtService                  services.JWTService                  = services.NewJWTService()
 	serviceToken                services.TokenService                = services.NewTokenService(tokenRepository, userService)
-	userService                 services.UserService                 = services.NewUserService(userRepository, jwtService)
+	userService                 services.UserService                 = services.NewUserService(userRepository, jwtService, apiKeyService)
 	reactionResponseDataService services.ReactionResponseDataService = services.NewReactionResponseDataService(reactionResponseDataRepository)
 	githubService               services.GithubService               = services.NewGithubService(githubRepository, tokenRepository, workflowsRepository, reactionRepository, reactionResponseDataService, userService, servicesRepository)
 	weatherService              services.WeatherService              = services.NewWeatherService(workflowsRepository, userService, reactionResponseDataService)
-	servicesService             services.ServicesService             = services.NewServicesService(servicesRepository, githubService, spotifyService, googleService, microsoftService, weatherService, interpolService)
+	servicesService             services.ServicesService             = services.NewServicesService(servicesRepository, githubService, spotifyService, googleService, microsoftService, weatherService, interpolService, httpService, webhookService, timerService, rssService, emailService, discordService, slackService, mattermostService, stripeService, tmdbService, gitlabService)
 	actionService               services.ActionService               = services.NewActionService(actionRepository, servicesService, userService)
 	reactionService             services.ReactionService             = services.NewReactionService(reactionRepository, servicesService)
 	interpolService             services.InterpolService             = services.NewInterpolService(workflowsRepository, reactionRepository, userService, reactionResponseDataRepository)
 	workflowsService            services.WorkflowService             = services.NewWorkflowService(workflowsRepository, userService, actionService, reactionService, servicesService, serviceToken, reactionResponseDataService, googleRepository, githubRepository)
-	spotifyService              services.SpotifyService              = services.NewSpotifyService(userService, spotifyRepository, workflowsRepository, actionRepository, reactionRepository, tokenRepository, servicesRepository)
-	googleService               services.GoogleService               = services.NewGoogleService(serviceToken, userService, workflowsRepository, servicesRepository, googleRepository)
-	microsoftService            services.MicrosoftService            = services.NewMicrosoftService(serviceToken, userService, workflowsRepository, servicesRepository)
+	spotifyService              services.SpotifyService              = services.NewSpotifyService(userService, spotifyRepository, workflowsRepository, actionRepository, reactionRepository, tokenRepository, servicesRepository, reactionResponseDataService)
+	googleService               services.GoogleService               = services.NewGoogleService(serviceToken, userService, workflowsRepository, servicesRepository, googleRepository, reactionResponseDataService)
+	microsoftService            services.MicrosoftService            = services.NewMicrosoftService(serviceToken, userService, workflowsRepository, servicesRepository, reactionResponseDataService)
+	userSecretService           services.UserSecretService           = services.NewUserSecretService(userSecretRepository)
+	apiKeyService               services.ApiKeyService               = services.NewApiKeyService(apiKeyRepository)
+	passwordRecoveryService     services.PasswordRecoveryService     = services.NewPasswordRecoveryService(passwordRecoveryRepository, userService, mailSender)
+	emailVerificationService    services.EmailVerificationService    = services.NewEmailVerificationService(emailVerificationRepository, userService, mailSender)
+	mfaService                  services.MfaService                  = services.NewMfaService(mfaRepository, userService, jwtService)
+	httpService                 services.HttpService                 = services.NewHttpService(workflowsRepository, reactionResponseDataService, userSecretService)
+	webhookService              services.WebhookService              = services.NewWebhookService(workflowsRepository, webhookRepository, userSecretService)
+	timerService                services.TimerService                = services.NewTimerService(workflowsRepository)
+	rssService                  services.RssService                  = services.NewRssService(workflowsRepository)
+	mailSender                  services.MailSender                  = services.NewMailSender()
+	emailService                services.EmailService                = services.NewEmailService(workflowsRepository, reactionResponseDataService, mailSender)
+	discordService              services.DiscordService              = services.NewDiscordService(workflowsRepository, reactionResponseDataService, userSecretService)
+	slackService                services.SlackService                = services.NewSlackService(workflowsRepository, reactionResponseDataService, userSecretService)
+	mattermostService           services.MattermostService           = services.NewMattermostService(workflowsRepository, reactionResponseDataService, userSecretService)
+	stripeService               services.StripeService               = services.NewStripeService(workflowsRepository, webhookRepository, reactionResponseDataService, userSecretService)
+	tmdbService                 services.TmdbService                 = services.NewTmdbService(workflowsRepository, reactionResponseDataService, userSecretService)
+	gitlabService               services.GitlabService               = services.NewGitlabService(serviceToken, workflowsRepository, webhookRepository, servicesRepository, reactionResponseDataService, userSecretService)
 
 	// Controllers
-	userController      controllers.UserController      = controllers.NewUserController(userService, jwtService, servicesService, reactionService, actionService, serviceToken, workflowsService, googleService, githubService)
-	githubController    controllers.GithubController    = controllers.NewGithubController(githubService, userService, serviceToken, servicesService)
-	servicesController  controllers.ServicesController  = controllers.NewServiceController(servicesService, actionService, reactionService)
-	workflowController  controllers.WorkflowController  = controllers.NewWorkflowController(workflowsService, reactionService, actionService)
-	spotifyController   controllers.SpotifyController   = controllers.NewSpotifyController(spotifyService, servicesService, userService, serviceToken)
-	microsoftController controllers.MicrosoftController = controllers.NewMicrosoftController(microsoftService, userService, servicesService, serviceToken)
-	googleController    controllers.GoogleController    = controllers.NewGoogleController(googleService, userService, servicesService, serviceToken)
-	mobileController    controllers.MobileController    = controllers.NewMobileController(userService, serviceToken, servicesService)
+	userController              controllers.UserController              = controllers.NewUserController(userService, jwtService, servicesService, reactionService, actionService, serviceToken, workflowsService, googleService, githubService, emailVerificationService)
+	githubController            controllers.GithubController            = controllers.NewGithubController(githubService, userService, serviceToken, servicesService)
+	servicesController          controllers.ServicesController          = controllers.NewServiceController(servicesService, actionService, reactionService)
+	workflowController          controllers.WorkflowController          = controllers.NewWorkflowController(workflowsService, reactionService, actionService)
+	spotifyController           controllers.SpotifyController           = controllers.NewSpotifyController(spotifyService, servicesService, userService, serviceToken)
+	microsoftController         controllers.MicrosoftController         = controllers.NewMicrosoftController(microsoftService, userService, servicesService, serviceToken)
+	googleController            controllers.GoogleController            = controllers.NewGoogleController(googleService, userService, servicesService, serviceToken)
+	mobileController            controllers.MobileController            = controllers.NewMobileController(userService, serviceToken, servicesService)
+	userSecretController        controllers.UserSecretController        = controllers.NewUserSecretController(userSecretService, userService)
+	apiKeyController            controllers.ApiKeyController            = controllers.NewApiKeyController(apiKeyService, userService)
+	passwordRecoveryController  controllers.PasswordRecoveryController  = controllers.NewPasswordRecoveryController(passwordRecoveryService)
+	emailVerificationController controllers.EmailVerificationController = controllers.NewEmailVerificationController(emailVerificationService, userService)
+	mfaController               controllers.MfaController               = controllers.NewMfaController(mfaService, userService)
+	webhookController           controllers.WebhookController           = controllers.NewWebhookController(webhookService, userService)
+	stripeController            controllers.StripeController            = controllers.NewStripeController(stripeService)
+	gitlabController            controllers.GitlabController            = controllers.NewGitlabController(gitlabService, userService, serviceToken, servicesService)
 )
 
 var (
-	userApi      *api.UserApi      = api.NewUserApi(userController)
-	githubApi    *api.GithubApi    = api.NewGithubApi(githubController)
-	servicesApi  *api.ServicesApi  = api.NewServicesApi(servicesController, workflowController)
-	workflowApi  *api.WorkflowApi  = api.NewWorkflowApi(workflowController)
-	spotifyApi   *api.SpotifyApi   = api.NewSpotifyApi(spotifyController)
-	mobileApi    *api.MobileApi    = api.NewMobileApi(mobileController)
-	microsoftApi *api.MicrosoftApi = api.NewMicrosoftApi(microsoftController)
-	googleApi    *api.GoogleApi    = api.NewGoogleApi(googleController)
+	userApi              *api.UserApi              = api.NewUserApi(userController)
+	githubApi            *api.GithubApi            = api.NewGithubApi(githubController)
+	servicesApi          *api.ServicesApi          = api.NewServicesApi(servicesController, workflowController)
+	workflowApi          *api.WorkflowApi          = api.NewWorkflowApi(workflowController)
+	spotifyApi           *api.SpotifyApi           = api.NewSpotifyApi(spotifyController)
+	mobileApi            *api.MobileApi            = api.NewMobileApi(mobileController)
+	microsoftApi         *api.MicrosoftApi         = api.NewMicrosoftApi(microsoftController)
+	googleApi            *api.GoogleApi            = api.NewGoogleApi(googleController)
+	userSecretApi        *api.UserSecretApi        = api.NewUserSecretApi(userSecretController)
+	apiKeyApi            *api.ApiKeyApi            = api.NewApiKeyApi(apiKeyController)
+	passwordRecoveryApi  *api.PasswordRecoveryApi  = api.NewPasswordRecoveryApi(passwordRecoveryController)
+	emailVerificationApi *api.EmailVerificationApi = api.NewEmailVerificationApi(emailVerificationController)
+	mfaApi               *api.MfaApi               = api.NewMfaApi(mfaController)
+	webhookApi           *api.WebhookApi           = api.NewWebhookApi(webhookController)
+	stripeApi            *api.StripeApi            = api.NewStripeApi(stripeController)
+	gitlabApi            *api.GitlabApi            = api.NewGitlabApi(gitlabController)
 )
 
+func trustedProxies() []string {
+	proxies := os.Getenv("TRUSTED_PROXIES")
+	if proxies == "" {
+		return nil
+	}
+	list := strings.Split(proxies, ",")
+	for index := range list {
+		list[index] = strings.TrimSpace(list[index])
+	}
+	return list
+}
+
 func main() {
 	router := setupRouter()
 
@@ -10,7 +10,7 @@ import (
 	"area51/toolbox"
 )
 
-func Authorization() gin.HandlerFunc {
+func Authorization(userService services.UserService, apiKeyService services.ApiKeyService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString, err := toolbox.GetBearerToken(ctx)
 		if err != nil {
@@ -20,9 +20,15 @@ func Authorization() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
-		token, _ := services.NewJWTService().ValidateJWTToken(tokenString)
+		if services.IsApiKey(tokenString) {
+			apiKeyAuthorization(ctx, apiKeyService, tokenString)
+			return
+		}
+		// Going through the user also refuses the sessions revoked by a
+		// password reset and the ones of deleted users.
+		user, err := userService.GetUserInfos(tokenString)
 
-		if token.Valid {
+		if err == nil && user.Id != 0 {
 			ctx.Next()
 		} else {
 			ctx.JSON(http.StatusUnauthorized, schemas.BasicResponse{
@@ -33,3 +39,22 @@ func Authorization() gin.HandlerFunc {
 		}
 	}
 }
+
+func apiKeyAuthorization(ctx *gin.Context, apiKeyService services.ApiKeyService, key string) {
+	apiKey, err := apiKeyService.Authenticate(key)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, schemas.BasicResponse{
+			Message: "Unauthorized",
+		})
+		ctx.Abort()
+		return
+	}
+	if !apiKeyService.AllowsMethod(apiKey, ctx.Request.Method) {
+		ctx.JSON(http.StatusForbidden, schemas.BasicResponse{
+			Message: schemas.ErrApiKeyScope.Error(),
+		})
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+type ApiKeyRepository interface {
+	Save(apiKey schemas.ApiKey) schemas.ApiKey
+	Delete(apiKey schemas.ApiKey)
+	UpdateLastUsedAt(id uint64, lastUsedAt time.Time)
+	FindByUserId(userId uint64) []schemas.ApiKey
+	FindByUserIdAndName(userId uint64, name string) schemas.ApiKey
+	FindByHash(hash string) schemas.ApiKey
+}
+
+type apiKeyRepository struct {
+	db *schemas.Database
+}
+
+func NewApiKeyRepository(conn *gorm.DB) ApiKeyRepository {
+	err := conn.AutoMigrate(&schemas.ApiKey{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &apiKeyRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *apiKeyRepository) Save(apiKey schemas.ApiKey) schemas.ApiKey {
+	err := repo.db.Connection.Create(&apiKey)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+	return apiKey
+}
+
+func (repo *apiKeyRepository) Delete(apiKey schemas.ApiKey) {
+	err := repo.db.Connection.Delete(&apiKey)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *apiKeyRepository) UpdateLastUsedAt(id uint64, lastUsedAt time.Time) {
+	err := repo.db.Connection.Model(&schemas.ApiKey{}).Where(&schemas.ApiKey{
+		Id: id,
+	}).Update("last_used_at", lastUsedAt)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *apiKeyRepository) FindByUserId(userId uint64) (apiKeys []schemas.ApiKey) {
+	err := repo.db.Connection.Where(&schemas.ApiKey{
+		UserId: userId,
+	}).Order("name").Find(&apiKeys)
+
+	if err.Error != nil {
+		return []schemas.ApiKey{}
+	}
+	return apiKeys
+}
+
+func (repo *apiKeyRepository) FindByUserIdAndName(userId uint64, name string) (apiKey schemas.ApiKey) {
+	err := repo.db.Connection.Where(&schemas.ApiKey{
+		UserId: userId,
+		Name:   name,
+	}).First(&apiKey)
+
+	if err.Error != nil {
+		return schemas.ApiKey{}
+	}
+	return apiKey
+}
+
+func (repo *apiKeyRepository) FindByHash(hash string) (apiKey schemas.ApiKey) {
+	err := repo.db.Connection.Where(&schemas.ApiKey{
+		Hash: hash,
+	}).First(&apiKey)
+
+	if err.Error != nil {
+		return schemas.ApiKey{}
+	}
+	return apiKey
+}
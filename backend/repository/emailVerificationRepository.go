@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+type EmailVerificationRepository interface {
+	Save(verification schemas.EmailVerification)
+	Delete(verification schemas.EmailVerification)
+	DeleteByUserId(userId uint64)
+	FindByToken(token string) schemas.EmailVerification
+}
+
+type emailVerificationRepository struct {
+	db *schemas.Database
+}
+
+func NewEmailVerificationRepository(conn *gorm.DB) EmailVerificationRepository {
+	err := conn.AutoMigrate(&schemas.EmailVerification{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &emailVerificationRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *emailVerificationRepository) Save(verification schemas.EmailVerification) {
+	err := repo.db.Connection.Create(&verification)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *emailVerificationRepository) Delete(verification schemas.EmailVerification) {
+	err := repo.db.Connection.Delete(&verification)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *emailVerificationRepository) DeleteByUserId(userId uint64) {
+	err := repo.db.Connection.Where(&schemas.EmailVerification{
+		UserId: userId,
+	}).Delete(&schemas.EmailVerification{})
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *emailVerificationRepository) FindByToken(token string) (verification schemas.EmailVerification) {
+	err := repo.db.Connection.Where(&schemas.EmailVerification{
+		Token: token,
+	}).First(&verification)
+
+	if err.Error != nil {
+		return schemas.EmailVerification{}
+	}
+	return verification
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+type MfaRepository interface {
+	Save(mfa schemas.UserMfa)
+	Update(mfa schemas.UserMfa)
+	DeleteByUserId(userId uint64)
+	FindByUserId(userId uint64) schemas.UserMfa
+	SaveRecoveryCodes(codes []schemas.MfaRecoveryCode)
+	FindUnusedRecoveryCodes(userId uint64) []schemas.MfaRecoveryCode
+	UseRecoveryCode(id uint64, usedAt time.Time) bool
+}
+
+type mfaRepository struct {
+	db *schemas.Database
+}
+
+func NewMfaRepository(conn *gorm.DB) MfaRepository {
+	err := conn.AutoMigrate(&schemas.UserMfa{}, &schemas.MfaRecoveryCode{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &mfaRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *mfaRepository) Save(mfa schemas.UserMfa) {
+	err := repo.db.Connection.Create(&mfa)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *mfaRepository) Update(mfa schemas.UserMfa) {
+	err := repo.db.Connection.Model(&schemas.UserMfa{}).Where(&schemas.UserMfa{
+		Id: mfa.Id,
+	}).Updates(map[string]interface{}{
+		"secret":         mfa.Secret,
+		"enabled":        mfa.Enabled,
+		"last_used_step": mfa.LastUsedStep,
+		"confirmed_at":   mfa.ConfirmedAt,
+	})
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+// DeleteByUserId removes the secret and the recovery codes of a user.
+func (repo *mfaRepository) DeleteByUserId(userId uint64) {
+	err := repo.db.Connection.Where(&schemas.MfaRecoveryCode{
+		UserId: userId,
+	}).Delete(&schemas.MfaRecoveryCode{})
+	if err.Error != nil {
+		panic(err.Error)
+	}
+	err = repo.db.Connection.Where(&schemas.UserMfa{
+		UserId: userId,
+	}).Delete(&schemas.UserMfa{})
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *mfaRepository) FindByUserId(userId uint64) (mfa schemas.UserMfa) {
+	err := repo.db.Connection.Where(&schemas.UserMfa{
+		UserId: userId,
+	}).First(&mfa)
+
+	if err.Error != nil {
+		return schemas.UserMfa{}
+	}
+	return mfa
+}
+
+func (repo *mfaRepository) SaveRecoveryCodes(codes []schemas.MfaRecoveryCode) {
+	err := repo.db.Connection.Create(&codes)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *mfaRepository) FindUnusedRecoveryCodes(userId uint64) (codes []schemas.MfaRecoveryCode) {
+	err := repo.db.Connection.Where(&schemas.MfaRecoveryCode{
+		UserId: userId,
+	}).Where("used_at IS NULL").Find(&codes)
+
+	if err.Error != nil {
+		return []schemas.MfaRecoveryCode{}
+	}
+	return codes
+}
+
+// UseRecoveryCode marks a code used and reports whether this call did it,
+// so two concurrent logins can not both spend it.
+func (repo *mfaRepository) UseRecoveryCode(id uint64, usedAt time.Time) bool {
+	result := repo.db.Connection.Model(&schemas.MfaRecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+
+	if result.Error != nil {
+		panic(result.Error)
+	}
+	return result.RowsAffected == 1
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+type PasswordRecoveryRepository interface {
+	Save(recovery schemas.PasswordRecovery)
+	Update(recovery schemas.PasswordRecovery)
+	DeleteByUserId(userId uint64)
+	FindLatestByUserId(userId uint64) schemas.PasswordRecovery
+}
+
+type passwordRecoveryRepository struct {
+	db *schemas.Database
+}
+
+func NewPasswordRecoveryRepository(conn *gorm.DB) PasswordRecoveryRepository {
+	err := conn.AutoMigrate(&schemas.PasswordRecovery{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &passwordRecoveryRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *passwordRecoveryRepository) Save(recovery schemas.PasswordRecovery) {
+	err := repo.db.Connection.Create(&recovery)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *passwordRecoveryRepository) Update(recovery schemas.PasswordRecovery) {
+	err := repo.db.Connection.Model(&schemas.PasswordRecovery{}).Where(&schemas.PasswordRecovery{
+		Id: recovery.Id,
+	}).Updates(map[string]interface{}{
+		"is_validated": recovery.IsValidated,
+		"attempts":     recovery.Attempts,
+	})
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *passwordRecoveryRepository) DeleteByUserId(userId uint64) {
+	err := repo.db.Connection.Where(&schemas.PasswordRecovery{
+		UserId: userId,
+	}).Delete(&schemas.PasswordRecovery{})
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *passwordRecoveryRepository) FindLatestByUserId(userId uint64) (recovery schemas.PasswordRecovery) {
+	err := repo.db.Connection.Where(&schemas.PasswordRecovery{
+		UserId: userId,
+	}).Order("id desc").First(&recovery)
+
+	if err.Error != nil {
+		return schemas.PasswordRecovery{}
+	}
+	return recovery
+}
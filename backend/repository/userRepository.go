@@ -12,6 +12,8 @@ type UserRepository interface {
 	Save(user schemas.User)
 	Update(user schemas.User)
 	Delete(user schemas.User)
+	UpdateEmailVerificationPending(userId uint64, pending bool)
+	UpdateMfaEnabled(userId uint64, enabled bool)
 
 	FindAll() []schemas.User
 	FindById(id uint64) schemas.User
@@ -51,6 +53,26 @@ func (repo *userRepository) Save(user schemas.User) {
 	}
 }
 
+func (r *userRepository) UpdateEmailVerificationPending(userId uint64, pending bool) {
+	err := r.db.Connection.Model(&schemas.User{}).Where(&schemas.User{
+		Id: userId,
+	}).Update("email_verification_pending", pending)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (r *userRepository) UpdateMfaEnabled(userId uint64, enabled bool) {
+	err := r.db.Connection.Model(&schemas.User{}).Where(&schemas.User{
+		Id: userId,
+	}).Update("mfa_enabled", enabled)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
 func (r *userRepository) Update(user schemas.User) {
 	err := r.db.Connection.Where(&schemas.User{
 		Id: user.Id,
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+type UserSecretRepository interface {
+	Save(secret schemas.UserSecret)
+	Update(secret schemas.UserSecret)
+	Delete(secret schemas.UserSecret)
+	FindByUserId(userId uint64) []schemas.UserSecret
+	FindByUserIdAndName(userId uint64, name string) schemas.UserSecret
+}
+
+type userSecretRepository struct {
+	db *schemas.Database
+}
+
+func NewUserSecretRepository(conn *gorm.DB) UserSecretRepository {
+	err := conn.AutoMigrate(&schemas.UserSecret{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &userSecretRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *userSecretRepository) Save(secret schemas.UserSecret) {
+	err := repo.db.Connection.Create(&secret)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *userSecretRepository) Update(secret schemas.UserSecret) {
+	err := repo.db.Connection.Where(&schemas.UserSecret{
+		Id: secret.Id,
+	}).Updates(&secret)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *userSecretRepository) Delete(secret schemas.UserSecret) {
+	err := repo.db.Connection.Delete(&secret)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *userSecretRepository) FindByUserId(userId uint64) (secrets []schemas.UserSecret) {
+	err := repo.db.Connection.Where(&schemas.UserSecret{
+		UserId: userId,
+	}).Order("name").Find(&secrets)
+
+	if err.Error != nil {
+		return []schemas.UserSecret{}
+	}
+	return secrets
+}
+
+func (repo *userSecretRepository) FindByUserIdAndName(userId uint64, name string) (secret schemas.UserSecret) {
+	err := repo.db.Connection.Where(&schemas.UserSecret{
+		UserId: userId,
+		Name:   name,
+	}).First(&secret)
+
+	if err.Error != nil {
+		return schemas.UserSecret{}
+	}
+	return secret
+}
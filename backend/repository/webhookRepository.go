@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"area51/schemas"
+)
+
+// rejectedDeliveriesKept is how many rejected or rate limited deliveries are
+// kept per workflow, the older ones are deleted as new ones come.
+const rejectedDeliveriesKept = 50
+
+type WebhookRepository interface {
+	SaveHook(hook schemas.WebhookHook)
+	FindHookByToken(token string) schemas.WebhookHook
+	FindHookByWorkflowId(workflowId uint64) schemas.WebhookHook
+
+	SaveDelivery(delivery schemas.WebhookDelivery)
+	UpdateDeliveryProcessed(delivery schemas.WebhookDelivery)
+	FindNextPendingDelivery(workflowId uint64) schemas.WebhookDelivery
+	FindAcceptedDeliveryByEventId(workflowId uint64, eventId string) schemas.WebhookDelivery
+	FindDeliveriesByWorkflowId(workflowId uint64, limit int) []schemas.WebhookDelivery
+}
+
+type webhookRepository struct {
+	db *schemas.Database
+}
+
+func NewWebhookRepository(conn *gorm.DB) WebhookRepository {
+	err := conn.AutoMigrate(&schemas.WebhookHook{}, &schemas.WebhookDelivery{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	return &webhookRepository{
+		db: &schemas.Database{
+			Connection: conn,
+		},
+	}
+}
+
+func (repo *webhookRepository) SaveHook(hook schemas.WebhookHook) {
+	err := repo.db.Connection.Omit("Workflow").Create(&hook)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+}
+
+func (repo *webhookRepository) FindHookByToken(token string) (hook schemas.WebhookHook) {
+	err := repo.db.Connection.Where(&schemas.WebhookHook{
+		Token: token,
+	}).First(&hook)
+
+	if err.Error != nil {
+		return schemas.WebhookHook{}
+	}
+	return hook
+}
+
+func (repo *webhookRepository) FindHookByWorkflowId(workflowId uint64) (hook schemas.WebhookHook) {
+	err := repo.db.Connection.Where(&schemas.WebhookHook{
+		WorkflowId: workflowId,
+	}).First(&hook)
+
+	if err.Error != nil {
+		return schemas.WebhookHook{}
+	}
+	return hook
+}
+
+func (repo *webhookRepository) SaveDelivery(delivery schemas.WebhookDelivery) {
+	err := repo.db.Connection.Omit("Workflow").Create(&delivery)
+
+	if err.Error != nil {
+		panic(err.Error)
+	}
+	if delivery.Status != schemas.WebhookDeliveryAccepted {
+		repo.pruneRejectedDeliveries(delivery.WorkflowId)
+	}
+}
+
+func (repo *webhookRepository) pruneRejectedDeliveries(workflowId uint64) {
+	kept := repo.db.Connection.Model(&schemas.WebhookDelivery{}).
+		Select("id").
+		Where("workflow_id = ? AND status <> ?", workflowId, schemas.WebhookDeliveryAccepted).
+		Order("id desc").
+		Limit(rejectedDeliveriesKept)
+	err := repo.db.Connection.
+		Where("workflow_id = ? AND status <> ? AND id NOT IN (?)", workflowId, schemas.WebhookDeliveryAccepted, kept).
+		Delete(&schemas.WebhookDelivery{})
+
+	if err.Error != nil {
+		return
+	}
+}
+
+func (repo *webhookRepository) UpdateDeliveryProcessed(delivery schemas.WebhookDelivery) {
+	err := repo.db.Connection.Model(&schemas.WebhookDelivery{}).Where(&schemas.WebhookDelivery{Id: delivery.Id}).Updates(map[string]interface{}{
+		"processed": delivery.Processed,
+	})
+	if err.Error != nil {
+		return
+	}
+}
+
+func (repo *webhookRepository) FindNextPendingDelivery(workflowId uint64) (delivery schemas.WebhookDelivery) {
+	err := repo.db.Connection.Where(map[string]interface{}{
+		"workflow_id": workflowId,
+		"status":      schemas.WebhookDeliveryAccepted,
+		"processed":   false,
+	}).Order("id").First(&delivery)
+
+	if err.Error != nil {
+		return schemas.WebhookDelivery{}
+	}
+	return delivery
+}
+
+func (repo *webhookRepository) FindAcceptedDeliveryByEventId(workflowId uint64, eventId string) (delivery schemas.WebhookDelivery) {
+	err := repo.db.Connection.Where(map[string]interface{}{
+		"workflow_id": workflowId,
+		"status":      schemas.WebhookDeliveryAccepted,
+		"event_id":    eventId,
+	}).First(&delivery)
+
+	if err.Error != nil {
+		return schemas.WebhookDelivery{}
+	}
+	return delivery
+}
+
+func (repo *webhookRepository) FindDeliveriesByWorkflowId(workflowId uint64, limit int) (deliveries []schemas.WebhookDelivery) {
+	err := repo.db.Connection.Where(&schemas.WebhookDelivery{
+		WorkflowId: workflowId,
+	}).Order("id desc").Limit(limit).Find(&deliveries)
+
+	if err.Error != nil {
+		return []schemas.WebhookDelivery{}
+	}
+	return deliveries
+}
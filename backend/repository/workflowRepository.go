@@ -14,6 +14,7 @@ type WorkflowRepository interface {
 	UpdateUtils(workflow schemas.Workflow)
 	UpdateActiveStatus(workflow schemas.Workflow)
 	UpdateReactionTrigger(workflow schemas.Workflow)
+	UpdateEvent(workflow schemas.Workflow)
 	Delete(workflowId uint64) error
 
 	FindAll() []schemas.Workflow
@@ -203,3 +204,12 @@ func (repo *workflowRepository) UpdateUtils(workflow schemas.Workflow) {
 		return
 	}
 }
+
+func (repo *workflowRepository) UpdateEvent(workflow schemas.Workflow) {
+	err := repo.db.Connection.Model(&schemas.Workflow{}).Where(&schemas.Workflow{Id: workflow.Id}).Updates(map[string]interface{}{
+		"event": workflow.Event,
+	})
+	if err.Error != nil {
+		return
+	}
+}
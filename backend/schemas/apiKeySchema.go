@@ -0,0 +1,59 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+// ApiKeyPrefix starts every personal API key so the authorization middleware
+// can tell them apart from JWTs.
+const ApiKeyPrefix = "a51_"
+
+const (
+	ApiKeyScopeRead  = "read"
+	ApiKeyScopeWrite = "write"
+)
+
+var ApiKeyScopes = []string{ApiKeyScopeRead, ApiKeyScopeWrite}
+
+// ApiKey is a personal API key. Only the SHA-256 of the key is stored, the key
+// itself is given once at creation; Prefix keeps its first characters so the
+// user can recognise it in the list.
+type ApiKey struct {
+	Id         uint64     `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId     uint64     `json:"-" gorm:"uniqueIndex:idx_user_api_key_name"`
+	User       User       `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Name       string     `json:"name" gorm:"type:varchar(64);uniqueIndex:idx_user_api_key_name"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16)"`
+	Hash       string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Scopes     string     `json:"scopes" gorm:"type:varchar(64)"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type ApiKeyCreation struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required"`
+}
+
+type ApiKeyName struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ApiKeyCreated is the only response carrying the key in clear.
+type ApiKeyCreated struct {
+	ApiKey ApiKey `json:"api_key"`
+	Key    string `json:"key"`
+}
+
+var (
+	ErrApiKeyNotFound      = errors.New("api key not found")
+	ErrApiKeyExpired       = errors.New("api key expired")
+	ErrApiKeyScope         = errors.New("api key scope does not allow this request")
+	ErrApiKeyForbidden     = errors.New("api keys are not accepted on this route")
+	ErrInvalidApiKeyName   = errors.New("api key name must be 1 to 64 letters, digits, '.', '_' or '-'")
+	ErrInvalidApiKeyScopes = errors.New("api key scopes must be among read and write")
+	ErrInvalidApiKeyExpiry = errors.New("api key expiry must be between 1 and 365 days")
+)
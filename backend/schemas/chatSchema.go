@@ -0,0 +1,111 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type ChatReaction string
+
+const (
+	DiscordPostMessageReaction    ChatReaction = "discord_post_message"
+	SlackPostMessageReaction      ChatReaction = "slack_post_message"
+	MattermostPostMessageReaction ChatReaction = "mattermost_post_message"
+)
+
+// ChatWebhookTarget is shared by the chat reactions: the incoming webhook
+// URL is given as is or through the name of one of the user secrets.
+type ChatWebhookTarget struct {
+	WebhookUrl    string `json:"webhook_url,omitempty"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+type DiscordPostMessageOptions struct {
+	ChatWebhookTarget
+	Content   string              `json:"content"`
+	Username  string              `json:"username,omitempty"`
+	AvatarUrl string              `json:"avatar_url,omitempty"`
+	Embed     *DiscordEmbedOption `json:"embed,omitempty"`
+}
+
+type DiscordEmbedOption struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Url         string `json:"url,omitempty"`
+	Color       int    `json:"color,omitempty"`
+}
+
+type DiscordWebhookPayload struct {
+	Content   string               `json:"content,omitempty"`
+	Username  string               `json:"username,omitempty"`
+	AvatarUrl string               `json:"avatar_url,omitempty"`
+	Embeds    []DiscordEmbedOption `json:"embeds,omitempty"`
+}
+
+type SlackPostMessageOptions struct {
+	ChatWebhookTarget
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	LinkUrl  string `json:"link_url,omitempty"`
+	LinkText string `json:"link_text,omitempty"`
+}
+
+type SlackWebhookPayload struct {
+	Text   string       `json:"text"`
+	Blocks []SlackBlock `json:"blocks,omitempty"`
+}
+
+type SlackBlock struct {
+	Type     string        `json:"type"`
+	Text     *SlackText    `json:"text,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type SlackButton struct {
+	Type string    `json:"type"`
+	Text SlackText `json:"text"`
+	Url  string    `json:"url"`
+}
+
+type MattermostPostMessageOptions struct {
+	ChatWebhookTarget
+	Text       string                      `json:"text"`
+	Channel    string                      `json:"channel,omitempty"`
+	Username   string                      `json:"username,omitempty"`
+	IconUrl    string                      `json:"icon_url,omitempty"`
+	Attachment *MattermostAttachmentOption `json:"attachment,omitempty"`
+}
+
+type MattermostAttachmentOption struct {
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link,omitempty"`
+	Text      string `json:"text"`
+	Color     string `json:"color,omitempty"`
+}
+
+type MattermostWebhookPayload struct {
+	Text        string                       `json:"text,omitempty"`
+	Channel     string                       `json:"channel,omitempty"`
+	Username    string                       `json:"username,omitempty"`
+	IconUrl     string                       `json:"icon_url,omitempty"`
+	Attachments []MattermostAttachmentOption `json:"attachments,omitempty"`
+}
+
+// ChatWebhookResponse is the delivery result saved in ReactionResponseData.
+type ChatWebhookResponse struct {
+	Platform   ServiceName     `json:"platform"`
+	Delivered  bool            `json:"delivered"`
+	StatusCode int             `json:"status_code"`
+	Attempts   int             `json:"attempts"`
+	Body       json.RawMessage `json:"body"`
+	Error      string          `json:"error,omitempty"`
+}
+
+var (
+	ErrChatWebhookMissing = errors.New("webhook_url or webhook_secret must be set")
+)
@@ -0,0 +1,54 @@
+package schemas
+
+import "errors"
+
+type EmailReaction string
+
+const (
+	EmailSendReaction EmailReaction = "send_email"
+)
+
+const (
+	SmtpSecurityNone     = "none"
+	SmtpSecurityStartTls = "starttls"
+	SmtpSecurityTls      = "tls"
+)
+
+type EmailSendOptions struct {
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	TextBody string `json:"text_body"`
+	HtmlBody string `json:"html_body"`
+}
+
+type EmailSendResponse struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Sent    bool     `json:"sent"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// MailMessage is a mail handed to a MailSender. At least one of TextBody
+// and HtmlBody is set, both make a multipart/alternative mail.
+type MailMessage struct {
+	To       []string
+	Subject  string
+	TextBody string
+	HtmlBody string
+}
+
+// SmtpConfig is read from the SMTP_* environment variables.
+type SmtpConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Security string
+}
+
+var (
+	ErrMailNotConfigured = errors.New("SMTP_HOST and SMTP_FROM must be set to send emails")
+	ErrMailNoRecipient   = errors.New("email needs at least one recipient")
+	ErrMailEmptyBody     = errors.New("email needs a text or an html body")
+)
@@ -0,0 +1,25 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+// EmailVerification is the pending verification of the email given at
+// registration. Token holds the SHA-256 of the token mailed in the link.
+type EmailVerification struct {
+	Id        uint64    `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId    uint64    `json:"-" gorm:"uniqueIndex"`
+	User      User      `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Token     string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrVerificationRateLimited  = errors.New("too many verification mails, try again later")
+	ErrVerificationMail         = errors.New("verification mail could not be sent")
+)
@@ -0,0 +1,192 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+type GitlabAction string
+
+const (
+	GitlabNewMergeRequestAction GitlabAction = "gitlab_new_merge_request"
+	GitlabPipelineFailedAction  GitlabAction = "gitlab_pipeline_failed"
+	GitlabNewTagAction          GitlabAction = "gitlab_new_tag"
+)
+
+type GitlabReaction string
+
+const (
+	GitlabCreateIssueReaction         GitlabReaction = "gitlab_create_issue"
+	GitlabCommentMergeRequestReaction GitlabReaction = "gitlab_comment_merge_request"
+)
+
+// GitlabHookEvents maps each action to the object_kind of the hook requests
+// it waits for.
+var GitlabHookEvents = map[string]string{
+	string(GitlabNewMergeRequestAction): "merge_request",
+	string(GitlabPipelineFailedAction):  "pipeline",
+	string(GitlabNewTagAction):          "tag_push",
+}
+
+type GitlabResponseToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type GitlabUserInfo struct {
+	Id        uint64 `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarUrl string `json:"avatar_url"`
+	WebUrl    string `json:"web_url"`
+}
+
+// GitlabWebhookOptions is shared by the GitLab actions: Project is the path
+// (group/project) or the id of the project and SecretName the user secret
+// GitLab sends back in X-Gitlab-Token.
+type GitlabWebhookOptions struct {
+	Project    string `json:"project"`
+	SecretName string `json:"secret_name"`
+	Ref        string `json:"ref,omitempty"`
+}
+
+// GitlabHookState is kept in Workflow.Utils once the project hook pointing
+// to the workflow has been created on GitLab.
+type GitlabHookState struct {
+	Project     string    `json:"project"`
+	HookId      uint64    `json:"hook_id"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type GitlabProjectHook struct {
+	Url                   string `json:"url"`
+	Token                 string `json:"token"`
+	PushEvents            bool   `json:"push_events"`
+	MergeRequestsEvents   bool   `json:"merge_requests_events"`
+	PipelineEvents        bool   `json:"pipeline_events"`
+	TagPushEvents         bool   `json:"tag_push_events"`
+	EnableSslVerification bool   `json:"enable_ssl_verification"`
+}
+
+type GitlabProjectHookResponse struct {
+	Id  uint64 `json:"id"`
+	Url string `json:"url"`
+}
+
+type GitlabWebhookProject struct {
+	Id                uint64 `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebUrl            string `json:"web_url"`
+}
+
+type GitlabWebhookUser struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// GitlabWebhookPayload holds the fields of the merge request, pipeline and
+// tag push hook bodies used by the actions.
+type GitlabWebhookPayload struct {
+	ObjectKind       string               `json:"object_kind"`
+	Ref              string               `json:"ref"`
+	Before           string               `json:"before"`
+	After            string               `json:"after"`
+	CheckoutSha      string               `json:"checkout_sha"`
+	Message          string               `json:"message"`
+	UserUsername     string               `json:"user_username"`
+	UserName         string               `json:"user_name"`
+	User             GitlabWebhookUser    `json:"user"`
+	Project          GitlabWebhookProject `json:"project"`
+	ObjectAttributes struct {
+		Id           uint64 `json:"id"`
+		Iid          uint64 `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		Action       string `json:"action"`
+		State        string `json:"state"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		Url          string `json:"url"`
+		Ref          string `json:"ref"`
+		Sha          string `json:"sha"`
+		Status       string `json:"status"`
+		Source       string `json:"source"`
+	} `json:"object_attributes"`
+	Builds []struct {
+		Name   string `json:"name"`
+		Stage  string `json:"stage"`
+		Status string `json:"status"`
+	} `json:"builds"`
+}
+
+type GitlabMergeRequestEvent struct {
+	Project      string `json:"project"`
+	Iid          uint64 `json:"iid"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Author       string `json:"author"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Url          string `json:"url"`
+}
+
+type GitlabPipelineEvent struct {
+	Project    string   `json:"project"`
+	Id         uint64   `json:"id"`
+	Ref        string   `json:"ref"`
+	Sha        string   `json:"sha"`
+	Source     string   `json:"source"`
+	Author     string   `json:"author"`
+	FailedJobs []string `json:"failed_jobs"`
+	Url        string   `json:"url"`
+}
+
+type GitlabTagEvent struct {
+	Project string `json:"project"`
+	Tag     string `json:"tag"`
+	Sha     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	Url     string `json:"url"`
+}
+
+type GitlabCreateIssueOptions struct {
+	Project     string `json:"project"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Labels      string `json:"labels,omitempty"`
+}
+
+type GitlabCommentMergeRequestOptions struct {
+	Project         string `json:"project"`
+	MergeRequestIid string `json:"merge_request_iid"`
+	Body            string `json:"body"`
+}
+
+type GitlabCreatedObject struct {
+	Id     uint64 `json:"id"`
+	Iid    uint64 `json:"iid"`
+	WebUrl string `json:"web_url"`
+}
+
+// GitlabReactionResponse is saved in ReactionResponseData by the GitLab
+// reactions.
+type GitlabReactionResponse struct {
+	Project string `json:"project"`
+	Id      uint64 `json:"id,omitempty"`
+	Iid     uint64 `json:"iid,omitempty"`
+	WebUrl  string `json:"web_url,omitempty"`
+	Done    bool   `json:"done"`
+	Error   string `json:"error,omitempty"`
+}
+
+var (
+	ErrGitlabTokenNotFound = errors.New("no gitlab token for this user")
+)
@@ -1,15 +1,27 @@
 package schemas
 
+import (
+	"errors"
+	"time"
+)
+
 type GoogleAction string
 
 const (
-	GoogleGetEmailAction GoogleAction = "get_email_action"
+	GoogleGetEmailAction     GoogleAction = "get_email_action"
+	GoogleNewEmailAction     GoogleAction = "new_email_matching_query"
+	GoogleEventSoonAction    GoogleAction = "event_starting_soon"
+	GoogleDriveNewFileAction GoogleAction = "drive_new_file_in_folder"
 )
 
 type GoogleReaction string
 
 const (
-	GoogleCreateEventReaction GoogleReaction = "create_event_reaction"
+	GoogleCreateEventReaction     GoogleReaction = "create_event_reaction"
+	GoogleSendEmailReaction       GoogleReaction = "send_email_gmail"
+	GoogleApplyLabelReaction      GoogleReaction = "apply_label"
+	GoogleSheetsAppendRowReaction GoogleReaction = "sheets_append_row"
+	GoogleDriveUploadReaction     GoogleReaction = "drive_upload_file"
 )
 
 type GoogleResponseToken struct {
@@ -85,3 +97,224 @@ type GoogleCalendarResponse struct {
 		Id string `json:"id"`
 	} `json:"items"`
 }
+
+type GmailNewEmailOptions struct {
+	Query string `json:"query"`
+}
+
+// GmailNewEmailState is kept in Workflow.Utils: the history id the next
+// check starts from and the matching mails not handed to the reaction yet.
+type GmailNewEmailState struct {
+	HistoryId string              `json:"history_id"`
+	Pending   []GmailMessageEvent `json:"pending"`
+}
+
+// GmailMessageEvent is the event handed to the reaction for a new mail.
+type GmailMessageEvent struct {
+	Id       string   `json:"id"`
+	ThreadId string   `json:"thread_id"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Subject  string   `json:"subject"`
+	Date     string   `json:"date"`
+	Snippet  string   `json:"snippet"`
+	LabelIds []string `json:"label_ids"`
+}
+
+type GmailApplyLabelOptions struct {
+	Label     string `json:"label"`
+	MessageId string `json:"message_id"`
+}
+
+type GmailProfile struct {
+	EmailAddress string `json:"emailAddress"`
+	HistoryId    string `json:"historyId"`
+}
+
+type GmailHistoryResponse struct {
+	History []struct {
+		MessagesAdded []struct {
+			Message GmailMessageReference `json:"message"`
+		} `json:"messagesAdded"`
+	} `json:"history"`
+	HistoryId     string `json:"historyId"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+type GmailMessageReference struct {
+	Id       string `json:"id"`
+	ThreadId string `json:"threadId"`
+}
+
+type GmailMessageListResponse struct {
+	Messages      []GmailMessageReference `json:"messages"`
+	NextPageToken string                  `json:"nextPageToken"`
+}
+
+type GmailMessage struct {
+	Id       string   `json:"id"`
+	ThreadId string   `json:"threadId"`
+	LabelIds []string `json:"labelIds"`
+	Snippet  string   `json:"snippet"`
+	Payload  struct {
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+	} `json:"payload"`
+}
+
+type GmailLabel struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type GmailLabelListResponse struct {
+	Labels []GmailLabel `json:"labels"`
+}
+
+// GmailReactionResponse is saved in ReactionResponseData by the Gmail
+// reactions.
+type GmailReactionResponse struct {
+	MessageId string `json:"message_id,omitempty"`
+	Label     string `json:"label,omitempty"`
+	To        string `json:"to,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+type GoogleEventSoonOptions struct {
+	CalendarId      string `json:"calendar_id"`
+	MinutesBefore   int    `json:"minutes_before"`
+	SummaryContains string `json:"summary_contains,omitempty"`
+}
+
+// GoogleEventSoonState is kept in Workflow.Utils. Notified maps the id and
+// start of each notified occurrence to its start, so a moved event is
+// notified again and old entries can be dropped.
+type GoogleEventSoonState struct {
+	Notified map[string]time.Time   `json:"notified"`
+	Pending  []GoogleEventSoonEvent `json:"pending"`
+}
+
+// GoogleEventSoonEvent is the event handed to the reaction.
+type GoogleEventSoonEvent struct {
+	Id               string `json:"id"`
+	RecurringEventId string `json:"recurring_event_id,omitempty"`
+	CalendarId       string `json:"calendar_id"`
+	Summary          string `json:"summary"`
+	Description      string `json:"description"`
+	Location         string `json:"location"`
+	Start            string `json:"start"`
+	End              string `json:"end"`
+	AllDay           bool   `json:"all_day"`
+	TimeZone         string `json:"time_zone"`
+	MinutesBefore    int    `json:"minutes_before"`
+	HtmlLink         string `json:"html_link"`
+}
+
+type GoogleCalendarEventTime struct {
+	Date     string `json:"date"`
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type GoogleCalendarEventsResponse struct {
+	TimeZone      string `json:"timeZone"`
+	NextPageToken string `json:"nextPageToken"`
+	Items         []struct {
+		Id               string                  `json:"id"`
+		Status           string                  `json:"status"`
+		RecurringEventId string                  `json:"recurringEventId"`
+		Summary          string                  `json:"summary"`
+		Description      string                  `json:"description"`
+		Location         string                  `json:"location"`
+		HtmlLink         string                  `json:"htmlLink"`
+		Start            GoogleCalendarEventTime `json:"start"`
+		End              GoogleCalendarEventTime `json:"end"`
+	} `json:"items"`
+}
+
+type GoogleSheetsAppendRowOptions struct {
+	SpreadsheetId string   `json:"spreadsheet_id"`
+	Sheet         string   `json:"sheet"`
+	Values        []string `json:"values"`
+	ParseValues   bool     `json:"parse_values"`
+}
+
+type GoogleSheetsAppendResponse struct {
+	Updates struct {
+		UpdatedRange string `json:"updatedRange"`
+		UpdatedRows  int    `json:"updatedRows"`
+	} `json:"updates"`
+}
+
+type GoogleDriveUploadOptions struct {
+	FolderId  string `json:"folder_id"`
+	Name      string `json:"name"`
+	Content   string `json:"content,omitempty"`
+	SourceUrl string `json:"source_url,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+}
+
+type GoogleDriveNewFileOptions struct {
+	FolderId string `json:"folder_id"`
+	MimeType string `json:"mime_type,omitempty"`
+}
+
+// GoogleDriveNewFileState is kept in Workflow.Utils: files created after
+// LastCreatedTime are new, SeenIds are the ones created exactly at it.
+type GoogleDriveNewFileState struct {
+	LastCreatedTime string                 `json:"last_created_time"`
+	SeenIds         []string               `json:"seen_ids"`
+	Pending         []GoogleDriveFileEvent `json:"pending"`
+}
+
+// GoogleDriveFileEvent is the event handed to the reaction for a new file.
+type GoogleDriveFileEvent struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	MimeType    string `json:"mime_type"`
+	FolderId    string `json:"folder_id"`
+	CreatedTime string `json:"created_time"`
+	Size        string `json:"size,omitempty"`
+	WebViewLink string `json:"web_view_link"`
+	Owner       string `json:"owner,omitempty"`
+}
+
+type GoogleDriveFile struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	MimeType    string `json:"mimeType"`
+	CreatedTime string `json:"createdTime"`
+	Size        string `json:"size"`
+	WebViewLink string `json:"webViewLink"`
+	Owners      []struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	} `json:"owners"`
+}
+
+type GoogleDriveFileListResponse struct {
+	Files         []GoogleDriveFile `json:"files"`
+	NextPageToken string            `json:"nextPageToken"`
+}
+
+// GoogleDriveReactionResponse is saved in ReactionResponseData by the Drive
+// and Sheets reactions.
+type GoogleDriveReactionResponse struct {
+	SpreadsheetId string   `json:"spreadsheet_id,omitempty"`
+	UpdatedRange  string   `json:"updated_range,omitempty"`
+	Values        []string `json:"values,omitempty"`
+	FileId        string   `json:"file_id,omitempty"`
+	FileName      string   `json:"file_name,omitempty"`
+	WebViewLink   string   `json:"web_view_link,omitempty"`
+	Done          bool     `json:"done"`
+	Error         string   `json:"error,omitempty"`
+}
+
+var (
+	ErrGoogleTokenNotFound = errors.New("no google token for this user")
+	ErrGoogleScopeMissing  = errors.New("the google account must be connected again to grant the missing permissions")
+)
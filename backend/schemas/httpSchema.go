@@ -0,0 +1,28 @@
+package schemas
+
+import "encoding/json"
+
+type HttpReaction string
+
+const (
+	HttpRequestReaction HttpReaction = "http_request"
+)
+
+type HttpRequestOptions struct {
+	Method         string            `json:"method"`
+	Url            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`
+	SecretHeaders  map[string]string `json:"secret_headers"`
+	Body           string            `json:"body"`
+	ExpectedStatus []int             `json:"expected_status"`
+	Timeout        int               `json:"timeout"`
+}
+
+type HttpRequestResponse struct {
+	Method     string          `json:"method"`
+	Url        string          `json:"url"`
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body"`
+	Expected   bool            `json:"expected"`
+	Error      string          `json:"error,omitempty"`
+}
@@ -1,5 +1,7 @@
 package schemas
 
+import "time"
+
 type InterpolAction string
 
 const (
@@ -38,13 +40,39 @@ type InterpolNotice struct {
 	PlaceOfBirth        *string     `json:"place_of_birth"`
 }
 
+type InterpolLink struct {
+	Href string `json:"href"`
+}
+
+type InterpolNoticeLinks struct {
+	Self      *InterpolLink `json:"self"`
+	Images    *InterpolLink `json:"images"`
+	Thumbnail *InterpolLink `json:"thumbnail"`
+	Next      *InterpolLink `json:"next"`
+}
+
+type InterpolListedNotice struct {
+	InterpolNotice
+	Links InterpolNoticeLinks `json:"_links"`
+}
+
 type InterpolEmbedded struct {
-	Notices []InterpolNotice `json:"notices"`
+	Notices []InterpolListedNotice `json:"notices"`
 }
 
 type InterpolNoticesList struct {
-	Total    uint64           `json:"total"`
-	Embedded InterpolEmbedded `json:"_embedded"`
+	Total    uint64              `json:"total"`
+	Embedded InterpolEmbedded    `json:"_embedded"`
+	Links    InterpolNoticeLinks `json:"_links"`
+}
+
+type InterpolImagesList struct {
+	Embedded struct {
+		Images []struct {
+			PictureId string              `json:"picture_id"`
+			Links     InterpolNoticeLinks `json:"_links"`
+		} `json:"images"`
+	} `json:"_embedded"`
 }
 
 type InterpolReactionOption struct {
@@ -63,9 +91,39 @@ type InterpolRedNoticesInfos struct {
 }
 
 type InterpolActionOptions struct {
-	SexId string `json:"sexId"`
+	SexId       string `json:"sexId,omitempty"`
+	Nationality string `json:"nationality,omitempty"`
+	AgeMin      int    `json:"age_min,omitempty"`
+	AgeMax      int    `json:"age_max,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Forename    string `json:"forename,omitempty"`
 }
 
-type InterpolActionOptionsInfo struct {
-	Total uint64 `json:"total"`
+// InterpolNoticeState is kept in Workflow.Utils with the entity ids of the
+// notices already seen for the filters of the workflow.
+type InterpolNoticeState struct {
+	Initialized bool                  `json:"initialized"`
+	CheckedAt   time.Time             `json:"checked_at"`
+	SeenIds     []string              `json:"seen_ids"`
+	Pending     []InterpolNoticeEvent `json:"pending"`
+}
+
+// InterpolNoticeEvent is the event handed to the reaction for a new notice,
+// with the detail of the notice and its images.
+type InterpolNoticeEvent struct {
+	EntityId            string     `json:"entity_id"`
+	Name                string     `json:"name"`
+	Forename            string     `json:"forename"`
+	DateOfBirth         string     `json:"date_of_birth"`
+	SexId               string     `json:"sex_id"`
+	Nationalities       []string   `json:"nationalities"`
+	PlaceOfBirth        string     `json:"place_of_birth"`
+	CountryOfBirthId    string     `json:"country_of_birth_id"`
+	DistinguishingMarks string     `json:"distinguishing_marks"`
+	Height              uint32     `json:"height"`
+	Weight              uint32     `json:"weight"`
+	ArrestWarrants      []Warrants `json:"arrest_warrants"`
+	Url                 string     `json:"url"`
+	Thumbnail           string     `json:"thumbnail"`
+	Images              []string   `json:"images"`
 }
@@ -0,0 +1,131 @@
+package schemas
+
+import (
+	"errors"
+	"strings"
+)
+
+const JsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JsonSchema is the subset of JSON Schema used to describe action and
+// reaction options. It is served as is in /about.json.
+type JsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Properties  map[string]*JsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JsonSchema            `json:"items,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	MinLength   *int                   `json:"minLength,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Default     interface{}            `json:"default,omitempty"`
+}
+
+func ObjectSchema(description string, properties map[string]*JsonSchema, required ...string) *JsonSchema {
+	return &JsonSchema{
+		Type:        "object",
+		Description: description,
+		Properties:  properties,
+		Required:    required,
+	}
+}
+
+// OptionsSchema is the root schema of an action or reaction option object.
+func OptionsSchema(properties map[string]*JsonSchema, required ...string) JsonSchema {
+	schema := ObjectSchema("", properties, required...)
+	schema.Schema = JsonSchemaDraft
+	return *schema
+}
+
+func StringSchema(description string) *JsonSchema {
+	return &JsonSchema{Type: "string", Description: description}
+}
+
+func EnumSchema(description string, values ...string) *JsonSchema {
+	schema := StringSchema(description)
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+func IntegerSchema(description string) *JsonSchema {
+	return &JsonSchema{Type: "integer", Description: description}
+}
+
+func NumberSchema(description string) *JsonSchema {
+	return &JsonSchema{Type: "number", Description: description}
+}
+
+func BooleanSchema(description string) *JsonSchema {
+	return &JsonSchema{Type: "boolean", Description: description}
+}
+
+func ArraySchema(description string, items *JsonSchema) *JsonSchema {
+	return &JsonSchema{Type: "array", Description: description, Items: items}
+}
+
+func (schema *JsonSchema) NonEmpty() *JsonSchema {
+	minLength := 1
+	schema.MinLength = &minLength
+	return schema
+}
+
+func (schema *JsonSchema) WithMinimum(minimum float64) *JsonSchema {
+	schema.Minimum = &minimum
+	return schema
+}
+
+func (schema *JsonSchema) WithMaximum(maximum float64) *JsonSchema {
+	schema.Maximum = &maximum
+	return schema
+}
+
+func (schema *JsonSchema) WithPattern(pattern string) *JsonSchema {
+	schema.Pattern = pattern
+	return schema
+}
+
+func (schema *JsonSchema) WithFormat(format string) *JsonSchema {
+	schema.Format = format
+	return schema
+}
+
+func (schema *JsonSchema) WithDefault(value interface{}) *JsonSchema {
+	schema.Default = value
+	return schema
+}
+
+type OptionFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type OptionsValidationError struct {
+	Errors []OptionFieldError
+}
+
+func (err *OptionsValidationError) Error() string {
+	messages := []string{}
+	for _, fieldError := range err.Errors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return ErrInvalidOptions.Error() + ": " + strings.Join(messages, ", ")
+}
+
+func (err *OptionsValidationError) Unwrap() error {
+	return ErrInvalidOptions
+}
+
+type OptionsErrorResponse struct {
+	Message string             `json:"message"`
+	Errors  []OptionFieldError `json:"errors"`
+}
+
+var (
+	ErrInvalidOptions = errors.New("invalid options")
+)
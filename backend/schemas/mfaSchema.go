@@ -0,0 +1,70 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+const MfaIssuer = "Area51"
+
+// UserMfa is the TOTP secret of a user, encrypted with SECRETS_KEY. It is
+// only enforced at login once Enabled, i.e. after the confirmation step.
+type UserMfa struct {
+	Id           uint64     `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId       uint64     `json:"-" gorm:"uniqueIndex"`
+	User         User       `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Secret       string     `json:"-" gorm:"type:text"`
+	Enabled      bool       `json:"enabled" gorm:"type:boolean"`
+	LastUsedStep int64      `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// MfaRecoveryCode replaces a TOTP code once. Code holds its bcrypt hash.
+type MfaRecoveryCode struct {
+	Id     uint64     `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId uint64     `json:"-" gorm:"index"`
+	User   User       `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Code   string     `json:"-" gorm:"type:varchar(100)"`
+	UsedAt *time.Time `json:"used_at"`
+}
+
+type MfaEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+type MfaCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MfaRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MfaLoginCredentials struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MfaPendingResponse answers a valid password when the second step is needed.
+type MfaPendingResponse struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+}
+
+type MfaReset struct {
+	UserId uint64 `json:"user_id" binding:"required"`
+}
+
+var (
+	ErrMfaRequired       = errors.New("two-factor code required")
+	ErrMfaPendingToken   = errors.New("two-factor login not completed")
+	ErrInvalidMfaCode    = errors.New("invalid two-factor code")
+	ErrMfaNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrMfaAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMfaNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMfaRateLimited    = errors.New("too many two-factor attempts, try again later")
+	ErrMfaForbidden      = errors.New("two-factor authentication can only be managed with a session")
+	ErrAdminRequired     = errors.New("admin rights required")
+)
@@ -1,16 +1,26 @@
 package schemas
 
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
 type MicrosoftAction string
 
 const (
 	MicrosoftOutlookEventsAction MicrosoftAction = "get_outlook_events"
 	MicrosoftTeamGroup           MicrosoftAction = "modify_team_group"
+	MicrosoftNewMailAction       MicrosoftAction = "new_outlook_mail"
 )
 
 type MicrosoftReaction string
 
 const (
-	MicrosoftMailReaction MicrosoftReaction = "send_mail"
+	MicrosoftMailReaction                MicrosoftReaction = "send_mail"
+	MicrosoftTeamsChatMessageReaction    MicrosoftReaction = "teams_post_chat_message"
+	MicrosoftTeamsChannelMessageReaction MicrosoftReaction = "teams_post_channel_message"
+	MicrosoftTodoTaskReaction            MicrosoftReaction = "todo_create_task"
 )
 
 type MicrosoftUserInfo struct {
@@ -25,8 +35,142 @@ type MicrosoftResponseToken struct {
 	TokenType    string `json:"token_type"`
 }
 
+type MicrosoftOutlookEventChangeType string
+
+const (
+	MicrosoftEventCreated   MicrosoftOutlookEventChangeType = "created"
+	MicrosoftEventUpdated   MicrosoftOutlookEventChangeType = "updated"
+	MicrosoftEventCancelled MicrosoftOutlookEventChangeType = "cancelled"
+)
+
 type MicrosoftOutlookEventsOptions struct {
-	Subject string `json:"subject"`
+	Subject     string                            `json:"subject,omitempty"`
+	ChangeTypes []MicrosoftOutlookEventChangeType `json:"change_types,omitempty"`
+	DaysAhead   int                               `json:"days_ahead,omitempty"`
+}
+
+// MicrosoftOutlookEventsState is kept in Workflow.Utils. The delta link
+// covers the calendar view from WindowStart to WindowEnd, Known holds the
+// events seen in it to tell creations from updates.
+type MicrosoftOutlookEventsState struct {
+	DeltaLink   string                                `json:"delta_link"`
+	WindowStart time.Time                             `json:"window_start"`
+	WindowEnd   time.Time                             `json:"window_end"`
+	Known       map[string]MicrosoftOutlookKnownEvent `json:"known"`
+	Pending     []MicrosoftOutlookEventChange         `json:"pending"`
+}
+
+type MicrosoftOutlookKnownEvent struct {
+	ChangeKey string                      `json:"change_key"`
+	Event     MicrosoftOutlookEventChange `json:"event"`
+}
+
+// MicrosoftOutlookEventChange is the event handed to the reaction, times
+// are in UTC.
+type MicrosoftOutlookEventChange struct {
+	ChangeType     MicrosoftOutlookEventChangeType `json:"change_type"`
+	Id             string                          `json:"id"`
+	Subject        string                          `json:"subject"`
+	Organizer      string                          `json:"organizer"`
+	OrganizerEmail string                          `json:"organizer_email"`
+	Start          string                          `json:"start"`
+	End            string                          `json:"end"`
+	IsAllDay       bool                            `json:"is_all_day"`
+	Location       string                          `json:"location"`
+	WebLink        string                          `json:"web_link"`
+}
+
+type MicrosoftDateTimeTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type MicrosoftEmailAddress struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type MicrosoftRecipient struct {
+	EmailAddress MicrosoftEmailAddress `json:"emailAddress"`
+}
+
+type MicrosoftCalendarEvent struct {
+	Id          string                    `json:"id"`
+	ChangeKey   string                    `json:"changeKey"`
+	Subject     string                    `json:"subject"`
+	IsCancelled bool                      `json:"isCancelled"`
+	IsAllDay    bool                      `json:"isAllDay"`
+	Start       MicrosoftDateTimeTimeZone `json:"start"`
+	End         MicrosoftDateTimeTimeZone `json:"end"`
+	Location    struct {
+		DisplayName string `json:"displayName"`
+	} `json:"location"`
+	Organizer MicrosoftRecipient `json:"organizer"`
+	WebLink   string             `json:"webLink"`
+	Removed   *struct {
+		Reason string `json:"reason"`
+	} `json:"@removed"`
+}
+
+// MicrosoftDeltaResponse is a page of a delta query: either NextLink to
+// continue or DeltaLink to use on the next check.
+type MicrosoftDeltaResponse struct {
+	Value     []json.RawMessage `json:"value"`
+	NextLink  string            `json:"@odata.nextLink"`
+	DeltaLink string            `json:"@odata.deltaLink"`
+}
+
+type MicrosoftNewMailOptions struct {
+	Folder          string `json:"folder"`
+	FromContains    string `json:"from_contains,omitempty"`
+	SubjectContains string `json:"subject_contains,omitempty"`
+}
+
+// MicrosoftNewMailState is kept in Workflow.Utils: only mails received
+// after Since are reported, SeenIds avoids reporting one twice.
+type MicrosoftNewMailState struct {
+	FolderId  string               `json:"folder_id"`
+	DeltaLink string               `json:"delta_link"`
+	Since     time.Time            `json:"since"`
+	SeenIds   []string             `json:"seen_ids"`
+	Pending   []MicrosoftMailEvent `json:"pending"`
+}
+
+// MicrosoftMailEvent is the event handed to the reaction for a new mail.
+type MicrosoftMailEvent struct {
+	Id             string `json:"id"`
+	Folder         string `json:"folder"`
+	Subject        string `json:"subject"`
+	From           string `json:"from"`
+	FromName       string `json:"from_name"`
+	To             string `json:"to"`
+	ReceivedAt     string `json:"received_at"`
+	Preview        string `json:"preview"`
+	HasAttachments bool   `json:"has_attachments"`
+	WebLink        string `json:"web_link"`
+}
+
+type MicrosoftMessage struct {
+	Id               string               `json:"id"`
+	Subject          string               `json:"subject"`
+	From             MicrosoftRecipient   `json:"from"`
+	ToRecipients     []MicrosoftRecipient `json:"toRecipients"`
+	ReceivedDateTime string               `json:"receivedDateTime"`
+	BodyPreview      string               `json:"bodyPreview"`
+	HasAttachments   bool                 `json:"hasAttachments"`
+	WebLink          string               `json:"webLink"`
+	Removed          *struct {
+		Reason string `json:"reason"`
+	} `json:"@removed"`
+}
+
+type MicrosoftMailFolder struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type MicrosoftMailFolderListResponse struct {
+	Value []MicrosoftMailFolder `json:"value"`
 }
 
 type MicrosoftTeamsGroupOptionsInfos struct {
@@ -39,12 +183,6 @@ type MicrosoftTeamsChatResponse struct {
 	LastUpdatedDateTime string `json:"lastUpdatedDateTime"`
 }
 
-type MicrosoftOutlookEventsResponse struct {
-	Value []struct {
-		Subject string `json:"subject"`
-	} `json:"value"`
-}
-
 type MicrosoftSendMailBodyOptions struct {
 	ContentType string `json:"contentType"`
 	Content     string `json:"content"`
@@ -87,5 +225,84 @@ type MicrosoftTeams struct {
 }
 
 type MicrosoftTeamsResponse struct {
-	Value []MicrosoftTeams `json:"value"`
+	Value    []MicrosoftTeams `json:"value"`
+	NextLink string           `json:"@odata.nextLink"`
+}
+
+type MicrosoftTeamsChatMessageOptions struct {
+	Chat        string `json:"chat"`
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
+}
+
+type MicrosoftTeamsChannelMessageOptions struct {
+	Team        string `json:"team"`
+	Channel     string `json:"channel"`
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
+}
+
+type MicrosoftTodoTaskOptions struct {
+	List       string `json:"list,omitempty"`
+	Title      string `json:"title"`
+	Body       string `json:"body,omitempty"`
+	DueDate    string `json:"due_date,omitempty"`
+	Importance string `json:"importance,omitempty"`
+}
+
+type MicrosoftItemBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+type MicrosoftChatMessage struct {
+	Body MicrosoftItemBody `json:"body"`
+}
+
+type MicrosoftChatMessageResponse struct {
+	Id     string `json:"id"`
+	WebUrl string `json:"webUrl"`
+}
+
+type MicrosoftTodoTask struct {
+	Title       string                     `json:"title"`
+	Body        *MicrosoftItemBody         `json:"body,omitempty"`
+	DueDateTime *MicrosoftDateTimeTimeZone `json:"dueDateTime,omitempty"`
+	Importance  string                     `json:"importance,omitempty"`
 }
+
+// MicrosoftNamedResource is a team, a channel or a To Do list as listed by
+// Graph.
+type MicrosoftNamedResource struct {
+	Id                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	WellknownListName string `json:"wellknownListName,omitempty"`
+}
+
+type MicrosoftNamedResourceListResponse struct {
+	Value    []MicrosoftNamedResource `json:"value"`
+	NextLink string                   `json:"@odata.nextLink"`
+}
+
+// MicrosoftReactionResponse is saved in ReactionResponseData by the Teams
+// and To Do reactions.
+type MicrosoftReactionResponse struct {
+	ChatId    string `json:"chat_id,omitempty"`
+	TeamId    string `json:"team_id,omitempty"`
+	ChannelId string `json:"channel_id,omitempty"`
+	ListId    string `json:"list_id,omitempty"`
+	Id        string `json:"id,omitempty"`
+	WebUrl    string `json:"web_url,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+var (
+	ErrMicrosoftTokenNotFound  = errors.New("no microsoft token for this user")
+	ErrMicrosoftDeltaExpired   = errors.New("the microsoft delta link expired")
+	ErrMicrosoftFolderNotFound = errors.New("microsoft mail folder not found")
+	ErrMicrosoftChatNotFound   = errors.New("teams chat not found")
+	ErrMicrosoftTeamNotFound   = errors.New("team not found")
+	ErrMicrosoftListNotFound   = errors.New("to do list not found")
+)
@@ -5,6 +5,7 @@ type ServicesUserInfos struct {
 	SpotifyUserInfos *SpotifyUserInfo `json:"spotify_user_infos"`
 	GoogleUserInfos  *GoogleUserInfo  `json:"google_user_infos"`
 	MicrosoftUserInfos *MicrosoftUserInfo `json:"microsoft_user_infos"`
+	GitlabUserInfos    *GitlabUserInfo    `json:"gitlab_user_infos"`
 }
 
 type MobileUsefulInfos struct {
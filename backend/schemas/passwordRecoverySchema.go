@@ -1,8 +1,36 @@
 package schemas
 
+import (
+	"errors"
+	"time"
+)
+
+// PasswordRecovery is a code sent by mail to reset a forgotten password.
+// Code holds its bcrypt hash; IsValidated marks it used.
 type PasswordRecovery struct {
-	Id          uint64 `json:"id,omitempty" gorm:"primary_key;auto_increment"`
-	UserId      User   `json:"user,omitempty" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-	Code        string `json:"code" gorm:"type:varchar(100)"`
-	IsValidated bool   `json:"is_validated" gorm:"type:boolean"`
+	Id          uint64    `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId      uint64    `json:"-" gorm:"index"`
+	User        User      `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Code        string    `json:"-" gorm:"type:varchar(100)"`
+	IsValidated bool      `json:"is_validated" gorm:"type:boolean"`
+	Attempts    int       `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type ForgotPasswordCredentials struct {
+	Email string `json:"email" binding:"required"`
 }
+
+type ResetPasswordCredentials struct {
+	Email    string `json:"email" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+var (
+	ErrInvalidRecoveryCode         = errors.New("invalid or expired recovery code")
+	ErrPasswordRecoveryRateLimited = errors.New("too many password recovery requests, try again later")
+	ErrPasswordTooShort            = errors.New("password must be at least 8 characters long")
+	ErrSessionRevoked              = errors.New("session revoked")
+)
@@ -10,6 +10,7 @@ type ReactionJson struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	Options     json.RawMessage `json:"options"`
+	Schema      JsonSchema      `json:"schema"`
 	ReactionId  uint64          `json:"reaction_id"`
 }
 
@@ -30,6 +31,7 @@ type Reaction struct {
 	CreatedAt   time.Time       `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
 	UpdatedAt   time.Time       `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
 	Options     json.RawMessage `gorm:"type:jsonb" json:"options"`
+	Schema      JsonSchema      `gorm:"type:jsonb;serializer:json" json:"schema"`
 }
 
 var (
@@ -0,0 +1,70 @@
+package schemas
+
+import "encoding/xml"
+
+type RssAction string
+
+const (
+	RssNewItemAction RssAction = "new_feed_item"
+)
+
+type RssFeedOptions struct {
+	Url string `json:"url"`
+}
+
+// RssItem is the event handed to the reaction for each new feed item.
+type RssItem struct {
+	Guid      string `json:"guid"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Author    string `json:"author"`
+	Summary   string `json:"summary"`
+	Published string `json:"published"`
+	FeedTitle string `json:"feed_title"`
+}
+
+// RssState is kept in Workflow.Utils between two fetches of a feed.
+type RssState struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Initialized  bool      `json:"initialized"`
+	SeenGuids    []string  `json:"seen_guids"`
+	Pending      []RssItem `json:"pending"`
+}
+
+// RssDocument decodes both RSS 2.0 (<rss><channel>) and Atom (<feed>).
+type RssDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string       `xml:"title"`
+		Items []RssXmlItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string         `xml:"title"`
+	Entries []AtomXmlEntry `xml:"entry"`
+}
+
+type RssXmlItem struct {
+	Guid        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type AtomXmlEntry struct {
+	Id    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
@@ -10,6 +10,7 @@ type ServiceToken struct {
 	Service      Service   `gorm:"foreignKey:ServiceId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"service_id"`
 	Token        string    `                                          json:"token"`
 	RefreshToken string    `                                          json:"refresh_token"`
+	Scope        string    `gorm:"type:text"                          json:"scope"`
 	ExpireAt     time.Time `                                          json:"expireAt"`
 	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"          json:"createdAt"`
 	UpdateAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"          json:"updateAt"`
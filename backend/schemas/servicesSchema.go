@@ -5,12 +5,23 @@ import "time"
 type ServiceName string
 
 const (
-	Github   ServiceName = "github"
-	Spotify  ServiceName = "spotify"
-	Google  ServiceName = "google"
-	Microsoft ServiceName = "microsoft"
-	Weather ServiceName = "weather"
-	Interpol ServiceName = "interpol"
+	Github     ServiceName = "github"
+	Spotify    ServiceName = "spotify"
+	Google     ServiceName = "google"
+	Microsoft  ServiceName = "microsoft"
+	Weather    ServiceName = "weather"
+	Interpol   ServiceName = "interpol"
+	Http       ServiceName = "http"
+	Webhook    ServiceName = "webhook"
+	Timer      ServiceName = "timer"
+	Rss        ServiceName = "rss"
+	Email      ServiceName = "email"
+	Discord    ServiceName = "discord"
+	Slack      ServiceName = "slack"
+	Mattermost ServiceName = "mattermost"
+	Stripe     ServiceName = "stripe"
+	Tmdb       ServiceName = "tmdb"
+	Gitlab     ServiceName = "gitlab"
 )
 
 type ServiceJson struct {
@@ -31,3 +42,19 @@ type Service struct {
 	CreatedAt   time.Time   `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
 	UpdatedAt   time.Time   `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
 }
+
+// ServiceOAuth describes how the frontend sends a user to the provider consent page.
+type ServiceOAuth struct {
+	AuthorizationUrl string
+	Scopes           []string
+}
+
+// ServiceRegistration is everything a provider declares about itself. The
+// services table, the actions and reactions tables, /about.json and the
+// workflow lookups are all derived from it.
+type ServiceRegistration struct {
+	Service   Service
+	Actions   []Action
+	Reactions []Reaction
+	OAuth     *ServiceOAuth
+}
@@ -1,16 +1,27 @@
 package schemas
 
+import (
+	"errors"
+	"time"
+)
+
 type SpotifyAction string
 
 const (
-	SpotifyAddTrackAction SpotifyAction = "add_track_action"
+	SpotifyAddTrackAction       SpotifyAction = "add_track_action"
+	SpotifySavedTrackAction     SpotifyAction = "new_saved_track"
+	SpotifyArtistReleaseAction  SpotifyAction = "followed_artist_release"
+	SpotifyStartedPlayingAction SpotifyAction = "started_playing"
 )
 
 type SpotifyReaction string
 
 const (
-	SpotifyAddTrackReaction SpotifyReaction = "add_track_reaction"
-	SpotifyCreatePlaylist   SpotifyReaction = "create_playlist"
+	SpotifyAddTrackReaction        SpotifyReaction = "add_track_reaction"
+	SpotifyCreatePlaylist          SpotifyReaction = "create_playlist"
+	SpotifySaveTrackReaction       SpotifyReaction = "save_track"
+	SpotifyPlaybackReaction        SpotifyReaction = "control_playback"
+	SpotifyAddPlayingTrackReaction SpotifyReaction = "add_playing_track_to_playlist"
 )
 
 type SpotifyResponseToken struct {
@@ -26,14 +37,9 @@ type SpotifyUserInfo struct {
 	DisplayName string `json:"display_name"`
 }
 
-type SpotifyActionOptions struct {
-	PlaylistURL string `json:"playlist_url"`
-	NbSongs     uint64 `json:"nbSongs"`
-	IsOld       bool   `json:"is_old"`
-}
-
 type SpotifyActionOptionsInfo struct {
-	PlaylistURL string `json:"playlist_url"`
+	PlaylistURL    string `json:"playlist_url"`
+	IncludeRemoved bool   `json:"include_removed,omitempty"`
 }
 
 type SpotifyReactionOptions struct {
@@ -41,12 +47,54 @@ type SpotifyReactionOptions struct {
 	TrackURL    string `json:"track_url"`
 }
 
-type SpotifyTracksInfos struct {
-	Total uint64 `json:"total"`
+type SpotifyPlaylistChangeType string
+
+const (
+	SpotifyTrackAdded   SpotifyPlaylistChangeType = "added"
+	SpotifyTrackRemoved SpotifyPlaylistChangeType = "removed"
+)
+
+// SpotifyPlaylistState is kept in Workflow.Utils: the snapshot the tracks
+// were listed at and, by track id (uri for local files), how many times
+// each is in the playlist.
+type SpotifyPlaylistState struct {
+	SnapshotId string                          `json:"snapshot_id"`
+	Known      map[string]SpotifyPlaylistTrack `json:"known"`
+	Pending    []SpotifyPlaylistTrackEvent     `json:"pending"`
+}
+
+type SpotifyPlaylistTrack struct {
+	Count   int               `json:"count"`
+	AddedBy string            `json:"added_by,omitempty"`
+	Track   SpotifyTrackEvent `json:"track"`
+}
+
+// SpotifyPlaylistTrackEvent is the event handed to the reaction for a track
+// added to or removed from the playlist.
+type SpotifyPlaylistTrackEvent struct {
+	SpotifyTrackEvent
+	ChangeType   SpotifyPlaylistChangeType `json:"change_type"`
+	PlaylistId   string                    `json:"playlist_id"`
+	PlaylistName string                    `json:"playlist_name"`
+	AddedBy      string                    `json:"added_by,omitempty"`
+}
+
+type SpotifyPlaylist struct {
+	Id           string              `json:"id"`
+	Name         string              `json:"name"`
+	SnapshotId   string              `json:"snapshot_id"`
+	ExternalUrls SpotifyExternalUrls `json:"external_urls"`
 }
 
-type SpotifyPlaylistInfos struct {
-	Tracks SpotifyTracksInfos `json:"tracks"`
+type SpotifyPlaylistTracksResponse struct {
+	Items []struct {
+		AddedAt string `json:"added_at"`
+		AddedBy *struct {
+			Id string `json:"id"`
+		} `json:"added_by"`
+		Track *SpotifyTrack `json:"track"`
+	} `json:"items"`
+	Next string `json:"next"`
 }
 
 type SpotifyPlaylistOptions struct {
@@ -62,3 +110,163 @@ type SpotifyPlaylistOptionsSchema struct {
 	Public        string `json:"public"`
 	Collaborative string `json:"collaborative"`
 }
+
+type SpotifyPlaybackCommand string
+
+const (
+	SpotifySkipNext     SpotifyPlaybackCommand = "skip_next"
+	SpotifySkipPrevious SpotifyPlaybackCommand = "skip_previous"
+	SpotifyPause        SpotifyPlaybackCommand = "pause"
+	SpotifyResume       SpotifyPlaybackCommand = "resume"
+)
+
+type SpotifyArtistReleaseOptions struct {
+	IncludeSingles bool `json:"include_singles"`
+}
+
+type SpotifySaveTrackOptions struct {
+	Track string `json:"track"`
+}
+
+type SpotifyPlaybackOptions struct {
+	Command SpotifyPlaybackCommand `json:"command"`
+}
+
+type SpotifyAddPlayingTrackOptions struct {
+	PlaylistURL string `json:"playlist_url"`
+}
+
+// SpotifySavedTrackState is kept in Workflow.Utils: tracks saved after
+// LastAddedAt are new, SeenIds are the ones saved exactly at it.
+type SpotifySavedTrackState struct {
+	LastAddedAt string              `json:"last_added_at"`
+	SeenIds     []string            `json:"seen_ids"`
+	Pending     []SpotifyTrackEvent `json:"pending"`
+}
+
+// SpotifyArtistReleaseState is kept in Workflow.Utils. The followed
+// artists are listed again every hour and checked a few per poll, releases
+// dated before Since or already seen are ignored.
+type SpotifyArtistReleaseState struct {
+	Since        string                `json:"since"`
+	ArtistIds    []string              `json:"artist_ids"`
+	ArtistsAt    time.Time             `json:"artists_at"`
+	NextArtist   int                   `json:"next_artist"`
+	SeenAlbumIds []string              `json:"seen_album_ids"`
+	Pending      []SpotifyReleaseEvent `json:"pending"`
+}
+
+// SpotifyPlayingState is kept in Workflow.Utils with the last track seen
+// playing.
+type SpotifyPlayingState struct {
+	Initialized bool   `json:"initialized"`
+	TrackId     string `json:"track_id"`
+}
+
+// SpotifyTrackEvent is the event handed to the reaction for a track.
+type SpotifyTrackEvent struct {
+	Id         string `json:"id"`
+	Uri        string `json:"uri"`
+	Name       string `json:"name"`
+	Artists    string `json:"artists"`
+	Album      string `json:"album"`
+	Url        string `json:"url"`
+	DurationMs int    `json:"duration_ms"`
+	AddedAt    string `json:"added_at,omitempty"`
+	Device     string `json:"device,omitempty"`
+	ContextUrl string `json:"context_url,omitempty"`
+}
+
+// SpotifyReleaseEvent is the event handed to the reaction for a release.
+type SpotifyReleaseEvent struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	AlbumType   string `json:"album_type"`
+	Artists     string `json:"artists"`
+	ReleaseDate string `json:"release_date"`
+	TotalTracks int    `json:"total_tracks"`
+	Url         string `json:"url"`
+	Image       string `json:"image,omitempty"`
+}
+
+type SpotifyArtist struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type SpotifyExternalUrls struct {
+	Spotify string `json:"spotify"`
+}
+
+type SpotifyAlbum struct {
+	Id           string              `json:"id"`
+	Name         string              `json:"name"`
+	AlbumType    string              `json:"album_type"`
+	ReleaseDate  string              `json:"release_date"`
+	TotalTracks  int                 `json:"total_tracks"`
+	Artists      []SpotifyArtist     `json:"artists"`
+	ExternalUrls SpotifyExternalUrls `json:"external_urls"`
+	Images       []struct {
+		Url string `json:"url"`
+	} `json:"images"`
+}
+
+type SpotifyTrack struct {
+	Id           string              `json:"id"`
+	Uri          string              `json:"uri"`
+	Name         string              `json:"name"`
+	Type         string              `json:"type"`
+	DurationMs   int                 `json:"duration_ms"`
+	Artists      []SpotifyArtist     `json:"artists"`
+	Album        SpotifyAlbum        `json:"album"`
+	ExternalUrls SpotifyExternalUrls `json:"external_urls"`
+}
+
+type SpotifySavedTracksResponse struct {
+	Items []struct {
+		AddedAt string       `json:"added_at"`
+		Track   SpotifyTrack `json:"track"`
+	} `json:"items"`
+	Next string `json:"next"`
+}
+
+type SpotifyFollowedArtistsResponse struct {
+	Artists struct {
+		Items []SpotifyArtist `json:"items"`
+		Next  string          `json:"next"`
+	} `json:"artists"`
+}
+
+type SpotifyAlbumsResponse struct {
+	Items []SpotifyAlbum `json:"items"`
+}
+
+type SpotifyCurrentlyPlaying struct {
+	IsPlaying            bool          `json:"is_playing"`
+	CurrentlyPlayingType string        `json:"currently_playing_type"`
+	Item                 *SpotifyTrack `json:"item"`
+	Context              *struct {
+		ExternalUrls SpotifyExternalUrls `json:"external_urls"`
+	} `json:"context"`
+	Device *struct {
+		Name string `json:"name"`
+	} `json:"device"`
+}
+
+// SpotifyReactionResponse is saved in ReactionResponseData by the Spotify
+// reactions.
+type SpotifyReactionResponse struct {
+	TrackId    string `json:"track_id,omitempty"`
+	TrackName  string `json:"track_name,omitempty"`
+	PlaylistId string `json:"playlist_id,omitempty"`
+	Command    string `json:"command,omitempty"`
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+var (
+	ErrSpotifyTokenNotFound  = errors.New("no spotify token for this user")
+	ErrSpotifyRateLimited    = errors.New("spotify rate limit reached, retrying later")
+	ErrSpotifyInvalidId      = errors.New("invalid spotify link, uri or id")
+	ErrSpotifyNothingPlaying = errors.New("nothing is playing on spotify")
+)
@@ -0,0 +1,101 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type StripeAction string
+
+const (
+	StripePaymentSucceededAction      StripeAction = "stripe_payment_succeeded"
+	StripeSubscriptionCancelledAction StripeAction = "stripe_subscription_cancelled"
+	StripeInvoiceFailedAction         StripeAction = "stripe_invoice_failed"
+)
+
+type StripeReaction string
+
+const (
+	StripeCreateCustomerReaction    StripeReaction = "stripe_create_customer"
+	StripeCreatePaymentLinkReaction StripeReaction = "stripe_create_payment_link"
+)
+
+// StripeEventTypes maps each action to the Stripe event type it waits for.
+var StripeEventTypes = map[string]string{
+	string(StripePaymentSucceededAction):      "payment_intent.succeeded",
+	string(StripeSubscriptionCancelledAction): "customer.subscription.deleted",
+	string(StripeInvoiceFailedAction):         "invoice.payment_failed",
+}
+
+// StripeWebhookOptions names the user secret holding the signing secret
+// (whsec_...) of the Stripe webhook endpoint.
+type StripeWebhookOptions struct {
+	SecretName string `json:"secret_name"`
+}
+
+// StripeApiKeyOption names the user secret holding the Stripe API key.
+type StripeApiKeyOption struct {
+	ApiKeySecret string `json:"api_key_secret"`
+}
+
+type StripeCreateCustomerOptions struct {
+	StripeApiKeyOption
+	Email       string `json:"email"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type StripeCreatePaymentLinkOptions struct {
+	StripeApiKeyOption
+	PriceId  string `json:"price_id"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// StripeEvent is the body of a Stripe webhook request.
+type StripeEvent struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Created  int64  `json:"created"`
+	Livemode bool   `json:"livemode"`
+	Data     struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// StripeEventPayload is the event handed to the reaction, Object is the
+// payment intent, subscription or invoice of the event.
+type StripeEventPayload struct {
+	Id       string          `json:"id"`
+	Type     string          `json:"type"`
+	Created  int64           `json:"created"`
+	Livemode bool            `json:"livemode"`
+	Object   json.RawMessage `json:"object"`
+}
+
+type StripeObject struct {
+	Id    string `json:"id"`
+	Url   string `json:"url"`
+	Email string `json:"email"`
+}
+
+type StripeErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// StripeReactionResponse is saved in ReactionResponseData by the Stripe
+// reactions.
+type StripeReactionResponse struct {
+	Id    string `json:"id,omitempty"`
+	Email string `json:"email,omitempty"`
+	Url   string `json:"url,omitempty"`
+	Done  bool   `json:"done"`
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	ErrStripeSignature = errors.New("invalid Stripe-Signature header")
+)
@@ -0,0 +1,43 @@
+package schemas
+
+import "time"
+
+type TimerAction string
+
+const (
+	TimerEveryMinutesAction TimerAction = "every_n_minutes"
+	TimerCronAction         TimerAction = "cron_schedule"
+	TimerAtDateTimeAction   TimerAction = "at_datetime"
+)
+
+// TimerDateTimeLayout is the wall clock layout of TimerAtDateTimeOptions,
+// read in the time zone of the action.
+const TimerDateTimeLayout = "2006-01-02T15:04"
+
+type TimerEveryMinutesOptions struct {
+	Minutes  int    `json:"minutes"`
+	TimeZone string `json:"time_zone"`
+}
+
+type TimerCronOptions struct {
+	Expression string `json:"expression"`
+	TimeZone   string `json:"time_zone"`
+}
+
+type TimerAtDateTimeOptions struct {
+	DateTime string `json:"datetime"`
+	TimeZone string `json:"time_zone"`
+}
+
+// TimerState is kept in Workflow.Utils between two checks of a timer.
+type TimerState struct {
+	NextRun time.Time `json:"next_run"`
+	Done    bool      `json:"done,omitempty"`
+}
+
+// TimerEvent is the event handed to the reaction when a timer fires.
+type TimerEvent struct {
+	ScheduledAt string `json:"scheduled_at"`
+	FiredAt     string `json:"fired_at"`
+	TimeZone    string `json:"time_zone"`
+}
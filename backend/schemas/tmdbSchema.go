@@ -0,0 +1,217 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type TmdbAction string
+
+const (
+	TmdbNewEpisodeAction        TmdbAction = "tmdb_new_episode"
+	TmdbWatchlistReleasedAction TmdbAction = "tmdb_watchlist_movie_released"
+	TmdbPersonNewCreditAction   TmdbAction = "tmdb_person_new_credit"
+)
+
+type TmdbReaction string
+
+const (
+	TmdbTrendingTodayReaction TmdbReaction = "tmdb_trending_today"
+)
+
+type TmdbNewEpisodeOptions struct {
+	TvId     uint64 `json:"tv_id"`
+	Language string `json:"language,omitempty"`
+}
+
+// TmdbWatchlistOptions names the user secret holding the TMDB session id
+// of the account whose watchlist is followed.
+type TmdbWatchlistOptions struct {
+	SessionSecret string `json:"session_secret"`
+	Region        string `json:"region"`
+}
+
+type TmdbPersonCreditOptions struct {
+	PersonId   uint64 `json:"person_id"`
+	CreditType string `json:"credit_type,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+type TmdbTrendingOptions struct {
+	MediaType string `json:"media_type"`
+	Language  string `json:"language,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// TmdbState is kept in Workflow.Utils by the TMDB actions: Seen holds the
+// keys of the episodes, released movies or credits already reported.
+type TmdbState struct {
+	Initialized bool              `json:"initialized"`
+	CheckedAt   time.Time         `json:"checked_at"`
+	Seen        []string          `json:"seen"`
+	Pending     []json.RawMessage `json:"pending"`
+}
+
+type TmdbEpisode struct {
+	Id            uint64  `json:"id"`
+	Name          string  `json:"name"`
+	Overview      string  `json:"overview"`
+	AirDate       string  `json:"air_date"`
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	StillPath     string  `json:"still_path"`
+	VoteAverage   float64 `json:"vote_average"`
+}
+
+type TmdbTvShow struct {
+	Id               uint64       `json:"id"`
+	Name             string       `json:"name"`
+	LastEpisodeToAir *TmdbEpisode `json:"last_episode_to_air"`
+}
+
+// TmdbEpisodeEvent is the event handed to the reaction for a new episode.
+type TmdbEpisodeEvent struct {
+	TvId          uint64 `json:"tv_id"`
+	Show          string `json:"show"`
+	EpisodeId     uint64 `json:"episode_id"`
+	Name          string `json:"name"`
+	Overview      string `json:"overview"`
+	AirDate       string `json:"air_date"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+	Image         string `json:"image,omitempty"`
+	Url           string `json:"url"`
+}
+
+type TmdbAccount struct {
+	Id       uint64 `json:"id"`
+	Username string `json:"username"`
+}
+
+type TmdbMovie struct {
+	Id          uint64  `json:"id"`
+	Title       string  `json:"title"`
+	Overview    string  `json:"overview"`
+	ReleaseDate string  `json:"release_date"`
+	PosterPath  string  `json:"poster_path"`
+	VoteAverage float64 `json:"vote_average"`
+}
+
+type TmdbMoviePage struct {
+	Page       int         `json:"page"`
+	TotalPages int         `json:"total_pages"`
+	Results    []TmdbMovie `json:"results"`
+}
+
+type TmdbReleaseDates struct {
+	Results []struct {
+		Iso31661     string `json:"iso_3166_1"`
+		ReleaseDates []struct {
+			ReleaseDate   string `json:"release_date"`
+			Type          int    `json:"type"`
+			Certification string `json:"certification"`
+		} `json:"release_dates"`
+	} `json:"results"`
+}
+
+// TmdbMovieReleasedEvent is the event handed to the reaction when a movie
+// of the watchlist is released in the region.
+type TmdbMovieReleasedEvent struct {
+	MovieId     uint64 `json:"movie_id"`
+	Title       string `json:"title"`
+	Overview    string `json:"overview"`
+	Region      string `json:"region"`
+	ReleaseDate string `json:"release_date"`
+	ReleaseType string `json:"release_type"`
+	Poster      string `json:"poster,omitempty"`
+	Url         string `json:"url"`
+}
+
+type TmdbCredit struct {
+	Id           uint64 `json:"id"`
+	CreditId     string `json:"credit_id"`
+	MediaType    string `json:"media_type"`
+	Title        string `json:"title"`
+	Name         string `json:"name"`
+	Character    string `json:"character"`
+	Job          string `json:"job"`
+	ReleaseDate  string `json:"release_date"`
+	FirstAirDate string `json:"first_air_date"`
+	PosterPath   string `json:"poster_path"`
+}
+
+type TmdbCombinedCredits struct {
+	Cast []TmdbCredit `json:"cast"`
+	Crew []TmdbCredit `json:"crew"`
+}
+
+type TmdbPerson struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+// TmdbCreditEvent is the event handed to the reaction for a new credit of
+// a person.
+type TmdbCreditEvent struct {
+	PersonId    uint64 `json:"person_id"`
+	Person      string `json:"person"`
+	CreditId    string `json:"credit_id"`
+	CreditType  string `json:"credit_type"`
+	MediaType   string `json:"media_type"`
+	Id          uint64 `json:"id"`
+	Title       string `json:"title"`
+	Character   string `json:"character,omitempty"`
+	Job         string `json:"job,omitempty"`
+	ReleaseDate string `json:"release_date"`
+	Poster      string `json:"poster,omitempty"`
+	Url         string `json:"url"`
+}
+
+type TmdbTrendingResult struct {
+	Id           uint64  `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	Overview     string  `json:"overview"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	PosterPath   string  `json:"poster_path"`
+	ProfilePath  string  `json:"profile_path"`
+	VoteAverage  float64 `json:"vote_average"`
+	Popularity   float64 `json:"popularity"`
+}
+
+type TmdbTrendingPage struct {
+	Results []TmdbTrendingResult `json:"results"`
+}
+
+type TmdbTrendingItem struct {
+	Id          uint64  `json:"id"`
+	MediaType   string  `json:"media_type"`
+	Title       string  `json:"title"`
+	Overview    string  `json:"overview,omitempty"`
+	ReleaseDate string  `json:"release_date,omitempty"`
+	VoteAverage float64 `json:"vote_average"`
+	Popularity  float64 `json:"popularity"`
+	Image       string  `json:"image,omitempty"`
+	Url         string  `json:"url"`
+}
+
+// TmdbTrendingResponse is saved in ReactionResponseData by the trending
+// today reaction.
+type TmdbTrendingResponse struct {
+	MediaType string             `json:"media_type"`
+	Items     []TmdbTrendingItem `json:"items"`
+	Done      bool               `json:"done"`
+	Error     string             `json:"error,omitempty"`
+}
+
+type TmdbErrorResponse struct {
+	StatusCode    int    `json:"status_code"`
+	StatusMessage string `json:"status_message"`
+}
+
+var (
+	ErrTmdbNotFound = errors.New("tmdb resource not found")
+)
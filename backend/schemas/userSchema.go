@@ -1,6 +1,9 @@
 package schemas
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type User struct {
 	Id       uint64         `json:"id,omitempty" gorm:"primary_key;auto_increment;"`
@@ -12,8 +15,17 @@ type User struct {
 	Image    string         `json:"image" gorm:"type:BYTEA"`
 	IsAdmin  bool           `json:"is_admin" gorm:"type:boolean"`
 	Services []ServiceToken `gorm:"many2many:user_service_tokens;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	// SessionsValidFrom revokes the JWTs issued before it, e.g. on password reset.
+	SessionsValidFrom *time.Time `json:"-"`
+	// EmailVerificationPending is set on the accounts registered with a
+	// password until their email is verified. Accounts created through a
+	// service, and the ones from before, are verified by default.
+	EmailVerificationPending bool `json:"email_verification_pending"`
+	// MfaEnabled asks password logins for a TOTP or recovery code.
+	MfaEnabled bool `json:"mfa_enabled"`
 }
 
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrLoginThrottled = errors.New("too many failed logins, try again later")
 )
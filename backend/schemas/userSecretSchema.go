@@ -0,0 +1,30 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+type UserSecret struct {
+	Id        uint64    `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	UserId    uint64    `json:"-" gorm:"uniqueIndex:idx_user_secret_name"`
+	User      User      `json:"-" gorm:"foreignkey:UserId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Name      string    `json:"name" gorm:"type:varchar(64);uniqueIndex:idx_user_secret_name"`
+	Value     string    `json:"-" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type UserSecretCredentials struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"required"`
+}
+
+type UserSecretName struct {
+	Name string `json:"name" binding:"required"`
+}
+
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrInvalidSecretName = errors.New("secret name must be 1 to 64 letters, digits, '.', '_' or '-'")
+)
@@ -5,20 +5,18 @@ type WeatherAction string
 const (
 	WeatherCurrentAction WeatherAction = "current_feeling_temperature"
 	WeatherTimeAction    WeatherAction = "sunrise_events"
+	WeatherRainAction    WeatherAction = "rain_expected"
+	WeatherWindAction    WeatherAction = "wind_above"
+	WeatherUvAction      WeatherAction = "uv_above"
 )
 
 type WeatherReaction string
 
 const (
-	WeatherCurrentReaction WeatherReaction = "current_weather"
+	WeatherCurrentReaction       WeatherReaction = "current_weather"
+	WeatherDailyForecastReaction WeatherReaction = "daily_forecast"
 )
 
-type WeatherActionOptions struct {
-	Current struct {
-		Feelslike_c float64 `json:"feelslike_c"`
-	} `json:"current"`
-}
-
 type WeatherReactionOptions struct {
 	Location struct {
 		Name    string `json:"name"`
@@ -58,3 +56,123 @@ type WeatherSpecificTimeInfos struct {
 		} `json:"astro"`
 	} `json:"astronomy"`
 }
+
+type WeatherForecastOptions struct {
+	CityName  string  `json:"city_name"`
+	Hours     int     `json:"hours"`
+	Threshold float64 `json:"threshold"`
+}
+
+type WeatherDailyForecastOptions struct {
+	CityName     string `json:"city_name"`
+	LanguageCode string `json:"language_code"`
+	Days         int    `json:"days,omitempty"`
+}
+
+// WeatherThresholdState is kept in Workflow.Utils so that the threshold
+// actions only fire when their condition goes from false to true.
+type WeatherThresholdState struct {
+	Initialized bool `json:"initialized"`
+	Matched     bool `json:"matched"`
+}
+
+// WeatherThresholdEvent is the event handed to the reaction when a
+// threshold is crossed. Time is the local time of the hour that matched.
+type WeatherThresholdEvent struct {
+	City        string  `json:"city"`
+	Country     string  `json:"country"`
+	Measure     string  `json:"measure"`
+	Value       float64 `json:"value"`
+	Threshold   float64 `json:"threshold"`
+	CompareSign string  `json:"compare_sign,omitempty"`
+	Time        string  `json:"time"`
+	Condition   string  `json:"condition"`
+}
+
+type WeatherLocation struct {
+	Name           string `json:"name"`
+	Region         string `json:"region"`
+	Country        string `json:"country"`
+	TzId           string `json:"tz_id"`
+	LocaltimeEpoch int64  `json:"localtime_epoch"`
+	Localtime      string `json:"localtime"`
+}
+
+type WeatherCondition struct {
+	Text string `json:"text"`
+}
+
+type WeatherForecastHour struct {
+	TimeEpoch    int64            `json:"time_epoch"`
+	Time         string           `json:"time"`
+	TempC        float64          `json:"temp_c"`
+	FeelslikeC   float64          `json:"feelslike_c"`
+	WindKph      float64          `json:"wind_kph"`
+	GustKph      float64          `json:"gust_kph"`
+	PrecipMm     float64          `json:"precip_mm"`
+	WillItRain   int              `json:"will_it_rain"`
+	ChanceOfRain float64          `json:"chance_of_rain"`
+	Uv           float64          `json:"uv"`
+	Condition    WeatherCondition `json:"condition"`
+}
+
+type WeatherForecastDay struct {
+	Date string `json:"date"`
+	Day  struct {
+		MaxtempC          float64          `json:"maxtemp_c"`
+		MintempC          float64          `json:"mintemp_c"`
+		MaxwindKph        float64          `json:"maxwind_kph"`
+		TotalprecipMm     float64          `json:"totalprecip_mm"`
+		DailyChanceOfRain float64          `json:"daily_chance_of_rain"`
+		Uv                float64          `json:"uv"`
+		Condition         WeatherCondition `json:"condition"`
+	} `json:"day"`
+	Astro struct {
+		Sunrise string `json:"sunrise"`
+		Sunset  string `json:"sunset"`
+	} `json:"astro"`
+	Hour []WeatherForecastHour `json:"hour"`
+}
+
+// WeatherForecastResponse is the response of the forecast.json endpoint,
+// which also holds the current conditions.
+type WeatherForecastResponse struct {
+	Location WeatherLocation `json:"location"`
+	Current  struct {
+		LastUpdatedEpoch int64            `json:"last_updated_epoch"`
+		TempC            float64          `json:"temp_c"`
+		FeelslikeC       float64          `json:"feelslike_c"`
+		Condition        WeatherCondition `json:"condition"`
+	} `json:"current"`
+	Forecast struct {
+		Forecastday []WeatherForecastDay `json:"forecastday"`
+	} `json:"forecast"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type WeatherDayForecast struct {
+	Date         string  `json:"date"`
+	Condition    string  `json:"condition"`
+	MinTempC     float64 `json:"min_temp_c"`
+	MaxTempC     float64 `json:"max_temp_c"`
+	ChanceOfRain float64 `json:"chance_of_rain"`
+	PrecipMm     float64 `json:"precip_mm"`
+	MaxWindKph   float64 `json:"max_wind_kph"`
+	Uv           float64 `json:"uv"`
+	Sunrise      string  `json:"sunrise"`
+	Sunset       string  `json:"sunset"`
+}
+
+// WeatherDailyForecastResponse is saved in ReactionResponseData by the
+// daily forecast reaction, Summary is the formatted text of Days.
+type WeatherDailyForecastResponse struct {
+	City    string               `json:"city"`
+	Country string               `json:"country"`
+	Summary string               `json:"summary"`
+	Days    []WeatherDayForecast `json:"days"`
+	Done    bool                 `json:"done"`
+	Error   string               `json:"error,omitempty"`
+}
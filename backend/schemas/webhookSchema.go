@@ -0,0 +1,70 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type WebhookAction string
+
+const (
+	WebhookReceivedAction WebhookAction = "webhook_received"
+)
+
+const (
+	WebhookVerificationNone         = "none"
+	WebhookVerificationSharedSecret = "shared_secret"
+	WebhookVerificationHmacSha256   = "hmac_sha256"
+)
+
+const (
+	WebhookDeliveryAccepted    = "accepted"
+	WebhookDeliveryRejected    = "rejected"
+	WebhookDeliveryRateLimited = "rate_limited"
+)
+
+type WebhookOptions struct {
+	Verification    string `json:"verification"`
+	SecretName      string `json:"secret_name"`
+	SignatureHeader string `json:"signature_header"`
+	RateLimit       int    `json:"rate_limit"`
+}
+
+// WebhookHook binds the unguessable token of /api/hooks/:token to a workflow.
+type WebhookHook struct {
+	Id         uint64    `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	WorkflowId uint64    `json:"workflow_id" gorm:"uniqueIndex"`
+	Workflow   Workflow  `json:"-" gorm:"foreignkey:WorkflowId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Token      string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	CreatedAt  time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// WebhookDelivery is one request received on a hook, kept as its run history.
+// Accepted deliveries are handed to the reaction one at a time.
+type WebhookDelivery struct {
+	Id            uint64          `json:"id,omitempty" gorm:"primary_key;auto_increment"`
+	WorkflowId    uint64          `json:"workflow_id" gorm:"index"`
+	Workflow      Workflow        `json:"-" gorm:"foreignkey:WorkflowId;references:Id;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Status        string          `json:"status" gorm:"type:varchar(20)"`
+	Error         string          `json:"error,omitempty"`
+	RemoteAddress string          `json:"remote_address" gorm:"type:varchar(64)"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	EventId       string          `json:"event_id,omitempty" gorm:"type:varchar(255);index"`
+	Processed     bool            `json:"processed" gorm:"default:false"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type WebhookJson struct {
+	WorkflowId uint64            `json:"workflow_id"`
+	Url        string            `json:"url"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrWebhookInactive     = errors.New("workflow of this webhook is not active")
+	ErrWebhookSignature    = errors.New("invalid webhook signature")
+	ErrWebhookRateLimited  = errors.New("too many requests on this webhook")
+	ErrWebhookInvalidEvent = errors.New("webhook body must be a JSON value")
+)
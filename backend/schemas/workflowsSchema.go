@@ -55,6 +55,7 @@ type Workflow struct {
 	ReactionTrigger bool            `json:"reaction_trigger" default:"false" gorm:"column:reaction_trigger"`
 	Name            string          `json:"name" gorm:"type:varchar(100)"`
 	Utils           json.RawMessage `gorm:"type:jsonb" json:"utils"`
+	Event           json.RawMessage `gorm:"type:jsonb" json:"event"`
 }
 
 var (
@@ -6,6 +6,7 @@ import (
 
 	"github.com/golang-jwt/jwt"
 
+	"area51/schemas"
 	"area51/toolbox"
 )
 
@@ -13,6 +14,9 @@ type JWTService interface {
 	GenerateJWTToken(userId string, username string, isAdmin bool) string
 	ValidateJWTToken(token string) (*jwt.Token, error)
 	GetUserIdFromToken(token string) (userId uint64, err error)
+	GenerateMfaPendingToken(userId string) string
+	GetUserIdFromMfaPendingToken(token string) (userId uint64, err error)
+	GetIssuedAtFromToken(token string) (issuedAt time.Time, err error)
 }
 
 type jwtService struct {
@@ -23,9 +27,14 @@ type jwtService struct {
 type jwtCustomClaims struct {
 	Name  string `json:"name"`
 	Admin bool   `json:"admin"`
+	// MfaPending marks the token given between the password and the TOTP
+	// code, it only opens /api/auth/login/mfa.
+	MfaPending bool `json:"mfa_pending,omitempty"`
 	jwt.StandardClaims
 }
 
+const mfaPendingTokenLifetime = 5 * time.Minute
+
 func NewJWTService() JWTService {
 	return &jwtService{
 		secretKey: toolbox.GetInEnv("JWT_SECRET"),
@@ -35,9 +44,9 @@ func NewJWTService() JWTService {
 
 func (service *jwtService) GenerateJWTToken(userId string, username string, isAdmin bool) string {
 	claims := &jwtCustomClaims{
-		username,
-		isAdmin,
-		jwt.StandardClaims{
+		Name:  username,
+		Admin: isAdmin,
+		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Hour * 48).Unix(),
 			Issuer:    service.issuer,
 			IssuedAt:  time.Now().Unix(),
@@ -71,6 +80,9 @@ func (service *jwtService) GetUserIdFromToken(tokenString string) (userId uint64
 
 	if token.Valid {
 		claims := token.Claims.(jwt.MapClaims)
+		if pending, _ := claims["mfa_pending"].(bool); pending {
+			return 0, schemas.ErrMfaPendingToken
+		}
 		if jti, ok := claims["jti"].(string); ok {
 			id, err := strconv.ParseUint(jti, 10, 64)
 			if err != nil {
@@ -83,3 +95,48 @@ func (service *jwtService) GetUserIdFromToken(tokenString string) (userId uint64
 		return 0, nil
 	}
 }
+
+func (service *jwtService) GetIssuedAtFromToken(tokenString string) (issuedAt time.Time, err error) {
+	token, err := service.ValidateJWTToken(tokenString)
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if iat, ok := claims["iat"].(float64); ok {
+		return time.Unix(int64(iat), 0), nil
+	}
+	return time.Time{}, nil
+}
+
+func (service *jwtService) GenerateMfaPendingToken(userId string) string {
+	claims := &jwtCustomClaims{
+		MfaPending: true,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(mfaPendingTokenLifetime).Unix(),
+			Issuer:    service.issuer,
+			IssuedAt:  time.Now().Unix(),
+			Id:        userId,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := token.SignedString([]byte(service.secretKey))
+	if err != nil {
+		panic(err)
+	}
+
+	return signedToken
+}
+
+func (service *jwtService) GetUserIdFromMfaPendingToken(tokenString string) (userId uint64, err error) {
+	token, err := service.ValidateJWTToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if pending, _ := claims["mfa_pending"].(bool); !pending || !token.Valid {
+		return 0, schemas.ErrMfaPendingToken
+	}
+	jti, _ := claims["jti"].(string)
+	return strconv.ParseUint(jti, 10, 64)
+}
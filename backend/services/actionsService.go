@@ -3,7 +3,6 @@ package services
 import (
 	"area51/repository"
 	"area51/schemas"
-	"area51/toolbox"
 )
 
 type ActionService interface {
@@ -13,16 +12,10 @@ type ActionService interface {
 	GetAllServicesByServiceId(serviceId uint64) (actionJson []schemas.ActionJson)
 }
 
-type ServiceAction interface {
-	GetServiceActionInfo() []schemas.Action
-}
-
 type actionService struct {
-	repository       repository.ActionRepository
-	userService      UserService
-	serviceService   ServicesService
-	allActions       []interface{}
-	allActionsSchema []schemas.Action
+	repository     repository.ActionRepository
+	userService    UserService
+	serviceService ServicesService
 }
 
 func NewActionService(
@@ -34,88 +27,6 @@ func NewActionService(
 		repository:     repository,
 		serviceService: serviceService,
 		userService:    userService,
-		allActionsSchema: []schemas.Action{
-			{
-				Name:        string(schemas.GithubPullRequest),
-				Description: "Creation or deletion of a pull request",
-				ServiceId:   serviceService.FindByName(schemas.Github).Id,
-				Options: toolbox.RealObject(schemas.GithubPullRequestOptions{
-					Owner: "my github username",
-					Repo:  "name of the repository",
-				}),
-			},
-			{
-				Name:        string(schemas.GithubPushOnRepo),
-				Description: "Detect a push on a repository",
-				ServiceId:   serviceService.FindByName(schemas.Github).Id,
-				Options: toolbox.RealObject(schemas.GithubPushOnRepoOptions{
-					Owner:  "my github username",
-					Repo:   "name of the repository",
-					Branch: "main",
-				}),
-			},
-			{
-				Name:        string(schemas.SpotifyAddTrackAction),
-				Description: "Add a track to a playlist",
-				ServiceId:   serviceService.FindByName(schemas.Spotify).Id,
-				Options: toolbox.RealObject(schemas.SpotifyActionOptionsInfo{
-					PlaylistURL: "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
-				}),
-			},
-			{
-				Name:        string(schemas.GoogleGetEmailAction),
-				Description: "Get the email of the user",
-				ServiceId:   serviceService.FindByName(schemas.Google).Id,
-				Options: toolbox.RealObject(schemas.GoogleActionOptions{
-					Label: "name of the box (INBOX, SPAM, ...)",
-				}),
-			},
-			{
-				Name:        string(schemas.MicrosoftOutlookEventsAction),
-				Description: "Detect an event in the oulook calendar of the user",
-				ServiceId:   serviceService.FindByName(schemas.Microsoft).Id,
-				Options: toolbox.RealObject(schemas.MicrosoftOutlookEventsOptions{
-					Subject: "Réunion de travail",
-				}),
-			},
-			{
-				Name:        string(schemas.WeatherCurrentAction),
-				Description: "Get the current weather",
-				ServiceId:   serviceService.FindByName(schemas.Weather).Id,
-				Options: toolbox.RealObject(schemas.WeatherCurrentOptions{
-					CityName:     "Bordeaux",
-					LanguageCode: "FR",
-					Temperature:  "0",
-					CompareSign:  "> or < or =",
-				}),
-			},
-			{
-				Name:        string(schemas.WeatherTimeAction),
-				Description: "Wait for a specific time",
-				ServiceId:   serviceService.FindByName(schemas.Weather).Id,
-				Options: toolbox.RealObject(schemas.WeatherSpecificTimeOption{
-					DateTime: "2025-01-18",
-					CityName: "Bordeaux",
-				}),
-			},
-			{
-				Name:        string(schemas.MicrosoftTeamGroup),
-				Description: "Modify a Teams group",
-				ServiceId:   serviceService.FindByName(schemas.Microsoft).Id,
-				Options: toolbox.RealObject(schemas.MicrosoftTeamsGroupOptionsInfos{
-					Name: "Area51",
-				}),
-			},
-			{
-				Name:        string(schemas.InterpolNewRedNotice),
-				Description: "Verify if the number of red notices has changed",
-				ServiceId:   serviceService.FindByName(schemas.Interpol).Id,
-				Options: toolbox.RealObject(schemas.InterpolActionOptions{
-					SexId: "M or F or U",
-				}),
-			},
-		},
-		allActions: []interface{}{serviceService},
 	}
 	newActionService.SaveAllAction()
 	return newActionService
@@ -128,13 +39,18 @@ func (service *actionService) FindAll() []schemas.Action {
 func (service *actionService) GetAllServicesByServiceId(
 	serviceId uint64,
 ) (actionJson []schemas.ActionJson) {
+	actionJson = []schemas.ActionJson{}
 	allActionForService := service.repository.FindByServiceId(serviceId)
 
 	for _, oneAction := range allActionForService {
+		if service.serviceService.FindActionByName(oneAction.Name) == nil {
+			continue
+		}
 		actionJson = append(actionJson, schemas.ActionJson{
 			Name:        oneAction.Name,
 			Description: oneAction.Description,
 			Options:     oneAction.Options,
+			Schema:      oneAction.Schema,
 			ActionId:    oneAction.Id,
 		})
 	}
@@ -142,10 +58,21 @@ func (service *actionService) GetAllServicesByServiceId(
 }
 
 func (service *actionService) SaveAllAction() {
-	for _, oneService := range service.allActionsSchema {
-		serviceByName := service.repository.FindAllByName(oneService.Name)
-		if len(serviceByName) == 0 {
-			service.repository.Save(oneService)
+	for _, registration := range service.serviceService.GetRegistrations() {
+		serviceId := service.serviceService.FindByName(registration.Service.Name).Id
+		for _, oneAction := range registration.Actions {
+			oneAction.ServiceId = serviceId
+			actionByName := service.repository.FindAllByName(oneAction.Name)
+			if len(actionByName) == 0 {
+				service.repository.Save(oneAction)
+				continue
+			}
+			existingAction := actionByName[0]
+			existingAction.ServiceId = oneAction.ServiceId
+			existingAction.Description = oneAction.Description
+			existingAction.Options = oneAction.Options
+			existingAction.Schema = oneAction.Schema
+			service.repository.Update(existingAction)
 		}
 	}
 }
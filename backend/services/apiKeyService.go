@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+)
+
+const (
+	apiKeyMaxExpiryDays = 365
+	apiKeyPrefixLength  = len(schemas.ApiKeyPrefix) + 8
+	// Requests come in bursts, last use is only written once a minute.
+	apiKeyLastUsedPrecision = time.Minute
+)
+
+type ApiKeyService interface {
+	CreateApiKey(userId uint64, creation schemas.ApiKeyCreation) (schemas.ApiKeyCreated, error)
+	DeleteApiKey(userId uint64, name string) error
+	GetApiKeys(userId uint64) []schemas.ApiKey
+	Authenticate(key string) (schemas.ApiKey, error)
+	AllowsMethod(apiKey schemas.ApiKey, method string) bool
+}
+
+type apiKeyService struct {
+	repository repository.ApiKeyRepository
+}
+
+func NewApiKeyService(
+	repository repository.ApiKeyRepository,
+) ApiKeyService {
+	return &apiKeyService{
+		repository: repository,
+	}
+}
+
+// IsApiKey tells a personal API key from a JWT.
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, schemas.ApiKeyPrefix)
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeApiKeyScopes checks the requested scopes and stores them once
+// each, in a fixed order.
+func normalizeApiKeyScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", schemas.ErrInvalidApiKeyScopes
+	}
+	for _, scope := range scopes {
+		if !containsString(schemas.ApiKeyScopes, scope) {
+			return "", schemas.ErrInvalidApiKeyScopes
+		}
+	}
+	var normalized []string
+	for _, scope := range schemas.ApiKeyScopes {
+		if containsString(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return strings.Join(normalized, " "), nil
+}
+
+func (service *apiKeyService) CreateApiKey(userId uint64, creation schemas.ApiKeyCreation) (schemas.ApiKeyCreated, error) {
+	if !secretNamePattern.MatchString(creation.Name) {
+		return schemas.ApiKeyCreated{}, schemas.ErrInvalidApiKeyName
+	}
+	scopes, err := normalizeApiKeyScopes(creation.Scopes)
+	if err != nil {
+		return schemas.ApiKeyCreated{}, err
+	}
+	if creation.ExpiresInDays < 1 || creation.ExpiresInDays > apiKeyMaxExpiryDays {
+		return schemas.ApiKeyCreated{}, schemas.ErrInvalidApiKeyExpiry
+	}
+	if service.repository.FindByUserIdAndName(userId, creation.Name).Id != 0 {
+		return schemas.ApiKeyCreated{}, schemas.ErrorAlreadyExistingRessource
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return schemas.ApiKeyCreated{}, err
+	}
+	key := schemas.ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	apiKey := service.repository.Save(schemas.ApiKey{
+		UserId:    userId,
+		Name:      creation.Name,
+		Prefix:    key[:apiKeyPrefixLength],
+		Hash:      hashApiKey(key),
+		Scopes:    scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, creation.ExpiresInDays),
+	})
+	return schemas.ApiKeyCreated{
+		ApiKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+func (service *apiKeyService) DeleteApiKey(userId uint64, name string) error {
+	apiKey := service.repository.FindByUserIdAndName(userId, name)
+	if apiKey.Id == 0 {
+		return schemas.ErrApiKeyNotFound
+	}
+	service.repository.Delete(apiKey)
+	return nil
+}
+
+func (service *apiKeyService) GetApiKeys(userId uint64) []schemas.ApiKey {
+	return service.repository.FindByUserId(userId)
+}
+
+// Authenticate finds the key by its hash, refuses it once expired and records
+// its last use.
+func (service *apiKeyService) Authenticate(key string) (schemas.ApiKey, error) {
+	if !IsApiKey(key) {
+		return schemas.ApiKey{}, schemas.ErrApiKeyNotFound
+	}
+	apiKey := service.repository.FindByHash(hashApiKey(key))
+	if apiKey.Id == 0 {
+		return schemas.ApiKey{}, schemas.ErrApiKeyNotFound
+	}
+	now := time.Now()
+	if !now.Before(apiKey.ExpiresAt) {
+		return schemas.ApiKey{}, schemas.ErrApiKeyExpired
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedPrecision {
+		service.repository.UpdateLastUsedAt(apiKey.Id, now)
+		apiKey.LastUsedAt = &now
+	}
+	return apiKey, nil
+}
+
+// AllowsMethod maps the scopes on HTTP methods: read covers the safe methods,
+// write everything else.
+func (service *apiKeyService) AllowsMethod(apiKey schemas.ApiKey, method string) bool {
+	scopes := strings.Fields(apiKey.Scopes)
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return containsString(scopes, schemas.ApiKeyScopeRead)
+	default:
+		return containsString(scopes, schemas.ApiKeyScopeWrite)
+	}
+}
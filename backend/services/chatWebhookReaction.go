@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	chatWebhookMaxAttempts   = 3
+	chatWebhookMaxRetryAfter = 30 * time.Second
+)
+
+// chatWebhookReaction holds what the Discord, Slack and Mattermost services
+// share: resolving the webhook URL, posting with 429 retries and recording
+// the delivery.
+type chatWebhookReaction struct {
+	platform                    schemas.ServiceName
+	workflowRepository          repository.WorkflowRepository
+	reactionResponseDataService ReactionResponseDataService
+	userSecretService           UserSecretService
+	mutex                       sync.Mutex
+	workflowMutexes             map[uint64]*sync.Mutex
+}
+
+// chatPayloadBuilder renders the options of a workflow into the webhook
+// target and the JSON payload of the platform.
+type chatPayloadBuilder func(workflow schemas.Workflow, templateData map[string]interface{}) (schemas.ChatWebhookTarget, interface{}, error)
+
+// workflowMutex returns the lock of one workflow: a post waiting on a
+// Retry-After only holds back the next posts of its own workflow.
+func (reaction *chatWebhookReaction) workflowMutex(workflowId uint64) *sync.Mutex {
+	reaction.mutex.Lock()
+	defer reaction.mutex.Unlock()
+
+	if reaction.workflowMutexes == nil {
+		reaction.workflowMutexes = map[uint64]*sync.Mutex{}
+	}
+	mutex, exists := reaction.workflowMutexes[workflowId]
+	if !exists {
+		mutex = &sync.Mutex{}
+		reaction.workflowMutexes[workflowId] = mutex
+	}
+	return mutex
+}
+
+func (reaction *chatWebhookReaction) post(workflowId uint64, build chatPayloadBuilder) {
+	mutex := reaction.workflowMutex(workflowId)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	workflow, err := reaction.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+
+	result := schemas.ChatWebhookResponse{
+		Platform: reaction.platform,
+		Body:     json.RawMessage("null"),
+	}
+	target, payload, err := build(workflow, toolbox.WorkflowTemplateData(workflow))
+	if err == nil {
+		var webhookUrl string
+		webhookUrl, err = reaction.resolveWebhookUrl(workflow.UserId, target)
+		if err == nil {
+			result = postChatWebhook(reaction.platform, webhookUrl, payload)
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if result.Error != "" {
+		fmt.Printf("Error posting to %s: %s\n", reaction.platform, result.Error)
+	}
+
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	reaction.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	reaction.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+func (reaction *chatWebhookReaction) resolveWebhookUrl(userId uint64, target schemas.ChatWebhookTarget) (string, error) {
+	if target.WebhookSecret != "" {
+		return reaction.userSecretService.GetSecretValue(userId, target.WebhookSecret)
+	}
+	if target.WebhookUrl != "" {
+		return target.WebhookUrl, nil
+	}
+	return "", schemas.ErrChatWebhookMissing
+}
+
+// postChatWebhook posts payload, waiting for Retry-After on 429 responses
+// up to chatWebhookMaxAttempts times.
+func postChatWebhook(platform schemas.ServiceName, webhookUrl string, payload interface{}) (result schemas.ChatWebhookResponse) {
+	result.Platform = platform
+	result.Body = json.RawMessage("null")
+	body, err := json.Marshal(payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	for result.Attempts < chatWebhookMaxAttempts {
+		result.Attempts++
+		request, err := http.NewRequest(http.MethodPost, webhookUrl, bytes.NewReader(body))
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		request.Header.Set("Content-Type", "application/json")
+		response, err := client.Do(request)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+		response.Body.Close()
+
+		result.StatusCode = response.StatusCode
+		if len(responseBody) == 0 {
+			result.Body = json.RawMessage("null")
+		} else if json.Valid(responseBody) {
+			result.Body = responseBody
+		} else {
+			result.Body = toolbox.RealObject(string(responseBody))
+		}
+		if response.StatusCode == http.StatusTooManyRequests && result.Attempts < chatWebhookMaxAttempts {
+			time.Sleep(chatRetryAfter(response.Header.Get("Retry-After")))
+			continue
+		}
+		break
+	}
+	result.Delivered = result.StatusCode >= 200 && result.StatusCode < 300
+	if !result.Delivered {
+		result.Error = fmt.Sprintf("%s answered with status %d", platform, result.StatusCode)
+	}
+	return result
+}
+
+// chatRetryAfter is retryAfterDelay capped to chatWebhookMaxRetryAfter.
+func chatRetryAfter(value string) time.Duration {
+	delay := retryAfterDelay(value)
+	if delay > chatWebhookMaxRetryAfter {
+		delay = chatWebhookMaxRetryAfter
+	}
+	return delay
+}
+
+// retryAfterDelay reads Retry-After as seconds (Discord sends decimals) or
+// as an HTTP date, one second when it is missing.
+func retryAfterDelay(value string) time.Duration {
+	delay := time.Second
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		delay = time.Duration(seconds * float64(time.Second))
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// chatTemplateRenderer renders several options and keeps the first error.
+type chatTemplateRenderer struct {
+	data map[string]interface{}
+	err  error
+}
+
+func (renderer *chatTemplateRenderer) render(field string, text string) string {
+	if renderer.err != nil {
+		return ""
+	}
+	rendered, err := toolbox.RenderTemplate(text, renderer.data)
+	if err != nil {
+		renderer.err = fmt.Errorf("%s: %w", field, err)
+	}
+	return rendered
+}
+
+// chatOptionsSchema adds the webhook target fields to the options of a
+// chat reaction.
+func chatOptionsSchema(properties map[string]*schemas.JsonSchema, required ...string) schemas.JsonSchema {
+	properties["webhook_url"] = schemas.StringSchema("Incoming webhook URL").WithFormat("uri")
+	properties["webhook_secret"] = schemas.StringSchema("Name of the secret holding the incoming webhook URL, used instead of webhook_url")
+	return schemas.OptionsSchema(properties, required...)
+}
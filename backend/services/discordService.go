@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type DiscordService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type discordService struct {
+	chatWebhookReaction
+}
+
+func NewDiscordService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) DiscordService {
+	return &discordService{
+		chatWebhookReaction: chatWebhookReaction{
+			platform:                    schemas.Discord,
+			workflowRepository:          workflowRepository,
+			reactionResponseDataService: reactionResponseDataService,
+			userSecretService:           userSecretService,
+		},
+	}
+}
+
+func (service *discordService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Discord,
+			Description: "Post messages to Discord channels",
+			Image:       "https://img.icons8.com/?size=100&id=30888&format=png&color=000000",
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.DiscordPostMessageReaction),
+				Description: "Post a message with an optional embed on a Discord webhook",
+				Schema: chatOptionsSchema(map[string]*schemas.JsonSchema{
+					"content":    schemas.StringSchema("Message template, up to 2000 characters"),
+					"username":   schemas.StringSchema("Name displayed instead of the webhook one"),
+					"avatar_url": schemas.StringSchema("Avatar displayed instead of the webhook one").WithFormat("uri"),
+					"embed": schemas.ObjectSchema("Rich embed shown under the message", map[string]*schemas.JsonSchema{
+						"title":       schemas.StringSchema("Embed title template"),
+						"description": schemas.StringSchema("Embed description template, supports markdown"),
+						"url":         schemas.StringSchema("Link of the title template"),
+						"color":       schemas.IntegerSchema("Color of the embed as a decimal RGB value").WithMinimum(0).WithMaximum(0xFFFFFF),
+					}),
+				}),
+				Options: toolbox.RealObject(schemas.DiscordPostMessageOptions{
+					ChatWebhookTarget: schemas.ChatWebhookTarget{WebhookSecret: "discord_webhook"},
+					Content:           "Workflow **{{.workflow.name}}** was triggered",
+					Username:          "Area51",
+					Embed: &schemas.DiscordEmbedOption{
+						Title:       "{{.workflow.name}}",
+						Description: "Triggered at {{.now}}",
+						Color:       0x5865F2,
+					},
+				}),
+			},
+		},
+	}
+}
+
+func (service *discordService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	return nil
+}
+
+func (service *discordService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.DiscordPostMessageReaction):
+		return service.PostMessage
+	default:
+		return nil
+	}
+}
+
+func (service *discordService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+func (service *discordService) PostMessage(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.post(workflowId, func(workflow schemas.Workflow, templateData map[string]interface{}) (schemas.ChatWebhookTarget, interface{}, error) {
+		var options schemas.DiscordPostMessageOptions
+		err := json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return options.ChatWebhookTarget, nil, fmt.Errorf("invalid options: %w", err)
+		}
+		renderer := chatTemplateRenderer{data: templateData}
+		payload := schemas.DiscordWebhookPayload{
+			Content:   renderer.render("content", options.Content),
+			Username:  options.Username,
+			AvatarUrl: options.AvatarUrl,
+		}
+		if options.Embed != nil {
+			payload.Embeds = append(payload.Embeds, schemas.DiscordEmbedOption{
+				Title:       renderer.render("embed.title", options.Embed.Title),
+				Description: renderer.render("embed.description", options.Embed.Description),
+				Url:         renderer.render("embed.url", options.Embed.Url),
+				Color:       options.Embed.Color,
+			})
+		}
+		return options.ChatWebhookTarget, payload, renderer.err
+	})
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type EmailService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type emailService struct {
+	workflowRepository          repository.WorkflowRepository
+	reactionResponseDataService ReactionResponseDataService
+	mailSender                  MailSender
+	mutex                       sync.Mutex
+}
+
+func NewEmailService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	mailSender MailSender,
+) EmailService {
+	return &emailService{
+		workflowRepository:          workflowRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		mailSender:                  mailSender,
+	}
+}
+
+func (service *emailService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Email,
+			Description: "Send emails through the SMTP server of the instance",
+			Image:       "https://img.icons8.com/?size=100&id=12623&format=png&color=000000",
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.EmailSendReaction),
+				Description: "Send an email",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"to":        schemas.StringSchema("Recipients, separated by commas").NonEmpty(),
+					"subject":   schemas.StringSchema("Subject template").NonEmpty(),
+					"text_body": schemas.StringSchema("Plain text body template"),
+					"html_body": schemas.StringSchema("HTML body template, event values are escaped"),
+				}, "to", "subject"),
+				Options: toolbox.RealObject(schemas.EmailSendOptions{
+					To:       "my.email@gmail.com",
+					Subject:  "{{.workflow.name}} was triggered",
+					TextBody: "Your workflow {{.workflow.name}} was triggered at {{.now}}.",
+					HtmlBody: "<p>Your workflow <b>{{.workflow.name}}</b> was triggered at {{.now}}.</p>",
+				}),
+			},
+		},
+	}
+}
+
+func (service *emailService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	return nil
+}
+
+func (service *emailService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.EmailSendReaction):
+		return service.SendEmail
+	default:
+		return nil
+	}
+}
+
+func (service *emailService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+func (service *emailService) SendEmail(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	var options schemas.EmailSendOptions
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.EmailSendResponse{}
+	message, err := buildEmailMessage(workflow, options)
+	if err == nil {
+		result.To = message.To
+		result.Subject = message.Subject
+		err = service.mailSender.Send(message)
+	}
+	if err != nil {
+		fmt.Println("Error sending email:", err)
+		result.Error = err.Error()
+	}
+	result.Sent = err == nil
+
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+func buildEmailMessage(workflow schemas.Workflow, options schemas.EmailSendOptions) (schemas.MailMessage, error) {
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	message := schemas.MailMessage{}
+
+	recipients, err := mail.ParseAddressList(options.To)
+	if err != nil {
+		return message, fmt.Errorf("to: %w", err)
+	}
+	for _, recipient := range recipients {
+		message.To = append(message.To, recipient.Address)
+	}
+	subject, err := toolbox.RenderTemplate(options.Subject, templateData)
+	if err != nil {
+		return message, fmt.Errorf("subject: %w", err)
+	}
+	message.Subject = strings.Join(strings.Fields(subject), " ")
+	message.TextBody, err = toolbox.RenderTemplate(options.TextBody, templateData)
+	if err != nil {
+		return message, fmt.Errorf("text_body: %w", err)
+	}
+	message.HtmlBody, err = toolbox.RenderHtmlTemplate(options.HtmlBody, templateData)
+	if err != nil {
+		return message, fmt.Errorf("html_body: %w", err)
+	}
+	return message, nil
+}
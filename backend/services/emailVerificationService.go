@@ -0,0 +1,117 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	emailVerificationLifetime    = 48 * time.Hour
+	emailVerificationHourlyLimit = 3
+)
+
+type EmailVerificationService interface {
+	SendVerification(user schemas.User) error
+	ResendVerification(user schemas.User) error
+	Verify(token string) error
+}
+
+type emailVerificationService struct {
+	repository  repository.EmailVerificationRepository
+	userService UserService
+	mailSender  MailSender
+	limiter     *toolbox.RateLimiter
+}
+
+func NewEmailVerificationService(
+	repository repository.EmailVerificationRepository,
+	userService UserService,
+	mailSender MailSender,
+) EmailVerificationService {
+	return &emailVerificationService{
+		repository:  repository,
+		userService: userService,
+		mailSender:  mailSender,
+		limiter:     toolbox.NewRateLimiter(time.Hour),
+	}
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func verificationUrl(token string) string {
+	appAdressHost := toolbox.GetInEnv("APP_HOST_ADDRESS")
+	appPort := toolbox.GetInEnv("APP_PORT")
+	return appAdressHost + appPort + "/api/auth/verify?token=" + url.QueryEscape(token)
+}
+
+// SendVerification mails a new verification link, the previous one stops
+// working.
+func (service *emailVerificationService) SendVerification(user schemas.User) error {
+	if !user.EmailVerificationPending {
+		return schemas.ErrEmailAlreadyVerified
+	}
+	if user.Email == nil {
+		return schemas.ErrMailNoRecipient
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+	service.repository.DeleteByUserId(user.Id)
+	service.repository.Save(schemas.EmailVerification{
+		UserId:    user.Id,
+		Token:     hashVerificationToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationLifetime),
+	})
+	return service.mailSender.Send(schemas.MailMessage{
+		To:      []string{*user.Email},
+		Subject: "Area51 email verification",
+		TextBody: fmt.Sprintf(
+			"Hello %s,\n\nOpen this link to verify your email, it expires in %d hours:\n%s\n\n"+
+				"Your workflows only run once your email is verified.\n",
+			user.Username, int(emailVerificationLifetime.Hours()), verificationUrl(token),
+		),
+	})
+}
+
+func (service *emailVerificationService) ResendVerification(user schemas.User) error {
+	if !user.EmailVerificationPending {
+		return schemas.ErrEmailAlreadyVerified
+	}
+	if !service.limiter.Allow(strconv.FormatUint(user.Id, 10), emailVerificationHourlyLimit) {
+		return schemas.ErrVerificationRateLimited
+	}
+	err := service.SendVerification(user)
+	if err != nil {
+		fmt.Println("Error sending verification mail:", err)
+		return schemas.ErrVerificationMail
+	}
+	return nil
+}
+
+func (service *emailVerificationService) Verify(token string) error {
+	if token == "" {
+		return schemas.ErrInvalidVerificationToken
+	}
+	verification := service.repository.FindByToken(hashVerificationToken(token))
+	if verification.Id == 0 || time.Now().After(verification.ExpiresAt) {
+		return schemas.ErrInvalidVerificationToken
+	}
+	service.userService.MarkEmailVerified(verification.UserId)
+	service.repository.Delete(verification)
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 )
 
 type GithubService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	AuthGetServiceAccessToken(code string, path string) (schemas.GitHubResponseToken, error)
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
@@ -54,6 +55,62 @@ func NewGithubService(
 	}
 }
 
+func (service *githubService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Github,
+			Description: "This is the Github service",
+			Image:       "https://pngimg.com/uploads/github/github_PNG80.png",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.GithubPullRequest),
+				Description: "Creation or deletion of a pull request",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"owner": schemas.StringSchema("Owner of the repository (user or organization)").NonEmpty(),
+					"repo":  schemas.StringSchema("Name of the repository").NonEmpty(),
+				}, "owner", "repo"),
+				Options: toolbox.RealObject(schemas.GithubPullRequestOptions{
+					Owner: "my github username",
+					Repo:  "name of the repository",
+				}),
+			},
+			{
+				Name:        string(schemas.GithubPushOnRepo),
+				Description: "Detect a push on a repository",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"owner":  schemas.StringSchema("Owner of the repository (user or organization)").NonEmpty(),
+					"repo":   schemas.StringSchema("Name of the repository").NonEmpty(),
+					"branch": schemas.StringSchema("Branch to watch").NonEmpty().WithDefault("main"),
+				}, "owner", "repo", "branch"),
+				Options: toolbox.RealObject(schemas.GithubPushOnRepoOptions{
+					Owner:  "my github username",
+					Repo:   "name of the repository",
+					Branch: "main",
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.GithubReactionListComments),
+				Description: "List all comments of a repository",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"owner": schemas.StringSchema("Owner of the repository (user or organization)").NonEmpty(),
+					"repo":  schemas.StringSchema("Name of the repository").NonEmpty(),
+				}, "owner", "repo"),
+				Options: toolbox.RealObject(schemas.GithubListAllReviewCommentsOptions{
+					Owner: "my github username",
+					Repo:  "name of the repository",
+				}),
+			},
+		},
+		OAuth: &schemas.ServiceOAuth{
+			AuthorizationUrl: "https://github.com/login/oauth/authorize",
+			Scopes:           []string{"repo"},
+		},
+	}
+}
+
 func (service *githubService) AuthGetServiceAccessToken(code string, path string) (schemas.GitHubResponseToken, error) {
 	clientId := toolbox.GetInEnv("GITHUB_CLIENT_ID")
 	clientSecret := toolbox.GetInEnv("GITHUB_SECRET")
@@ -0,0 +1,655 @@
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	gitlabDefaultBaseUrl   = "https://gitlab.com"
+	gitlabHookRetryDelay   = 10 * time.Minute
+	gitlabWebhookRateLimit = 300
+	gitlabDeletedSha       = "0000000000000000000000000000000000000000"
+)
+
+type GitlabService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	AuthGetServiceAccessToken(code string, path string) (schemas.GitlabResponseToken, error)
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+	ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error
+}
+
+type gitlabService struct {
+	serviceToken                TokenService
+	workflowRepository          repository.WorkflowRepository
+	webhookRepository           repository.WebhookRepository
+	serviceRepository           repository.ServiceRepository
+	reactionResponseDataService ReactionResponseDataService
+	userSecretService           UserSecretService
+	rateLimiter                 *toolbox.RateLimiter
+	mutex                       sync.Mutex
+}
+
+func NewGitlabService(
+	serviceToken TokenService,
+	workflowRepository repository.WorkflowRepository,
+	webhookRepository repository.WebhookRepository,
+	serviceRepository repository.ServiceRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) GitlabService {
+	return &gitlabService{
+		serviceToken:                serviceToken,
+		workflowRepository:          workflowRepository,
+		webhookRepository:           webhookRepository,
+		serviceRepository:           serviceRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		userSecretService:           userSecretService,
+		rateLimiter:                 toolbox.NewRateLimiter(time.Minute),
+	}
+}
+
+// GitlabBaseUrl is the GitLab instance used for OAuth and the API, set with
+// GITLAB_BASE_URL for self-hosted instances.
+func GitlabBaseUrl() string {
+	baseUrl := os.Getenv("GITLAB_BASE_URL")
+	if baseUrl == "" {
+		baseUrl = gitlabDefaultBaseUrl
+	}
+	return strings.TrimSuffix(baseUrl, "/")
+}
+
+func gitlabWebhookSchema(properties map[string]*schemas.JsonSchema) schemas.JsonSchema {
+	properties["project"] = schemas.StringSchema("Path (group/project) or id of the project").NonEmpty()
+	properties["secret_name"] = schemas.StringSchema("Name of the secret GitLab sends in X-Gitlab-Token").NonEmpty()
+	return schemas.OptionsSchema(properties, "project", "secret_name")
+}
+
+func (service *gitlabService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Gitlab,
+			Description: "This is the GitLab service",
+			Image:       "https://img.icons8.com/?size=100&id=34886&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.GitlabNewMergeRequestAction),
+				Description: "A merge request is opened",
+				Schema: gitlabWebhookSchema(map[string]*schemas.JsonSchema{
+					"ref": schemas.StringSchema("Only merge requests targeting this branch"),
+				}),
+				Options: toolbox.RealObject(schemas.GitlabWebhookOptions{
+					Project:    "my-group/my-project",
+					SecretName: "gitlab_webhook_token",
+					Ref:        "main",
+				}),
+			},
+			{
+				Name:        string(schemas.GitlabPipelineFailedAction),
+				Description: "A pipeline failed",
+				Schema: gitlabWebhookSchema(map[string]*schemas.JsonSchema{
+					"ref": schemas.StringSchema("Only pipelines of this branch or tag"),
+				}),
+				Options: toolbox.RealObject(schemas.GitlabWebhookOptions{
+					Project:    "my-group/my-project",
+					SecretName: "gitlab_webhook_token",
+				}),
+			},
+			{
+				Name:        string(schemas.GitlabNewTagAction),
+				Description: "A tag is pushed",
+				Schema:      gitlabWebhookSchema(map[string]*schemas.JsonSchema{}),
+				Options: toolbox.RealObject(schemas.GitlabWebhookOptions{
+					Project:    "my-group/my-project",
+					SecretName: "gitlab_webhook_token",
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.GitlabCreateIssueReaction),
+				Description: "Create an issue in a project",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"project":     schemas.StringSchema("Path (group/project) or id of the project, template").NonEmpty(),
+					"title":       schemas.StringSchema("Title template of the issue").NonEmpty(),
+					"description": schemas.StringSchema("Description template of the issue, supports markdown"),
+					"labels":      schemas.StringSchema("Comma separated labels"),
+				}, "project", "title"),
+				Options: toolbox.RealObject(schemas.GitlabCreateIssueOptions{
+					Project:     "my-group/my-project",
+					Title:       "Pipeline {{.event.id}} failed on {{.event.ref}}",
+					Description: "Failed jobs: {{.event.failed_jobs}}",
+					Labels:      "ci",
+				}),
+			},
+			{
+				Name:        string(schemas.GitlabCommentMergeRequestReaction),
+				Description: "Comment on a merge request",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"project":           schemas.StringSchema("Path (group/project) or id of the project, template").NonEmpty(),
+					"merge_request_iid": schemas.StringSchema("Iid of the merge request, template").NonEmpty(),
+					"body":              schemas.StringSchema("Comment template, supports markdown").NonEmpty(),
+				}, "project", "merge_request_iid", "body"),
+				Options: toolbox.RealObject(schemas.GitlabCommentMergeRequestOptions{
+					Project:         "{{.event.project}}",
+					MergeRequestIid: "{{.event.iid}}",
+					Body:            "Thanks {{.event.author}}, this merge request will be reviewed soon.",
+				}),
+			},
+		},
+		OAuth: &schemas.ServiceOAuth{
+			AuthorizationUrl: GitlabBaseUrl() + "/oauth/authorize",
+			Scopes:           []string{"api", "read_user"},
+		},
+	}
+}
+
+func (service *gitlabService) AuthGetServiceAccessToken(code string, path string) (schemas.GitlabResponseToken, error) {
+	appPort := toolbox.GetInEnv("FRONTEND_PORT")
+	appAdressHost := toolbox.GetInEnv("APP_HOST_ADDRESS")
+
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", appAdressHost+appPort+path)
+	return requestGitlabToken(data)
+}
+
+// requestGitlabToken exchanges a code or a refresh token on the token
+// endpoint of the instance.
+func requestGitlabToken(data url.Values) (schemas.GitlabResponseToken, error) {
+	data.Set("client_id", toolbox.GetInEnv("GITLAB_CLIENT_ID"))
+	data.Set("client_secret", toolbox.GetInEnv("GITLAB_SECRET"))
+
+	request, err := http.NewRequest("POST", GitlabBaseUrl()+"/oauth/token", strings.NewReader(data.Encode()))
+	if err != nil {
+		return schemas.GitlabResponseToken{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	client := &http.Client{
+		Timeout: time.Second * 45,
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return schemas.GitlabResponseToken{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return schemas.GitlabResponseToken{}, fmt.Errorf("gitlab token endpoint returned %s", response.Status)
+	}
+
+	result := schemas.GitlabResponseToken{}
+	err = json.NewDecoder(response.Body).Decode(&result)
+	if err != nil {
+		return schemas.GitlabResponseToken{}, fmt.Errorf("unable to decode response because %w", err)
+	}
+	return result, nil
+}
+
+// GitlabTokenExpiry returns when a GitLab access token expires, GitLab
+// tokens last two hours.
+func GitlabTokenExpiry(token schemas.GitlabResponseToken) time.Time {
+	if token.ExpiresIn == 0 {
+		return time.Time{}
+	}
+	createdAt := time.Now()
+	if token.CreatedAt != 0 {
+		createdAt = time.Unix(token.CreatedAt, 0)
+	}
+	return createdAt.Add(time.Duration(token.ExpiresIn) * time.Second)
+}
+
+func (service *gitlabService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.GitlabNewMergeRequestAction),
+		string(schemas.GitlabPipelineFailedAction),
+		string(schemas.GitlabNewTagAction):
+		return service.GitlabHookReceived
+	default:
+		return nil
+	}
+}
+
+func (service *gitlabService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.GitlabCreateIssueReaction):
+		return service.CreateIssue
+	case string(schemas.GitlabCommentMergeRequestReaction):
+		return service.CommentMergeRequest
+	default:
+		return nil
+	}
+}
+
+func (service *gitlabService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return func(userInfos *schemas.ServicesUserInfos) {
+		err := gitlabRequest(accessToken, http.MethodGet, "user", nil, &userInfos.GitlabUserInfos)
+		if err != nil {
+			fmt.Println("Error fetching gitlab user:", err)
+		}
+	}
+}
+
+// getGitlabToken returns the access token of the user, refreshed first when
+// it is about to expire.
+func (service *gitlabService) getGitlabToken(userId uint64) (string, error) {
+	searchedService := service.serviceRepository.FindByName(schemas.Gitlab)
+	token, err := service.serviceToken.GetTokenByUserIdAndServiceId(userId, searchedService.Id)
+	if err != nil || token.Id == 0 {
+		return "", schemas.ErrGitlabTokenNotFound
+	}
+	if token.ExpireAt.IsZero() || token.RefreshToken == "" || time.Until(token.ExpireAt) > time.Minute {
+		return token.Token, nil
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", token.RefreshToken)
+	data.Set("redirect_uri", toolbox.GetInEnv("APP_HOST_ADDRESS")+toolbox.GetInEnv("FRONTEND_PORT")+"/callback")
+	refreshed, err := requestGitlabToken(data)
+	if err != nil {
+		return "", fmt.Errorf("unable to refresh gitlab token because %w", err)
+	}
+	err = service.serviceToken.Update(schemas.ServiceToken{
+		Id:           token.Id,
+		Token:        refreshed.AccessToken,
+		RefreshToken: refreshed.RefreshToken,
+		Scope:        refreshed.Scope,
+		ExpireAt:     GitlabTokenExpiry(refreshed),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refreshed.AccessToken, nil
+}
+
+// GitlabHookReceived makes sure the project sends its events to the hook of
+// the workflow, then hands the oldest matching event to the reaction.
+func (service *gitlabService) GitlabHookReceived(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		channel <- err.Error()
+		return
+	}
+	options := schemas.GitlabWebhookOptions{}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		channel <- err.Error()
+		return
+	}
+	service.ensureProjectHook(workflow, options)
+	channel <- popWebhookDelivery(service.workflowRepository, service.webhookRepository, workflowId)
+}
+
+// ensureProjectHook creates the project hook pointing to the workflow once
+// per project. Failures are kept in the state and retried later, the hook
+// can also be added by hand with the URL of /api/workflow/hook.
+func (service *gitlabService) ensureProjectHook(workflow schemas.Workflow, options schemas.GitlabWebhookOptions) {
+	state := schemas.GitlabHookState{}
+	if len(workflow.Utils) != 0 {
+		err := json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing gitlab state:", err)
+		}
+	}
+	if state.Project == options.Project && (state.HookId != 0 || time.Since(state.AttemptedAt) < gitlabHookRetryDelay) {
+		return
+	}
+
+	state = schemas.GitlabHookState{Project: options.Project, AttemptedAt: time.Now()}
+	hookId, err := service.createProjectHook(workflow, options)
+	if err != nil {
+		fmt.Println("Error creating gitlab project hook:", err)
+		state.Error = err.Error()
+	}
+	state.HookId = hookId
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling gitlab state:", err)
+		return
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+}
+
+func (service *gitlabService) createProjectHook(workflow schemas.Workflow, options schemas.GitlabWebhookOptions) (uint64, error) {
+	accessToken, err := service.getGitlabToken(workflow.UserId)
+	if err != nil {
+		return 0, err
+	}
+	secret, err := service.userSecretService.GetSecretValue(workflow.UserId, options.SecretName)
+	if err != nil {
+		return 0, fmt.Errorf("secret %q: %w", options.SecretName, err)
+	}
+	hook := ensureWebhookHook(service.webhookRepository, workflow.Id)
+	objectKind := schemas.GitlabHookEvents[workflow.Action.Name]
+
+	result := schemas.GitlabProjectHookResponse{}
+	err = gitlabRequest(accessToken, http.MethodPost, "projects/"+url.PathEscape(options.Project)+"/hooks", schemas.GitlabProjectHook{
+		Url:                   webhookUrl("/api/hooks/gitlab/", hook.Token),
+		Token:                 secret,
+		MergeRequestsEvents:   objectKind == "merge_request",
+		PipelineEvents:        objectKind == "pipeline",
+		TagPushEvents:         objectKind == "tag_push",
+		EnableSslVerification: true,
+	}, &result)
+	return result.Id, err
+}
+
+// ReceiveWebhook checks the X-Gitlab-Token of a hook request and keeps it
+// as a delivery of the workflow when it is an event of its action. Other
+// events are acknowledged and dropped.
+func (service *gitlabService) ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error {
+	hook := service.webhookRepository.FindHookByToken(token)
+	if hook.Id == 0 {
+		return schemas.ErrWebhookNotFound
+	}
+	workflow, err := service.workflowRepository.FindByIds(hook.WorkflowId)
+	if err != nil {
+		return schemas.ErrWebhookNotFound
+	}
+	if schemas.GitlabHookEvents[workflow.Action.Name] == "" {
+		return schemas.ErrWebhookNotFound
+	}
+	options := schemas.GitlabWebhookOptions{}
+	err = json.Unmarshal(workflow.ActionOptions, &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+	}
+
+	delivery := schemas.WebhookDelivery{
+		WorkflowId:    workflow.Id,
+		Status:        schemas.WebhookDeliveryRejected,
+		RemoteAddress: remoteAddress,
+		Payload:       json.RawMessage("null"),
+	}
+	if !service.rateLimiter.Allow(token, gitlabWebhookRateLimit) {
+		delivery.Status = schemas.WebhookDeliveryRateLimited
+		delivery.Error = schemas.ErrWebhookRateLimited.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookRateLimited
+	}
+	if !workflow.IsActive {
+		delivery.Error = schemas.ErrWebhookInactive.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInactive
+	}
+	secret, err := service.userSecretService.GetSecretValue(workflow.UserId, options.SecretName)
+	if err != nil || subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+		delivery.Error = schemas.ErrWebhookSignature.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookSignature
+	}
+
+	payload := schemas.GitlabWebhookPayload{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		delivery.Error = schemas.ErrWebhookInvalidEvent.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInvalidEvent
+	}
+	event := gitlabHookEvent(workflow.Action.Name, options, payload)
+	if event == nil {
+		return nil
+	}
+	delivery.Status = schemas.WebhookDeliveryAccepted
+	delivery.Payload = toolbox.RealObject(event)
+	service.webhookRepository.SaveDelivery(delivery)
+	return nil
+}
+
+// gitlabHookEvent returns the event of the action for a hook payload, or
+// nil when the payload does not concern the action.
+func gitlabHookEvent(actionName string, options schemas.GitlabWebhookOptions, payload schemas.GitlabWebhookPayload) interface{} {
+	if payload.ObjectKind != schemas.GitlabHookEvents[actionName] {
+		return nil
+	}
+	attributes := payload.ObjectAttributes
+	project := payload.Project.PathWithNamespace
+
+	switch actionName {
+	case string(schemas.GitlabNewMergeRequestAction):
+		if attributes.Action != "open" || (options.Ref != "" && attributes.TargetBranch != options.Ref) {
+			return nil
+		}
+		return schemas.GitlabMergeRequestEvent{
+			Project:      project,
+			Iid:          attributes.Iid,
+			Title:        attributes.Title,
+			Description:  attributes.Description,
+			Author:       firstNonEmpty(payload.User.Username, payload.User.Name),
+			SourceBranch: attributes.SourceBranch,
+			TargetBranch: attributes.TargetBranch,
+			Url:          attributes.Url,
+		}
+	case string(schemas.GitlabPipelineFailedAction):
+		if attributes.Status != "failed" || (options.Ref != "" && attributes.Ref != options.Ref) {
+			return nil
+		}
+		event := schemas.GitlabPipelineEvent{
+			Project:    project,
+			Id:         attributes.Id,
+			Ref:        attributes.Ref,
+			Sha:        attributes.Sha,
+			Source:     attributes.Source,
+			Author:     firstNonEmpty(payload.User.Username, payload.User.Name),
+			FailedJobs: []string{},
+			Url:        firstNonEmpty(attributes.Url, payload.Project.WebUrl+"/-/pipelines/"+strconv.FormatUint(attributes.Id, 10)),
+		}
+		for _, build := range payload.Builds {
+			if build.Status == "failed" {
+				event.FailedJobs = append(event.FailedJobs, build.Name)
+			}
+		}
+		return event
+	case string(schemas.GitlabNewTagAction):
+		if payload.After == gitlabDeletedSha || payload.CheckoutSha == "" {
+			return nil
+		}
+		tag := strings.TrimPrefix(payload.Ref, "refs/tags/")
+		return schemas.GitlabTagEvent{
+			Project: project,
+			Tag:     tag,
+			Sha:     payload.CheckoutSha,
+			Message: payload.Message,
+			Author:  firstNonEmpty(payload.UserUsername, payload.UserName),
+			Url:     payload.Project.WebUrl + "/-/tags/" + url.PathEscape(tag),
+		}
+	}
+	return nil
+}
+
+func (service *gitlabService) CreateIssue(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.runGitlabReaction(workflowId, func(workflow schemas.Workflow, gitlabToken string, result *schemas.GitlabReactionResponse) error {
+		options := schemas.GitlabCreateIssueOptions{}
+		err := json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return err
+		}
+		rendered, err := renderGitlabOptions(workflow, map[string]string{
+			"project":     options.Project,
+			"title":       options.Title,
+			"description": options.Description,
+		})
+		if err != nil {
+			return err
+		}
+		result.Project = rendered["project"]
+
+		issue := schemas.GitlabCreatedObject{}
+		err = gitlabRequest(gitlabToken, http.MethodPost, "projects/"+url.PathEscape(result.Project)+"/issues", map[string]string{
+			"title":       rendered["title"],
+			"description": rendered["description"],
+			"labels":      options.Labels,
+		}, &issue)
+		if err != nil {
+			return err
+		}
+		result.Id = issue.Id
+		result.Iid = issue.Iid
+		result.WebUrl = issue.WebUrl
+		return nil
+	})
+}
+
+func (service *gitlabService) CommentMergeRequest(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.runGitlabReaction(workflowId, func(workflow schemas.Workflow, gitlabToken string, result *schemas.GitlabReactionResponse) error {
+		options := schemas.GitlabCommentMergeRequestOptions{}
+		err := json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return err
+		}
+		rendered, err := renderGitlabOptions(workflow, map[string]string{
+			"project":           options.Project,
+			"merge_request_iid": options.MergeRequestIid,
+			"body":              options.Body,
+		})
+		if err != nil {
+			return err
+		}
+		result.Project = rendered["project"]
+		mergeRequestIid, err := strconv.ParseUint(strings.TrimSpace(rendered["merge_request_iid"]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("merge_request_iid: %w", err)
+		}
+
+		note := schemas.GitlabCreatedObject{}
+		notesPath := fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.PathEscape(result.Project), mergeRequestIid)
+		err = gitlabRequest(gitlabToken, http.MethodPost, notesPath, map[string]string{
+			"body": rendered["body"],
+		}, &note)
+		if err != nil {
+			return err
+		}
+		result.Id = note.Id
+		result.Iid = mergeRequestIid
+		return nil
+	})
+}
+
+func renderGitlabOptions(workflow schemas.Workflow, templates map[string]string) (map[string]string, error) {
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	rendered := map[string]string{}
+	for name, text := range templates {
+		value, err := toolbox.RenderTemplate(text, templateData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		rendered[name] = value
+	}
+	return rendered, nil
+}
+
+// runGitlabReaction runs a GitLab reaction once its workflow is triggered
+// and saves its result, errors included.
+func (service *gitlabService) runGitlabReaction(
+	workflowId uint64,
+	run func(workflow schemas.Workflow, gitlabToken string, result *schemas.GitlabReactionResponse) error,
+) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+
+	result := schemas.GitlabReactionResponse{}
+	gitlabToken, err := service.getGitlabToken(workflow.UserId)
+	if err == nil {
+		err = run(workflow, gitlabToken, &result)
+	}
+	if err != nil {
+		fmt.Println("Error running gitlab reaction:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+// gitlabRequest calls the v4 API of the instance. body is sent as JSON when
+// not nil and result may be nil.
+func gitlabRequest(accessToken string, method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(jsonBody)
+	}
+	request, err := http.NewRequest(method, GitlabBaseUrl()+"/api/v4/"+path, requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		gitlabError := struct {
+			Message json.RawMessage `json:"message"`
+			Error   string          `json:"error"`
+		}{}
+		err = json.NewDecoder(response.Body).Decode(&gitlabError)
+		if err != nil || (len(gitlabError.Message) == 0 && gitlabError.Error == "") {
+			return fmt.Errorf("gitlab api returned %s", response.Status)
+		}
+		message := gitlabError.Error
+		if len(gitlabError.Message) != 0 {
+			message = string(gitlabError.Message)
+			_ = json.Unmarshal(gitlabError.Message, &message)
+		}
+		return fmt.Errorf("gitlab api returned %s: %s", response.Status, message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
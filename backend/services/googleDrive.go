@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	googleSheetsScope     = "https://www.googleapis.com/auth/spreadsheets"
+	googleDriveScope      = "https://www.googleapis.com/auth/drive"
+	googleDriveApiUrl     = "https://www.googleapis.com/drive/v3/files"
+	googleDriveUploadUrl  = "https://www.googleapis.com/upload/drive/v3/files"
+	googleDriveUploadSize = 10 * 1024 * 1024
+	googleDriveFileFields = "id,name,mimeType,createdTime,size,webViewLink,owners(displayName,emailAddress)"
+)
+
+// DriveNewFileInFolder queues the files created in the folder since the
+// last check. The first check only records the current time.
+func (service *googleService) DriveNewFileInFolder(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkDriveNewFiles(workflowId, actionOption)
+}
+
+func (service *googleService) checkDriveNewFiles(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.GoogleDriveNewFileOptions{}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	googleToken, err := service.getGoogleToken(workflow.UserId, googleDriveScope)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.GoogleDriveNewFileState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing drive state:", err)
+		}
+	}
+
+	message := "No new file"
+	if state.LastCreatedTime == "" {
+		state.LastCreatedTime = time.Now().UTC().Format(time.RFC3339)
+		message = "Drive folder initialized"
+	} else {
+		files, err := listDriveFilesCreatedSince(googleToken, options, state.LastCreatedTime)
+		if err != nil {
+			fmt.Println("Error listing drive files:", err)
+			return err.Error()
+		}
+		for _, file := range files {
+			if containsString(state.SeenIds, file.Id) {
+				continue
+			}
+			if file.CreatedTime != state.LastCreatedTime {
+				state.LastCreatedTime = file.CreatedTime
+				state.SeenIds = nil
+			}
+			state.SeenIds = append(state.SeenIds, file.Id)
+			event := schemas.GoogleDriveFileEvent{
+				Id:          file.Id,
+				Name:        file.Name,
+				MimeType:    file.MimeType,
+				FolderId:    options.FolderId,
+				CreatedTime: file.CreatedTime,
+				Size:        file.Size,
+				WebViewLink: file.WebViewLink,
+			}
+			if len(file.Owners) != 0 {
+				event.Owner = firstNonEmpty(file.Owners[0].EmailAddress, file.Owners[0].DisplayName)
+			}
+			state.Pending = append(state.Pending, event)
+		}
+	}
+
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "New file in drive folder"
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling drive state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+// listDriveFilesCreatedSince returns the files of the folder created at or
+// after createdTime, oldest first.
+func listDriveFilesCreatedSince(accessToken string, options schemas.GoogleDriveNewFileOptions, createdTime string) (files []schemas.GoogleDriveFile, err error) {
+	searchQuery := fmt.Sprintf("%s in parents and trashed = false and createdTime >= %s",
+		driveQueryString(options.FolderId), driveQueryString(createdTime))
+	if options.MimeType != "" {
+		searchQuery += " and mimeType = " + driveQueryString(options.MimeType)
+	}
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("q", searchQuery)
+		query.Set("orderBy", "createdTime")
+		query.Set("pageSize", "100")
+		query.Set("fields", "nextPageToken,files("+googleDriveFileFields+")")
+		query.Set("supportsAllDrives", "true")
+		query.Set("includeItemsFromAllDrives", "true")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		list := schemas.GoogleDriveFileListResponse{}
+		_, err = googleRequest(accessToken, http.MethodGet, googleDriveApiUrl, query, nil, &list)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, list.Files...)
+		if list.NextPageToken == "" {
+			return files, nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+// driveQueryString quotes a value for the q parameter of the Drive API.
+func driveQueryString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+func containsString(values []string, searched string) bool {
+	for _, value := range values {
+		if value == searched {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *googleService) SheetsAppendRowReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.GoogleSheetsAppendRowOptions{Sheet: "Sheet1"}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.GoogleDriveReactionResponse{SpreadsheetId: options.SpreadsheetId}
+	err = service.appendSheetRow(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error appending sheet row:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveDriveReaction(workflow, result)
+}
+
+func (service *googleService) appendSheetRow(workflow schemas.Workflow, options schemas.GoogleSheetsAppendRowOptions, result *schemas.GoogleDriveReactionResponse) error {
+	googleToken, err := service.getGoogleToken(workflow.UserId, googleSheetsScope)
+	if err != nil {
+		return err
+	}
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	row := make([]string, len(options.Values))
+	for index, value := range options.Values {
+		row[index], err = toolbox.RenderTemplate(value, templateData)
+		if err != nil {
+			return fmt.Errorf("values[%d]: %w", index, err)
+		}
+	}
+	result.Values = row
+
+	valueInputOption := "RAW"
+	if options.ParseValues {
+		valueInputOption = "USER_ENTERED"
+	}
+	query := url.Values{}
+	query.Set("valueInputOption", valueInputOption)
+	query.Set("insertDataOption", "INSERT_ROWS")
+	sheetRange := "'" + strings.ReplaceAll(options.Sheet, "'", "''") + "'"
+	requestUrl := "https://sheets.googleapis.com/v4/spreadsheets/" + url.PathEscape(options.SpreadsheetId) +
+		"/values/" + url.PathEscape(sheetRange) + ":append"
+	response := schemas.GoogleSheetsAppendResponse{}
+	_, err = googleRequest(googleToken, http.MethodPost, requestUrl, query, map[string]interface{}{
+		"majorDimension": "ROWS",
+		"values":         [][]string{row},
+	}, &response)
+	result.UpdatedRange = response.Updates.UpdatedRange
+	return err
+}
+
+func (service *googleService) DriveUploadReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.GoogleDriveUploadOptions{}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.GoogleDriveReactionResponse{}
+	err = service.uploadDriveFile(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error uploading drive file:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveDriveReaction(workflow, result)
+}
+
+func (service *googleService) uploadDriveFile(workflow schemas.Workflow, options schemas.GoogleDriveUploadOptions, result *schemas.GoogleDriveReactionResponse) error {
+	googleToken, err := service.getGoogleToken(workflow.UserId, googleDriveScope)
+	if err != nil {
+		return err
+	}
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	name, err := toolbox.RenderTemplate(options.Name, templateData)
+	if err != nil {
+		return fmt.Errorf("name: %w", err)
+	}
+	mimeType := options.MimeType
+	var content []byte
+	if options.SourceUrl != "" {
+		sourceUrl, err := toolbox.RenderTemplate(options.SourceUrl, templateData)
+		if err != nil {
+			return fmt.Errorf("source_url: %w", err)
+		}
+		var sourceType string
+		content, sourceType, err = downloadDriveSource(sourceUrl)
+		if err != nil {
+			return err
+		}
+		if mimeType == "" {
+			mimeType = sourceType
+		}
+		if strings.TrimSpace(name) == "" {
+			parsedUrl, _ := url.Parse(sourceUrl)
+			name = path.Base(parsedUrl.Path)
+		}
+	} else {
+		renderedContent, err := toolbox.RenderTemplate(options.Content, templateData)
+		if err != nil {
+			return fmt.Errorf("content: %w", err)
+		}
+		content = []byte(renderedContent)
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return fmt.Errorf("name is empty")
+	}
+	result.FileName = name
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	metadataPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"application/json; charset=UTF-8"},
+	})
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(metadataPart).Encode(map[string]interface{}{
+		"name":     name,
+		"mimeType": mimeType,
+		"parents":  []string{options.FolderId},
+	})
+	if err != nil {
+		return err
+	}
+	contentPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mimeType},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = contentPart.Write(content)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("uploadType", "multipart")
+	query.Set("supportsAllDrives", "true")
+	query.Set("fields", "id,name,webViewLink")
+	request, err := http.NewRequest(http.MethodPost, googleDriveUploadUrl+"?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+googleToken)
+	request.Header.Set("Content-Type", "multipart/related; boundary="+writer.Boundary())
+	client := &http.Client{Timeout: 60 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	bodyBytes, _ := io.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("google answered %d: %s", response.StatusCode, string(bodyBytes))
+	}
+	file := schemas.GoogleDriveFile{}
+	err = json.Unmarshal(bodyBytes, &file)
+	if err != nil {
+		return err
+	}
+	result.FileId = file.Id
+	result.WebViewLink = file.WebViewLink
+	return nil
+}
+
+// downloadDriveSource fetches the file to upload, up to 10MB, with its MIME
+// type.
+func downloadDriveSource(sourceUrl string) ([]byte, string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Get(sourceUrl)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("source_url answered %d", response.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(response.Body, googleDriveUploadSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(content) > googleDriveUploadSize {
+		return nil, "", fmt.Errorf("source_url is larger than %d bytes", googleDriveUploadSize)
+	}
+	mimeType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		mimeType = http.DetectContentType(content)
+	}
+	return content, mimeType, nil
+}
+
+func (service *googleService) saveDriveReaction(workflow schemas.Workflow, result schemas.GoogleDriveReactionResponse) {
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflow.Id,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
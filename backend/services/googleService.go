@@ -3,10 +3,12 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/mail"
 	"net/url"
 	"strings"
 	"sync"
@@ -18,6 +20,7 @@ import (
 )
 
 type GoogleService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	AuthGetServiceAccessToken(code string, path string) (schemas.GoogleResponseToken, error)
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
@@ -25,12 +28,13 @@ type GoogleService interface {
 }
 
 type googleService struct {
-	serviceToken       TokenService
-	userService        UserService
-	workflowRepository repository.WorkflowRepository
-	serviceRepository  repository.ServiceRepository
-	googleRepository   repository.GoogleRepository
-	mutex              sync.Mutex
+	serviceToken                TokenService
+	userService                 UserService
+	workflowRepository          repository.WorkflowRepository
+	serviceRepository           repository.ServiceRepository
+	googleRepository            repository.GoogleRepository
+	reactionResponseDataService ReactionResponseDataService
+	mutex                       sync.Mutex
 }
 
 func NewGoogleService(
@@ -39,13 +43,193 @@ func NewGoogleService(
 	workflowRepository repository.WorkflowRepository,
 	serviceRepository repository.ServiceRepository,
 	googleRepository repository.GoogleRepository,
+	reactionResponseDataService ReactionResponseDataService,
 ) GoogleService {
 	return &googleService{
-		serviceToken:       serviceToken,
-		userService:        userService,
-		workflowRepository: workflowRepository,
-		serviceRepository:  serviceRepository,
-		googleRepository:   googleRepository,
+		serviceToken:                serviceToken,
+		userService:                 userService,
+		workflowRepository:          workflowRepository,
+		serviceRepository:           serviceRepository,
+		googleRepository:            googleRepository,
+		reactionResponseDataService: reactionResponseDataService,
+	}
+}
+
+func (service *googleService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Google,
+			Description: "This is the Google service",
+			Image:       "https://pngimg.com/uploads/google/google_PNG19630.png",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.GoogleGetEmailAction),
+				Description: "Get the email of the user",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"label": schemas.StringSchema("Gmail label to watch (INBOX, SPAM, ...)").NonEmpty().WithDefault("INBOX"),
+				}, "label"),
+				Options: toolbox.RealObject(schemas.GoogleActionOptions{
+					Label: "INBOX",
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleNewEmailAction),
+				Description: "A new email matches a Gmail search query",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"query": schemas.StringSchema("Gmail search query, as typed in the Gmail search bar (from:boss@example.com has:attachment)").NonEmpty().WithDefault("in:inbox"),
+				}, "query"),
+				Options: toolbox.RealObject(schemas.GmailNewEmailOptions{
+					Query: "in:inbox from:boss@example.com",
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleEventSoonAction),
+				Description: "An event of a calendar starts in N minutes",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"calendar_id":      schemas.StringSchema("Identifier of the calendar, primary for your main calendar").NonEmpty().WithDefault("primary"),
+					"minutes_before":   schemas.IntegerSchema("How many minutes before the start to trigger").WithMinimum(1).WithMaximum(1440),
+					"summary_contains": schemas.StringSchema("Only events whose title contains this text, case insensitive"),
+				}, "calendar_id", "minutes_before"),
+				Options: toolbox.RealObject(schemas.GoogleEventSoonOptions{
+					CalendarId:      "primary",
+					MinutesBefore:   15,
+					SummaryContains: "standup",
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleDriveNewFileAction),
+				Description: "A new file is added to a Google Drive folder",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"folder_id": schemas.StringSchema("Identifier of the folder, the last part of its URL").NonEmpty(),
+					"mime_type": schemas.StringSchema("Only files of this MIME type, e.g. application/pdf"),
+				}, "folder_id"),
+				Options: toolbox.RealObject(schemas.GoogleDriveNewFileOptions{
+					FolderId: "1AbCdEfGhIjKlMnOpQrStUvWxYz",
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.GoogleCreateEventReaction),
+				Description: "Create an event in Google Calendar",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"calendar_id": schemas.StringSchema("Identifier of the calendar, usually your email address").NonEmpty(),
+					"calendar_corpus": schemas.ObjectSchema("Event to create", map[string]*schemas.JsonSchema{
+						"summary":     schemas.StringSchema("Title of the event").NonEmpty(),
+						"description": schemas.StringSchema("Description of the event"),
+						"location":    schemas.StringSchema("Location of the event"),
+						"start": schemas.ObjectSchema("Start of the event", map[string]*schemas.JsonSchema{
+							"startDateTime": schemas.StringSchema("Local start date and time (2025-01-15T10:00:00)").NonEmpty(),
+							"startTimeZone": schemas.StringSchema("IANA time zone of the start (Europe/Paris)").NonEmpty(),
+						}, "startDateTime", "startTimeZone"),
+						"end": schemas.ObjectSchema("End of the event", map[string]*schemas.JsonSchema{
+							"endDateTime": schemas.StringSchema("Local end date and time (2025-01-15T11:00:00)").NonEmpty(),
+							"endTimeZone": schemas.StringSchema("IANA time zone of the end (Europe/Paris)").NonEmpty(),
+						}, "endDateTime", "endTimeZone"),
+						"attendees": schemas.ObjectSchema("Attendee to invite", map[string]*schemas.JsonSchema{
+							"email": schemas.StringSchema("Email address of the attendee").WithFormat("email"),
+						}),
+					}, "summary", "start", "end"),
+				}, "calendar_id", "calendar_corpus"),
+				Options: toolbox.RealObject(schemas.GoogleCalendarOptionsSchema{
+					CalendarId: "your address email",
+					CalendarCorpus: schemas.GoogleCalendarCorpusOptionsSchema{
+						Summary:     "Réunion",
+						Description: "on va parler de l'avenir",
+						Location:    "Osaka",
+						Start: schemas.GoogleCalendarCorpusOptionsTimeStartSchema{
+							StartDateTime: "2025-01-15T10:00:00.0000000",
+							StartTimeZone: "Europe/Paris",
+						},
+						End: schemas.GoogleCalendarCorpusOptionsTimeEndSchema{
+							EndDateTime: "2025-01-15T10:00:00.0000000",
+							EndTimeZone: "Europe/Paris",
+						},
+						Attendees: schemas.GoogleCalendarCorpusOptionsAttendees{
+							Email: "my.email@gmail.com",
+						},
+					},
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleSendEmailReaction),
+				Description: "Send an email from your Gmail account",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"to":        schemas.StringSchema("Recipients, separated by commas").NonEmpty(),
+					"subject":   schemas.StringSchema("Subject template").NonEmpty(),
+					"text_body": schemas.StringSchema("Plain text body template"),
+					"html_body": schemas.StringSchema("HTML body template, event values are escaped"),
+				}, "to", "subject"),
+				Options: toolbox.RealObject(schemas.EmailSendOptions{
+					To:       "my.email@gmail.com",
+					Subject:  "{{.workflow.name}} was triggered",
+					TextBody: "Your workflow {{.workflow.name}} was triggered at {{.now}}.",
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleApplyLabelReaction),
+				Description: "Apply a Gmail label to an email, the label is created if needed",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"label":      schemas.StringSchema("Name of the label").NonEmpty(),
+					"message_id": schemas.StringSchema("Gmail id of the email, {{.event.id}} for the mail of the new email action").NonEmpty().WithDefault("{{.event.id}}"),
+				}, "label", "message_id"),
+				Options: toolbox.RealObject(schemas.GmailApplyLabelOptions{
+					Label:     "Area51",
+					MessageId: "{{.event.id}}",
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleSheetsAppendRowReaction),
+				Description: "Append a row to a Google Sheet",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"spreadsheet_id": schemas.StringSchema("Identifier of the spreadsheet, found in its URL").NonEmpty(),
+					"sheet":          schemas.StringSchema("Name of the sheet").NonEmpty().WithDefault("Sheet1"),
+					"values": schemas.ArraySchema(
+						"Cells of the row, each may contain {{.event.some_field}} style templates",
+						schemas.StringSchema("Value of a cell"),
+					),
+					"parse_values": schemas.BooleanSchema("Parse the values as if typed by hand: numbers, dates and formulas").WithDefault(false),
+				}, "spreadsheet_id", "sheet", "values"),
+				Options: toolbox.RealObject(schemas.GoogleSheetsAppendRowOptions{
+					SpreadsheetId: "1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms",
+					Sheet:         "Sheet1",
+					Values:        []string{"{{.now}}", "{{.workflow.name}}", "{{.event.subject}}"},
+				}),
+			},
+			{
+				Name:        string(schemas.GoogleDriveUploadReaction),
+				Description: "Upload a file to a Google Drive folder",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"folder_id":  schemas.StringSchema("Identifier of the folder, the last part of its URL").NonEmpty(),
+					"name":       schemas.StringSchema("Name of the file, may contain templates, taken from source_url when empty"),
+					"content":    schemas.StringSchema("Content template of the file, used when source_url is empty"),
+					"source_url": schemas.StringSchema("URL of a file to download and upload instead of content").WithFormat("uri"),
+					"mime_type":  schemas.StringSchema("MIME type of the file, text/plain for content by default"),
+				}, "folder_id"),
+				Options: toolbox.RealObject(schemas.GoogleDriveUploadOptions{
+					FolderId: "1AbCdEfGhIjKlMnOpQrStUvWxYz",
+					Name:     "{{.workflow.name}} {{.now}}.txt",
+					Content:  "{{.event.subject}}",
+				}),
+			},
+		},
+		OAuth: &schemas.ServiceOAuth{
+			AuthorizationUrl: "https://accounts.google.com/o/oauth2/v2/auth",
+			Scopes: []string{
+				"openid",
+				"https://www.googleapis.com/auth/userinfo.email",
+				"https://www.googleapis.com/auth/userinfo.profile",
+				"https://www.googleapis.com/auth/gmail.readonly",
+				"https://www.googleapis.com/auth/gmail.labels",
+				"https://www.googleapis.com/auth/gmail.modify",
+				"https://www.googleapis.com/auth/gmail.send",
+				"https://www.googleapis.com/auth/calendar",
+				"https://www.googleapis.com/auth/calendar.events",
+				googleSheetsScope,
+				googleDriveScope,
+			},
+		},
 	}
 }
 
@@ -127,6 +311,12 @@ func (service *googleService) FindActionByName(name string) func(channel chan st
 	switch name {
 	case string(schemas.GoogleGetEmailAction):
 		return service.GetEmailAction
+	case string(schemas.GoogleNewEmailAction):
+		return service.NewEmailMatchingQuery
+	case string(schemas.GoogleEventSoonAction):
+		return service.EventStartingSoon
+	case string(schemas.GoogleDriveNewFileAction):
+		return service.DriveNewFileInFolder
 	default:
 		return nil
 	}
@@ -136,6 +326,14 @@ func (service *googleService) FindReactionByName(name string) func(channel chan
 	switch name {
 	case string(schemas.GoogleCreateEventReaction):
 		return service.CreateEventReaction
+	case string(schemas.GoogleSendEmailReaction):
+		return service.SendEmailReaction
+	case string(schemas.GoogleApplyLabelReaction):
+		return service.ApplyLabelReaction
+	case string(schemas.GoogleSheetsAppendRowReaction):
+		return service.SheetsAppendRowReaction
+	case string(schemas.GoogleDriveUploadReaction):
+		return service.DriveUploadReaction
 	default:
 		return nil
 	}
@@ -349,3 +547,596 @@ func (service *googleService) CreateEventReaction(channel chan string, workflowI
 	service.workflowRepository.UpdateReactionTrigger(workflow)
 
 }
+
+const (
+	gmailApiUrl        = "https://gmail.googleapis.com/gmail/v1/users/me/"
+	gmailSearchPages   = 5
+	gmailSearchPerPage = 100
+)
+
+// getGoogleToken returns the Google access token of the user. Tokens stored
+// with their granted scopes must hold the given ones, older tokens are
+// trusted as is. When several Google tokens are stored, the first one
+// covering the scopes is used.
+func (service *googleService) getGoogleToken(userId uint64, scopes ...string) (string, error) {
+	allTokens, err := service.serviceToken.GetTokenByUserId(userId)
+	if err != nil {
+		return "", err
+	}
+	searchedService := service.serviceRepository.FindByName(schemas.Google)
+	var missing []string
+	for _, token := range allTokens {
+		if token.ServiceId != searchedService.Id {
+			continue
+		}
+		if token.Scope != "" {
+			tokenMissing := toolbox.MissingScopes(token.Scope, scopes)
+			if len(tokenMissing) != 0 {
+				if missing == nil {
+					missing = tokenMissing
+				}
+				continue
+			}
+		}
+		return token.Token, nil
+	}
+	if missing != nil {
+		return "", fmt.Errorf("%w: %s", schemas.ErrGoogleScopeMissing, strings.Join(missing, " "))
+	}
+	return "", schemas.ErrGoogleTokenNotFound
+}
+
+// gmailRequest calls the Gmail API for the authenticated user.
+func gmailRequest(accessToken string, method string, path string, query url.Values, body interface{}, result interface{}) (int, error) {
+	return googleRequest(accessToken, method, gmailApiUrl+path, query, body, result)
+}
+
+// googleRequest calls a Google JSON API. result may be nil; the status code
+// is returned with any non 2xx status as an error.
+func googleRequest(accessToken string, method string, requestUrl string, query url.Values, body interface{}, result interface{}) (int, error) {
+	if len(query) != 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	var requestBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		requestBody = bytes.NewReader(jsonData)
+	}
+	request, err := http.NewRequest(method, requestUrl, requestBody)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("google answered %d: %s", response.StatusCode, string(bodyBytes))
+	}
+	if result != nil {
+		err = json.Unmarshal(bodyBytes, result)
+		if err != nil {
+			return response.StatusCode, err
+		}
+	}
+	return response.StatusCode, nil
+}
+
+// NewEmailMatchingQuery follows the mailbox history from the last seen
+// history id and queues the added mails matching the query. The first check
+// only records the current history id.
+func (service *googleService) NewEmailMatchingQuery(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkNewEmails(workflowId, actionOption)
+}
+
+func (service *googleService) checkNewEmails(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.GmailNewEmailOptions{}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	accessToken, err := service.getGoogleToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.GmailNewEmailState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing gmail state:", err)
+		}
+	}
+
+	message := "No new email"
+	if state.HistoryId == "" {
+		err = service.resetGmailHistory(accessToken, &state)
+		message = "Gmail history initialized"
+	} else {
+		var addedIds []string
+		var historyId string
+		var status int
+		addedIds, historyId, status, err = listGmailAddedMessages(accessToken, state.HistoryId)
+		if status == http.StatusNotFound {
+			// the history id is too old, start again from now
+			err = service.resetGmailHistory(accessToken, &state)
+			message = "Gmail history expired, restarted from now"
+		} else if err == nil {
+			var events []schemas.GmailMessageEvent
+			events, err = findGmailMatchingMessages(accessToken, options.Query, addedIds)
+			if err == nil {
+				state.HistoryId = historyId
+				state.Pending = append(state.Pending, events...)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println("Error checking gmail:", err)
+		return err.Error()
+	}
+
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "New email"
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling gmail state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+func (service *googleService) resetGmailHistory(accessToken string, state *schemas.GmailNewEmailState) error {
+	profile := schemas.GmailProfile{}
+	_, err := gmailRequest(accessToken, http.MethodGet, "profile", nil, nil, &profile)
+	if err != nil {
+		return err
+	}
+	state.HistoryId = profile.HistoryId
+	return nil
+}
+
+// listGmailAddedMessages returns the ids of the mails added since historyId,
+// oldest first, along with the history id to start from next time.
+func listGmailAddedMessages(accessToken string, historyId string) (addedIds []string, lastHistoryId string, status int, err error) {
+	seen := map[string]bool{}
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("startHistoryId", historyId)
+		query.Set("historyTypes", "messageAdded")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		history := schemas.GmailHistoryResponse{}
+		status, err = gmailRequest(accessToken, http.MethodGet, "history", query, nil, &history)
+		if err != nil {
+			return nil, "", status, err
+		}
+		for _, record := range history.History {
+			for _, added := range record.MessagesAdded {
+				if !seen[added.Message.Id] {
+					seen[added.Message.Id] = true
+					addedIds = append(addedIds, added.Message.Id)
+				}
+			}
+		}
+		lastHistoryId = history.HistoryId
+		if history.NextPageToken == "" {
+			return addedIds, lastHistoryId, status, nil
+		}
+		pageToken = history.NextPageToken
+	}
+}
+
+// findGmailMatchingMessages keeps the ids matching query. The history has
+// no search, so the newest results of the query are intersected with ids.
+func findGmailMatchingMessages(accessToken string, query string, ids []string) (events []schemas.GmailMessageEvent, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	matching := map[string]bool{}
+	pageToken := ""
+	for page := 0; page < gmailSearchPages && len(matching) < len(wanted); page++ {
+		search := url.Values{}
+		search.Set("q", query)
+		search.Set("maxResults", fmt.Sprint(gmailSearchPerPage))
+		if pageToken != "" {
+			search.Set("pageToken", pageToken)
+		}
+		list := schemas.GmailMessageListResponse{}
+		_, err = gmailRequest(accessToken, http.MethodGet, "messages", search, nil, &list)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range list.Messages {
+			if wanted[message.Id] {
+				matching[message.Id] = true
+			}
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+
+	for _, id := range ids {
+		if !matching[id] {
+			continue
+		}
+		event, err := getGmailMessageEvent(accessToken, id)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func getGmailMessageEvent(accessToken string, id string) (schemas.GmailMessageEvent, error) {
+	query := url.Values{}
+	query.Set("format", "metadata")
+	query["metadataHeaders"] = []string{"From", "To", "Subject", "Date"}
+	message := schemas.GmailMessage{}
+	_, err := gmailRequest(accessToken, http.MethodGet, "messages/"+url.PathEscape(id), query, nil, &message)
+	if err != nil {
+		return schemas.GmailMessageEvent{}, err
+	}
+	event := schemas.GmailMessageEvent{
+		Id:       message.Id,
+		ThreadId: message.ThreadId,
+		Snippet:  message.Snippet,
+		LabelIds: message.LabelIds,
+	}
+	for _, header := range message.Payload.Headers {
+		switch strings.ToLower(header.Name) {
+		case "from":
+			event.From = header.Value
+		case "to":
+			event.To = header.Value
+		case "subject":
+			event.Subject = header.Value
+		case "date":
+			event.Date = header.Value
+		}
+	}
+	return event, nil
+}
+
+func (service *googleService) SendEmailReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.EmailSendOptions{}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.GmailReactionResponse{}
+	err = service.sendGmail(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error sending gmail:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveGmailReaction(workflow, result)
+}
+
+func (service *googleService) sendGmail(workflow schemas.Workflow, options schemas.EmailSendOptions, result *schemas.GmailReactionResponse) error {
+	googleToken, err := service.getGoogleToken(workflow.UserId)
+	if err != nil {
+		return err
+	}
+	message, err := buildEmailMessage(workflow, options)
+	if err != nil {
+		return err
+	}
+	result.To = strings.Join(message.To, ", ")
+	result.Subject = message.Subject
+	if message.TextBody == "" && message.HtmlBody == "" {
+		return schemas.ErrMailEmptyBody
+	}
+	profile := schemas.GmailProfile{}
+	_, err = gmailRequest(googleToken, http.MethodGet, "profile", nil, nil, &profile)
+	if err != nil {
+		return err
+	}
+	raw, err := buildMail(&mail.Address{Address: profile.EmailAddress}, message)
+	if err != nil {
+		return err
+	}
+	sent := schemas.GmailMessageReference{}
+	_, err = gmailRequest(googleToken, http.MethodPost, "messages/send", nil, map[string]string{
+		"raw": base64.URLEncoding.EncodeToString(raw),
+	}, &sent)
+	result.MessageId = sent.Id
+	return err
+}
+
+func (service *googleService) ApplyLabelReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.GmailApplyLabelOptions{}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.GmailReactionResponse{Label: options.Label}
+	err = service.applyGmailLabel(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error applying gmail label:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveGmailReaction(workflow, result)
+}
+
+func (service *googleService) applyGmailLabel(workflow schemas.Workflow, options schemas.GmailApplyLabelOptions, result *schemas.GmailReactionResponse) error {
+	googleToken, err := service.getGoogleToken(workflow.UserId)
+	if err != nil {
+		return err
+	}
+	messageId, err := toolbox.RenderTemplate(options.MessageId, toolbox.WorkflowTemplateData(workflow))
+	if err != nil {
+		return err
+	}
+	messageId = strings.TrimSpace(messageId)
+	if messageId == "" || messageId == "<no value>" {
+		return fmt.Errorf("message_id is empty")
+	}
+	result.MessageId = messageId
+
+	labels := schemas.GmailLabelListResponse{}
+	_, err = gmailRequest(googleToken, http.MethodGet, "labels", nil, nil, &labels)
+	if err != nil {
+		return err
+	}
+	label := schemas.GmailLabel{}
+	for _, existingLabel := range labels.Labels {
+		if strings.EqualFold(existingLabel.Name, options.Label) {
+			label = existingLabel
+			break
+		}
+	}
+	if label.Id == "" {
+		_, err = gmailRequest(googleToken, http.MethodPost, "labels", nil, map[string]string{
+			"name":                  options.Label,
+			"labelListVisibility":   "labelShow",
+			"messageListVisibility": "show",
+		}, &label)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = gmailRequest(googleToken, http.MethodPost, "messages/"+url.PathEscape(messageId)+"/modify", nil, map[string][]string{
+		"addLabelIds": {label.Id},
+	}, nil)
+	return err
+}
+
+func (service *googleService) saveGmailReaction(workflow schemas.Workflow, result schemas.GmailReactionResponse) {
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflow.Id,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+// EventStartingSoon triggers once per occurrence when an event of the
+// calendar starts within minutes_before. Recurring events are expanded by
+// the API (singleEvents), each occurrence having its own id.
+func (service *googleService) EventStartingSoon(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkEventsStartingSoon(workflowId, actionOption)
+}
+
+func (service *googleService) checkEventsStartingSoon(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.GoogleEventSoonOptions{CalendarId: "primary"}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	googleToken, err := service.getGoogleToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.GoogleEventSoonState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing calendar state:", err)
+		}
+	}
+	if state.Notified == nil {
+		state.Notified = map[string]time.Time{}
+	}
+
+	now := time.Now()
+	events, err := listEventsStartingSoon(googleToken, options, now)
+	if err != nil {
+		fmt.Println("Error listing calendar events:", err)
+		return err.Error()
+	}
+	for _, event := range events {
+		start, _ := time.Parse(time.RFC3339, event.Start)
+		key := event.Id + "@" + event.Start
+		if _, notified := state.Notified[key]; notified {
+			continue
+		}
+		state.Notified[key] = start
+		state.Pending = append(state.Pending, event)
+	}
+	for key, start := range state.Notified {
+		if start.Before(now.Add(-24 * time.Hour)) {
+			delete(state.Notified, key)
+		}
+	}
+
+	message := "No event starting soon"
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "Event starting soon"
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling calendar state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+// listEventsStartingSoon returns the confirmed occurrences starting in
+// (now, now + minutes_before], oldest first. All-day events start at
+// midnight in the time zone of the calendar.
+func listEventsStartingSoon(accessToken string, options schemas.GoogleEventSoonOptions, now time.Time) (events []schemas.GoogleEventSoonEvent, err error) {
+	windowEnd := now.Add(time.Duration(options.MinutesBefore) * time.Minute)
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("singleEvents", "true")
+		query.Set("orderBy", "startTime")
+		// timeMin filters on the end of the events, timeMax on their start
+		query.Set("timeMin", now.UTC().Format(time.RFC3339))
+		query.Set("timeMax", windowEnd.UTC().Format(time.RFC3339))
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		requestUrl := "https://www.googleapis.com/calendar/v3/calendars/" + url.PathEscape(options.CalendarId) + "/events"
+		list := schemas.GoogleCalendarEventsResponse{}
+		_, err = googleRequest(accessToken, http.MethodGet, requestUrl, query, nil, &list)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := time.LoadLocation(list.TimeZone)
+		if err != nil {
+			location = time.UTC
+		}
+		for _, item := range list.Items {
+			if item.Status == "cancelled" {
+				continue
+			}
+			if options.SummaryContains != "" && !strings.Contains(strings.ToLower(item.Summary), strings.ToLower(options.SummaryContains)) {
+				continue
+			}
+			start, allDay, err := parseCalendarEventTime(item.Start, location)
+			if err != nil {
+				fmt.Println("Error parsing event start:", err)
+				continue
+			}
+			if !start.After(now) || start.After(windowEnd) {
+				continue
+			}
+			end, _, _ := parseCalendarEventTime(item.End, location)
+			timeZone := item.Start.TimeZone
+			if timeZone == "" {
+				timeZone = location.String()
+			}
+			events = append(events, schemas.GoogleEventSoonEvent{
+				Id:               item.Id,
+				RecurringEventId: item.RecurringEventId,
+				CalendarId:       options.CalendarId,
+				Summary:          item.Summary,
+				Description:      item.Description,
+				Location:         item.Location,
+				Start:            start.Format(time.RFC3339),
+				End:              end.Format(time.RFC3339),
+				AllDay:           allDay,
+				TimeZone:         timeZone,
+				MinutesBefore:    options.MinutesBefore,
+				HtmlLink:         item.HtmlLink,
+			})
+		}
+		if list.NextPageToken == "" {
+			return events, nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+func parseCalendarEventTime(eventTime schemas.GoogleCalendarEventTime, location *time.Location) (time.Time, bool, error) {
+	if eventTime.DateTime != "" {
+		parsedTime, err := time.Parse(time.RFC3339, eventTime.DateTime)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if eventLocation, err := time.LoadLocation(eventTime.TimeZone); err == nil && eventTime.TimeZone != "" {
+			parsedTime = parsedTime.In(eventLocation)
+		}
+		return parsedTime, false, nil
+	}
+	parsedTime, err := time.ParseInLocation(time.DateOnly, eventTime.Date, location)
+	return parsedTime, true, err
+}
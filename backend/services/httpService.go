@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const httpResponseBodyLimit = 64 * 1024
+
+type HttpService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type httpService struct {
+	workflowRepository          repository.WorkflowRepository
+	reactionResponseDataService ReactionResponseDataService
+	userSecretService           UserSecretService
+	mutex                       sync.Mutex
+}
+
+func NewHttpService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) HttpService {
+	return &httpService{
+		workflowRepository:          workflowRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		userSecretService:           userSecretService,
+	}
+}
+
+func (service *httpService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Http,
+			Description: "Call any HTTP API",
+			Image:       "https://img.icons8.com/?size=100&id=38536&format=png&color=000000",
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.HttpRequestReaction),
+				Description: "Send an HTTP request",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"method": schemas.EnumSchema("HTTP method", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead).WithDefault(http.MethodGet),
+					"url":    schemas.StringSchema("URL to call, may contain {{.workflow.name}} style templates").NonEmpty(),
+					"headers": {
+						Type:        "object",
+						Description: "Plain headers sent with the request",
+					},
+					"secret_headers": {
+						Type:        "object",
+						Description: "Headers whose value is read from one of your secrets, as header name -> secret name",
+					},
+					"body": schemas.StringSchema("Body template of the request"),
+					"expected_status": schemas.ArraySchema(
+						"Status codes considered a success, any 2xx when empty",
+						schemas.IntegerSchema("HTTP status code").WithMinimum(100).WithMaximum(599),
+					),
+					"timeout": schemas.IntegerSchema("Timeout of the request in seconds").WithMinimum(1).WithMaximum(60).WithDefault(10),
+				}, "method", "url"),
+				Options: toolbox.RealObject(schemas.HttpRequestOptions{
+					Method:         http.MethodPost,
+					Url:            "https://example.com/api/hooks",
+					Headers:        map[string]string{"Content-Type": "application/json"},
+					SecretHeaders:  map[string]string{"Authorization": "my_api_token"},
+					Body:           `{"workflow": "{{.workflow.name}}"}`,
+					ExpectedStatus: []int{200, 201},
+					Timeout:        10,
+				}),
+			},
+		},
+	}
+}
+
+func (service *httpService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	return nil
+}
+
+func (service *httpService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.HttpRequestReaction):
+		return service.SendRequest
+	default:
+		return nil
+	}
+}
+
+func (service *httpService) SendRequest(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+
+	options := schemas.HttpRequestOptions{
+		Method:  http.MethodGet,
+		Timeout: 10,
+	}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := service.doRequest(workflow, options)
+	if result.Error != "" {
+		fmt.Println("Error sending http request:", result.Error)
+	}
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+func (service *httpService) doRequest(workflow schemas.Workflow, options schemas.HttpRequestOptions) (result schemas.HttpRequestResponse) {
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	result.Method = strings.ToUpper(options.Method)
+	result.Body = json.RawMessage("null")
+
+	requestUrl, err := toolbox.RenderTemplate(options.Url, templateData)
+	if err != nil {
+		result.Error = "url: " + err.Error()
+		return result
+	}
+	result.Url = requestUrl
+	body, err := toolbox.RenderTemplate(options.Body, templateData)
+	if err != nil {
+		result.Error = "body: " + err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(options.Timeout)*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, result.Method, requestUrl, strings.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for name, value := range options.Headers {
+		renderedValue, err := toolbox.RenderTemplate(value, templateData)
+		if err != nil {
+			result.Error = "header " + name + ": " + err.Error()
+			return result
+		}
+		request.Header.Set(name, renderedValue)
+	}
+	for name, secretName := range options.SecretHeaders {
+		secretValue, err := service.userSecretService.GetSecretValue(workflow.UserId, secretName)
+		if err != nil {
+			result.Error = "header " + name + ": " + err.Error()
+			return result
+		}
+		request.Header.Set(name, secretValue)
+	}
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer response.Body.Close()
+
+	result.StatusCode = response.StatusCode
+	result.Expected = isExpectedStatus(response.StatusCode, options.ExpectedStatus)
+	bodyBytes, err := io.ReadAll(io.LimitReader(response.Body, httpResponseBodyLimit))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if json.Valid(bodyBytes) {
+		result.Body = bodyBytes
+	} else {
+		result.Body = toolbox.RealObject(string(bodyBytes))
+	}
+	if !result.Expected {
+		result.Error = fmt.Sprintf("unexpected status code %d", response.StatusCode)
+	}
+	return result
+}
+
+func isExpectedStatus(statusCode int, expectedStatus []int) bool {
+	if len(expectedStatus) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	for _, expected := range expectedStatus {
+		if statusCode == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *httpService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
@@ -4,13 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"area51/repository"
 	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	interpolApiUrl        = "https://ws-public.interpol.int/notices/v1/"
+	interpolNoticesPage   = 160
+	interpolMaxPages      = 10
+	interpolCheckInterval = 10 * time.Minute
+	interpolMaxSeenIds    = 5000
 )
 
 type InterpolService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
@@ -38,6 +52,74 @@ func NewInterpolService(
 	}
 }
 
+func (service *interpolService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Interpol,
+			Description: "This is the Interpol Service",
+			Image:       "https://img.icons8.com/?size=100&id=vlYbYJMp9Ixb&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.InterpolNewRedNotice),
+				Description: "A new red notice matches the filters",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"sexId":       schemas.EnumSchema("Sex of the wanted persons", "M", "F", "U"),
+					"nationality": schemas.StringSchema("Nationality as a two letters country code, e.g. FR").WithPattern(`^[A-Z]{2}$`),
+					"age_min":     schemas.IntegerSchema("Minimum age").WithMinimum(0).WithMaximum(120),
+					"age_max":     schemas.IntegerSchema("Maximum age").WithMinimum(0).WithMaximum(120),
+					"name":        schemas.StringSchema("Family name of the wanted person"),
+					"forename":    schemas.StringSchema("Forename of the wanted person"),
+				}),
+				Options: toolbox.RealObject(schemas.InterpolActionOptions{
+					SexId:       "M",
+					Nationality: "FR",
+					AgeMin:      18,
+					AgeMax:      40,
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.InterpolGetRedNotices),
+				Description: "Detect a change on a specific notice",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"firstname": schemas.StringSchema("Forename of the person to look for"),
+					"lastname":  schemas.StringSchema("Name of the person to look for"),
+				}),
+				Options: toolbox.RealObject(schemas.InterpolReactionOptionInfos{
+					FirstName: "Sylvain",
+					LastName:  "téun",
+				}),
+			},
+			{
+				Name:        string(schemas.InterpolGetYellowNotices),
+				Description: "Detect a change on a specific notice",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"firstname": schemas.StringSchema("Forename of the person to look for"),
+					"lastname":  schemas.StringSchema("Name of the person to look for"),
+				}),
+				Options: toolbox.RealObject(schemas.InterpolReactionOptionInfos{
+					FirstName: "Michel",
+					LastName:  "Levoisin",
+				}),
+			},
+			{
+				Name:        string(schemas.InterpolGetUNNotices),
+				Description: "Detect a change on a specific notice",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"firstname": schemas.StringSchema("Forename of the person to look for"),
+					"lastname":  schemas.StringSchema("Name of the person to look for"),
+				}),
+				Options: toolbox.RealObject(schemas.InterpolReactionOptionInfos{
+					FirstName: "Gérard",
+					LastName:  "Auplacard",
+				}),
+			},
+		},
+	}
+}
+
 func (service *interpolService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
 	switch name {
 	case string(schemas.InterpolNewRedNotice):
@@ -97,38 +179,15 @@ func (service *interpolService) GetNotices(channel chan string, workflowId uint6
 		return
 	}
 
-	request, err := http.NewRequest("GET", "https://ws-public.interpol.int/notices/v1/"+noticeType+"?forename="+options.FirstName+"&name="+options.LastName, nil)
-	if err != nil {
-		fmt.Printf("unable to create request because: %s", err)
-		return
-	}
-	request.Header.Set("Accept", "application/json")
-	request.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	request.Header.Set("Connection", "keep-alive")
-	request.Header.Set("Cache-Control", "no-cache")
-	request.Header.Set("Referer", "https://www.interpol.int/")
-	request.Header.Set("Origin", "https://www.interpol.int")
-	request.Header.Set("Upgrade-Insecure-Requests", "1")
-	request.Header.Set("Sec-Fetch-Dest", "document")
-	request.Header.Set("Sec-Fetch-Mode", "navigate")
-	request.Header.Set("Sec-Fetch-Site", "none")
-	request.Header.Set("Sec-Fetch-User", "?1")
-	client := &http.Client{}
-
-	response, err := client.Do(request)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
+	query := url.Values{}
+	query.Set("forename", options.FirstName)
+	query.Set("name", options.LastName)
 	result := schemas.InterpolNoticesList{}
-	err = json.NewDecoder(response.Body).Decode(&result)
+	err = interpolRequest(interpolApiUrl+noticeType+"?"+query.Encode(), &result)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	defer response.Body.Close()
 	savedResult := schemas.ReactionResponseData{
 		WorkflowId:  workflowId,
 		ApiResponse: json.RawMessage{},
@@ -149,94 +208,223 @@ func (service *interpolService) GetUserInfosByToken(accessToken string, serviceN
 }
 
 func (service *interpolService) GetNewRedNotice(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkNewRedNotices(workflowId, actionOption)
+}
+
+func (service *interpolService) checkNewRedNotices(workflowId uint64, actionOption json.RawMessage) string {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
-	workflow := service.workflowRepository.FindById(workflowId)
 
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
 	options := schemas.InterpolActionOptions{}
-	err := json.Unmarshal([]byte(actionOption), &options)
+	err = json.Unmarshal([]byte(actionOption), &options)
 	if err != nil {
-		fmt.Println("Error ->", err)
-		return
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	state := schemas.InterpolNoticeState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing interpol state:", err)
+		}
 	}
 
-	url := "https://ws-public.interpol.int/notices/v1/red?sexId=" + options.SexId
+	message := "No new red notice"
+	if time.Since(state.CheckedAt) >= interpolCheckInterval {
+		notices, err := listRedNotices(options)
+		if err != nil {
+			fmt.Println("Error listing red notices:", err)
+			return err.Error()
+		}
+		state.CheckedAt = time.Now()
+		for _, notice := range notices {
+			if notice.EntityId == nil || containsString(state.SeenIds, *notice.EntityId) {
+				continue
+			}
+			state.SeenIds = append(state.SeenIds, *notice.EntityId)
+			if !state.Initialized {
+				continue
+			}
+			event, err := redNoticeEvent(notice)
+			if err != nil {
+				fmt.Println("Error fetching red notice:", err)
+				state.SeenIds = state.SeenIds[:len(state.SeenIds)-1]
+				continue
+			}
+			state.Pending = append(state.Pending, event)
+		}
+		if len(state.SeenIds) > interpolMaxSeenIds {
+			state.SeenIds = state.SeenIds[len(state.SeenIds)-interpolMaxSeenIds:]
+		}
+		if !state.Initialized {
+			state.Initialized = true
+			message = "Red notices initialized"
+		}
+	}
 
-	request, err := http.NewRequest("GET", url, nil)
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "New red notice"
+	}
+	jsonData, err := json.Marshal(state)
 	if err != nil {
-		fmt.Printf("unable to create request because: %s", err)
-		return
+		fmt.Println("Error marshalling interpol state:", err)
+		return err.Error()
 	}
-	request.Header.Set("Accept", "application/json")
-	request.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	request.Header.Set("Connection", "keep-alive")
-	request.Header.Set("Cache-Control", "no-cache")
-	request.Header.Set("Referer", "https://www.interpol.int/")
-	request.Header.Set("Origin", "https://www.interpol.int")
-	request.Header.Set("Upgrade-Insecure-Requests", "1")
-	request.Header.Set("Sec-Fetch-Dest", "document")
-	request.Header.Set("Sec-Fetch-Mode", "navigate")
-	request.Header.Set("Sec-Fetch-Site", "none")
-	request.Header.Set("Sec-Fetch-User", "?1")
-	client := &http.Client{}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
 
-	response, err := client.Do(request)
-	if err != nil {
-		fmt.Println(err)
-		return
+// listRedNotices returns every red notice matching the options, following
+// the next links up to interpolMaxPages pages.
+func listRedNotices(options schemas.InterpolActionOptions) (notices []schemas.InterpolListedNotice, err error) {
+	query := url.Values{}
+	query.Set("resultPerPage", strconv.Itoa(interpolNoticesPage))
+	if options.SexId != "" {
+		query.Set("sexId", options.SexId)
+	}
+	if options.Nationality != "" {
+		query.Set("nationality", strings.ToUpper(options.Nationality))
+	}
+	if options.AgeMin != 0 {
+		query.Set("ageMin", strconv.Itoa(options.AgeMin))
+	}
+	if options.AgeMax != 0 {
+		query.Set("ageMax", strconv.Itoa(options.AgeMax))
+	}
+	if options.Name != "" {
+		query.Set("name", options.Name)
 	}
+	if options.Forename != "" {
+		query.Set("forename", options.Forename)
+	}
+
+	requestUrl := interpolApiUrl + "red?" + query.Encode()
+	for page := 0; page < interpolMaxPages && requestUrl != ""; page++ {
+		result := schemas.InterpolNoticesList{}
+		err = interpolRequest(requestUrl, &result)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, result.Embedded.Notices...)
+		requestUrl = ""
+		if result.Links.Next != nil && len(result.Embedded.Notices) != 0 {
+			requestUrl = result.Links.Next.Href
+		}
+	}
+	return notices, nil
+}
 
-	result := schemas.InterpolActionOptionsInfo{}
-	err = json.NewDecoder(response.Body).Decode(&result)
+// redNoticeEvent builds the event of a listed notice from its detail and
+// images endpoints.
+func redNoticeEvent(notice schemas.InterpolListedNotice) (event schemas.InterpolNoticeEvent, err error) {
+	noticeId := strings.ReplaceAll(*notice.EntityId, "/", "-")
+	detailUrl := interpolApiUrl + "red/" + noticeId
+	if notice.Links.Self != nil && notice.Links.Self.Href != "" {
+		detailUrl = notice.Links.Self.Href
+	}
+	detail := schemas.InterpolListedNotice{}
+	err = interpolRequest(detailUrl, &detail)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return event, err
+	}
+	if detail.EntityId == nil {
+		detail.InterpolNotice = notice.InterpolNotice
 	}
-	defer response.Body.Close()
 
-	existingRecords := map[string]interface{}{}
+	event = schemas.InterpolNoticeEvent{
+		EntityId:            *notice.EntityId,
+		Name:                stringValue(detail.Name),
+		Forename:            stringValue(detail.Forename),
+		DateOfBirth:         stringValue(detail.DateOfBirth),
+		SexId:               stringValue(detail.SexId),
+		PlaceOfBirth:        stringValue(detail.PlaceOfBirth),
+		CountryOfBirthId:    stringValue(detail.CountryOfBirthId),
+		DistinguishingMarks: stringValue(detail.DistinguishingMarks),
+		Url:                 "https://www.interpol.int/How-we-work/Notices/Red-Notices/View-Red-Notices#" + noticeId,
+	}
+	if detail.Nationalities != nil {
+		event.Nationalities = *detail.Nationalities
+	} else if notice.Nationalities != nil {
+		event.Nationalities = *notice.Nationalities
+	}
+	if detail.ArrestWarrants != nil {
+		event.ArrestWarrants = *detail.ArrestWarrants
+	}
+	if detail.Height != nil {
+		event.Height = *detail.Height
+	}
+	if detail.Weight != nil {
+		event.Weight = *detail.Weight
+	}
+	if notice.Links.Thumbnail != nil {
+		event.Thumbnail = notice.Links.Thumbnail.Href
+	} else if detail.Links.Thumbnail != nil {
+		event.Thumbnail = detail.Links.Thumbnail.Href
+	}
 
-	if string(workflow.Utils) != "" {
-		err = json.Unmarshal([]byte(workflow.Utils), &existingRecords)
-		if err != nil {
-			fmt.Println("Error unmarshalling existingRecords:", err)
-			return
+	imagesUrl := interpolApiUrl + "red/" + noticeId + "/images"
+	if detail.Links.Images != nil && detail.Links.Images.Href != "" {
+		imagesUrl = detail.Links.Images.Href
+	} else if notice.Links.Images != nil && notice.Links.Images.Href != "" {
+		imagesUrl = notice.Links.Images.Href
+	}
+	images := schemas.InterpolImagesList{}
+	err = interpolRequest(imagesUrl, &images)
+	if err != nil {
+		fmt.Println("Error fetching red notice images:", err)
+		return event, nil
+	}
+	for _, image := range images.Embedded.Images {
+		if image.Links.Self != nil {
+			event.Images = append(event.Images, image.Links.Self.Href)
 		}
 	}
+	return event, nil
+}
 
-	if existingRecords["Total"] == nil {
-		existingRecords["Total"] = 0
-		jsonData, err := json.Marshal(existingRecords)
-		if err != nil {
-			fmt.Println("Error marshalling existingRecords:", err)
-			return
-		}
-		workflow.Utils = jsonData
-		service.workflowRepository.Update(workflow)
-	}
-	var TotalRedNotice int
-	switch v := existingRecords["Total"].(type) {
-	case float64:
-		TotalRedNotice = int(v)
-	case int:
-		TotalRedNotice = v
-	default:
-		fmt.Println("Error asserting NumPR to int or float64")
-		return
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
 	}
-	if TotalRedNotice != int(result.Total) {
-		existingRecords["Total"] = result.Total
-		jsonData, err := json.Marshal(existingRecords)
-		if err != nil {
-			fmt.Println("Error marshalling existingRecords:", err)
-			return
-		}
-		workflow.Utils = jsonData
-		workflow.ReactionTrigger = true
-		service.workflowRepository.Update(workflow)
-	} else {
-		return
+	return *value
+}
+
+// interpolRequest gets an Interpol API url and decodes the JSON response in
+// result. The API refuses requests that do not look like a browser.
+func interpolRequest(requestUrl string, result interface{}) error {
+	request, err := http.NewRequest("GET", requestUrl, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request because: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	request.Header.Set("Connection", "keep-alive")
+	request.Header.Set("Cache-Control", "no-cache")
+	request.Header.Set("Referer", "https://www.interpol.int/")
+	request.Header.Set("Origin", "https://www.interpol.int")
+	request.Header.Set("Sec-Fetch-Dest", "empty")
+	request.Header.Set("Sec-Fetch-Mode", "cors")
+	request.Header.Set("Sec-Fetch-Site", "same-site")
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("interpol api returned %s", response.Status)
 	}
-	channel <- "Number of red notices up to date"
+	return json.NewDecoder(response.Body).Decode(result)
 }
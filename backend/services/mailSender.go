@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"area51/schemas"
+)
+
+// MailSender delivers mails for the email reaction and the account mails.
+// The default one talks SMTP; any SMTP stand-in (e.g. Mailpit from
+// compose.dev.yaml) can be used locally, or MAIL_SENDER=log prints the mails
+// instead of sending them.
+type MailSender interface {
+	Send(message schemas.MailMessage) error
+}
+
+const MailSenderLog = "log"
+
+type smtpMailSender struct{}
+
+type logMailSender struct{}
+
+func NewMailSender() MailSender {
+	if strings.ToLower(os.Getenv("MAIL_SENDER")) == MailSenderLog {
+		return &logMailSender{}
+	}
+	return &smtpMailSender{}
+}
+
+// Send prints the mail to the standard output, so the account mails (codes
+// and links) can be followed without any SMTP server.
+func (sender *logMailSender) Send(message schemas.MailMessage) error {
+	if len(message.To) == 0 {
+		return schemas.ErrMailNoRecipient
+	}
+	if message.TextBody == "" && message.HtmlBody == "" {
+		return schemas.ErrMailEmptyBody
+	}
+	body := message.TextBody
+	if body == "" {
+		body = message.HtmlBody
+	}
+	fmt.Printf("Mail to %s\nSubject: %s\n\n%s\n", strings.Join(message.To, ", "), message.Subject, body)
+	return nil
+}
+
+// SmtpConfigFromEnv reads the SMTP configuration. It is read on each send so
+// operators can leave it unset when they do not want mails.
+func SmtpConfigFromEnv() schemas.SmtpConfig {
+	config := schemas.SmtpConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		Security: strings.ToLower(os.Getenv("SMTP_SECURITY")),
+	}
+	if config.Security == "" {
+		config.Security = schemas.SmtpSecurityStartTls
+	}
+	if config.Port == "" {
+		switch config.Security {
+		case schemas.SmtpSecurityTls:
+			config.Port = "465"
+		case schemas.SmtpSecurityNone:
+			config.Port = "25"
+		default:
+			config.Port = "587"
+		}
+	}
+	return config
+}
+
+func (sender *smtpMailSender) Send(message schemas.MailMessage) error {
+	config := SmtpConfigFromEnv()
+	if config.Host == "" || config.From == "" {
+		return schemas.ErrMailNotConfigured
+	}
+	if len(message.To) == 0 {
+		return schemas.ErrMailNoRecipient
+	}
+	if message.TextBody == "" && message.HtmlBody == "" {
+		return schemas.ErrMailEmptyBody
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+	content, err := buildMail(from, message)
+	if err != nil {
+		return err
+	}
+
+	client, err := dialSmtp(config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if config.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(from.Address)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range message.To {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func dialSmtp(config schemas.SmtpConfig) (*smtp.Client, error) {
+	address := net.JoinHostPort(config.Host, config.Port)
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	switch config.Security {
+	case schemas.SmtpSecurityTls:
+		connection, err := tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(connection, config.Host)
+	case schemas.SmtpSecurityStartTls, schemas.SmtpSecurityNone:
+		connection, err := dialer.Dial("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		client, err := smtp.NewClient(connection, config.Host)
+		if err != nil {
+			connection.Close()
+			return nil, err
+		}
+		if config.Security == schemas.SmtpSecurityStartTls {
+			err = client.StartTLS(tlsConfig)
+			if err != nil {
+				client.Close()
+				return nil, err
+			}
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("unknown SMTP_SECURITY %q, expected none, starttls or tls", config.Security)
+}
+
+func buildMail(from *mail.Address, message schemas.MailMessage) ([]byte, error) {
+	var content bytes.Buffer
+	messageId := make([]byte, 16)
+	_, err := rand.Read(messageId)
+	if err != nil {
+		return nil, err
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	fmt.Fprintf(&content, "From: %s\r\n", from.String())
+	fmt.Fprintf(&content, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&content, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&content, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&content, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(messageId), domain)
+	content.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.TextBody != "" && message.HtmlBody != "" {
+		boundary := "area51-" + hex.EncodeToString(messageId)
+		fmt.Fprintf(&content, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", message.TextBody},
+			{"text/html", message.HtmlBody},
+		} {
+			fmt.Fprintf(&content, "--%s\r\n", boundary)
+			err = writeMailPart(&content, part.contentType, part.body)
+			if err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(&content, "--%s--\r\n", boundary)
+		return content.Bytes(), nil
+	}
+	if message.HtmlBody != "" {
+		err = writeMailPart(&content, "text/html", message.HtmlBody)
+	} else {
+		err = writeMailPart(&content, "text/plain", message.TextBody)
+	}
+	return content.Bytes(), err
+}
+
+func writeMailPart(content *bytes.Buffer, contentType string, body string) error {
+	fmt.Fprintf(content, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	content.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	writer := quotedprintable.NewWriter(content)
+	_, err := writer.Write([]byte(body))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	content.WriteString("\r\n")
+	return nil
+}
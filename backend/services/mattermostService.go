@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type MattermostService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type mattermostService struct {
+	chatWebhookReaction
+}
+
+func NewMattermostService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) MattermostService {
+	return &mattermostService{
+		chatWebhookReaction: chatWebhookReaction{
+			platform:                    schemas.Mattermost,
+			workflowRepository:          workflowRepository,
+			reactionResponseDataService: reactionResponseDataService,
+			userSecretService:           userSecretService,
+		},
+	}
+}
+
+func (service *mattermostService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Mattermost,
+			Description: "Post messages to Mattermost channels",
+			Image:       "https://img.icons8.com/?size=100&id=Ky1YcC2Wy6qy&format=png&color=000000",
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.MattermostPostMessageReaction),
+				Description: "Post a message with an optional attachment on a Mattermost webhook",
+				Schema: chatOptionsSchema(map[string]*schemas.JsonSchema{
+					"text":     schemas.StringSchema("Message template, supports markdown"),
+					"channel":  schemas.StringSchema("Channel overriding the webhook one, if the webhook allows it"),
+					"username": schemas.StringSchema("Name displayed instead of the webhook one"),
+					"icon_url": schemas.StringSchema("Icon displayed instead of the webhook one").WithFormat("uri"),
+					"attachment": schemas.ObjectSchema("Attachment shown under the message", map[string]*schemas.JsonSchema{
+						"title":      schemas.StringSchema("Attachment title template"),
+						"title_link": schemas.StringSchema("Link of the title template"),
+						"text":       schemas.StringSchema("Attachment text template, supports markdown"),
+						"color":      schemas.StringSchema("Color of the attachment border").WithPattern(`^#[0-9A-Fa-f]{6}$`),
+					}),
+				}),
+				Options: toolbox.RealObject(schemas.MattermostPostMessageOptions{
+					ChatWebhookTarget: schemas.ChatWebhookTarget{WebhookSecret: "mattermost_webhook"},
+					Text:              "Workflow **{{.workflow.name}}** was triggered",
+					Attachment: &schemas.MattermostAttachmentOption{
+						Title: "{{.workflow.name}}",
+						Text:  "Triggered at {{.now}}",
+						Color: "#1E325C",
+					},
+				}),
+			},
+		},
+	}
+}
+
+func (service *mattermostService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	return nil
+}
+
+func (service *mattermostService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.MattermostPostMessageReaction):
+		return service.PostMessage
+	default:
+		return nil
+	}
+}
+
+func (service *mattermostService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+func (service *mattermostService) PostMessage(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.post(workflowId, func(workflow schemas.Workflow, templateData map[string]interface{}) (schemas.ChatWebhookTarget, interface{}, error) {
+		var options schemas.MattermostPostMessageOptions
+		err := json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return options.ChatWebhookTarget, nil, fmt.Errorf("invalid options: %w", err)
+		}
+		renderer := chatTemplateRenderer{data: templateData}
+		payload := schemas.MattermostWebhookPayload{
+			Text:     renderer.render("text", options.Text),
+			Channel:  options.Channel,
+			Username: options.Username,
+			IconUrl:  options.IconUrl,
+		}
+		if options.Attachment != nil {
+			payload.Attachments = append(payload.Attachments, schemas.MattermostAttachmentOption{
+				Title:     renderer.render("attachment.title", options.Attachment.Title),
+				TitleLink: renderer.render("attachment.title_link", options.Attachment.TitleLink),
+				Text:      renderer.render("attachment.text", options.Attachment.Text),
+				Color:     options.Attachment.Color,
+			})
+		}
+		return options.ChatWebhookTarget, payload, renderer.err
+	})
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"area51/database"
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+	mfaLoginAttemptLimit = 10
+)
+
+type MfaService interface {
+	Enroll(user schemas.User) (schemas.MfaEnrollment, error)
+	Confirm(user schemas.User, code string) (schemas.MfaRecoveryCodes, error)
+	Disable(user schemas.User, code string) error
+	Login(credentials schemas.MfaLoginCredentials) (string, error)
+	Reset(admin schemas.User, userId uint64) error
+}
+
+type mfaService struct {
+	repository  repository.MfaRepository
+	userService UserService
+	serviceJWT  JWTService
+	limiter     *toolbox.RateLimiter
+}
+
+func NewMfaService(
+	repository repository.MfaRepository,
+	userService UserService,
+	serviceJWT JWTService,
+) MfaService {
+	return &mfaService{
+		repository:  repository,
+		userService: userService,
+		serviceJWT:  serviceJWT,
+		limiter:     toolbox.NewRateLimiter(15 * time.Minute),
+	}
+}
+
+// Enroll starts over with a new secret; it is only enforced once confirmed.
+func (service *mfaService) Enroll(user schemas.User) (schemas.MfaEnrollment, error) {
+	mfa := service.repository.FindByUserId(user.Id)
+	if mfa.Enabled {
+		return schemas.MfaEnrollment{}, schemas.ErrMfaAlreadyEnabled
+	}
+	secret, err := toolbox.GenerateTotpSecret()
+	if err != nil {
+		return schemas.MfaEnrollment{}, err
+	}
+	encryptedSecret, err := toolbox.EncryptSecret(secret)
+	if err != nil {
+		return schemas.MfaEnrollment{}, err
+	}
+	service.repository.DeleteByUserId(user.Id)
+	service.repository.Save(schemas.UserMfa{
+		UserId: user.Id,
+		Secret: encryptedSecret,
+	})
+	return schemas.MfaEnrollment{
+		Secret:     secret,
+		OtpauthUri: toolbox.TotpUri(schemas.MfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm enables the enrolled secret once the user proves their app has it,
+// and gives the recovery codes, which are not shown again.
+func (service *mfaService) Confirm(user schemas.User, code string) (schemas.MfaRecoveryCodes, error) {
+	mfa := service.repository.FindByUserId(user.Id)
+	if mfa.Id == 0 {
+		return schemas.MfaRecoveryCodes{}, schemas.ErrMfaNotEnrolled
+	}
+	if mfa.Enabled {
+		return schemas.MfaRecoveryCodes{}, schemas.ErrMfaAlreadyEnabled
+	}
+	valid, err := service.checkTotp(&mfa, code)
+	if err != nil {
+		return schemas.MfaRecoveryCodes{}, err
+	}
+	if !valid {
+		return schemas.MfaRecoveryCodes{}, schemas.ErrInvalidMfaCode
+	}
+	recoveryCodes, hashedCodes, err := generateMfaRecoveryCodes(user.Id)
+	if err != nil {
+		return schemas.MfaRecoveryCodes{}, err
+	}
+	now := time.Now()
+	mfa.Enabled = true
+	mfa.ConfirmedAt = &now
+	service.repository.Update(mfa)
+	service.repository.SaveRecoveryCodes(hashedCodes)
+	service.userService.SetMfaEnabled(user.Id, true)
+	return schemas.MfaRecoveryCodes{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (service *mfaService) Disable(user schemas.User, code string) error {
+	mfa := service.repository.FindByUserId(user.Id)
+	if !mfa.Enabled {
+		return schemas.ErrMfaNotEnabled
+	}
+	if !service.limiter.Allow(strconv.FormatUint(user.Id, 10), mfaLoginAttemptLimit) {
+		return schemas.ErrMfaRateLimited
+	}
+	valid, err := service.checkCode(&mfa, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return schemas.ErrInvalidMfaCode
+	}
+	service.repository.DeleteByUserId(user.Id)
+	service.userService.SetMfaEnabled(user.Id, false)
+	return nil
+}
+
+// Login is the second step of a password login: it trades the mfa pending
+// token and a TOTP or recovery code for a session.
+func (service *mfaService) Login(credentials schemas.MfaLoginCredentials) (string, error) {
+	userId, err := service.serviceJWT.GetUserIdFromMfaPendingToken(credentials.MfaToken)
+	if err != nil {
+		return "", schemas.ErrMfaPendingToken
+	}
+	if !service.limiter.Allow(strconv.FormatUint(userId, 10), mfaLoginAttemptLimit) {
+		return "", schemas.ErrMfaRateLimited
+	}
+	user := service.userService.GetUserById(userId)
+	mfa := service.repository.FindByUserId(userId)
+	if user.Id == 0 || !mfa.Enabled {
+		return "", schemas.ErrMfaPendingToken
+	}
+	valid, err := service.checkCode(&mfa, credentials.Code)
+	if err != nil {
+		return "", err
+	}
+	if !valid {
+		return "", schemas.ErrInvalidMfaCode
+	}
+	return service.serviceJWT.GenerateJWTToken(
+		strconv.FormatUint(user.Id, 10),
+		user.Username,
+		user.IsAdmin,
+	), nil
+}
+
+// Reset lets an admin remove the 2FA of a user who lost both their app and
+// their recovery codes.
+func (service *mfaService) Reset(admin schemas.User, userId uint64) error {
+	if !admin.IsAdmin {
+		return schemas.ErrAdminRequired
+	}
+	if service.userService.GetUserById(userId).Id == 0 {
+		return schemas.ErrUserNotFound
+	}
+	service.repository.DeleteByUserId(userId)
+	service.userService.SetMfaEnabled(userId, false)
+	return nil
+}
+
+// checkTotp accepts each step once, so a code seen by someone else can not be
+// replayed within its validity.
+func (service *mfaService) checkTotp(mfa *schemas.UserMfa, code string) (bool, error) {
+	secret, err := toolbox.DecryptSecret(mfa.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, valid := toolbox.ValidateTotp(secret, code, time.Now())
+	if !valid || step <= mfa.LastUsedStep {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	service.repository.Update(*mfa)
+	return true, nil
+}
+
+func (service *mfaService) checkCode(mfa *schemas.UserMfa, code string) (bool, error) {
+	valid, err := service.checkTotp(mfa, code)
+	if err != nil || valid {
+		return valid, err
+	}
+	code = normalizeMfaRecoveryCode(code)
+	for _, recoveryCode := range service.repository.FindUnusedRecoveryCodes(mfa.UserId) {
+		if database.CompareHashAndPassword(&recoveryCode.Code, &code) {
+			return service.repository.UseRecoveryCode(recoveryCode.Id, time.Now()), nil
+		}
+	}
+	return false, nil
+}
+
+func normalizeMfaRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// generateMfaRecoveryCodes returns the codes to show, formatted xxxxx-xxxxx,
+// and their hashes to store.
+func generateMfaRecoveryCodes(userId uint64) ([]string, []schemas.MfaRecoveryCode, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	var codes []string
+	var hashedCodes []schemas.MfaRecoveryCode
+	for index := 0; index < mfaRecoveryCodeCount; index++ {
+		random := make([]byte, 7)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(random))[:10]
+		hashedCode, err := database.HashPassword(code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashedCodes = append(hashedCodes, schemas.MfaRecoveryCode{
+			UserId: userId,
+			Code:   hashedCode,
+		})
+	}
+	return codes, hashedCodes, nil
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	// outlookWindowRefresh is how long a calendar view delta link is used
+	// before a new one is made for a window starting today.
+	outlookWindowRefresh   = 7 * 24 * time.Hour
+	outlookMailSeenLimit   = 500
+	outlookDateTimeLayout  = "2006-01-02T15:04:05.9999999"
+	outlookMailSelect      = "subject,from,toRecipients,receivedDateTime,bodyPreview,hasAttachments,webLink"
+	outlookDefaultDaysSpan = 30
+)
+
+// GetOutlookEvents follows the calendar view of the user with delta queries
+// and queues its created, updated and cancelled events. The first check, and
+// each renewal of the followed window, only records the current events.
+func (service *microsoftService) GetOutlookEvents(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkOutlookEvents(workflowId, actionOption)
+}
+
+func (service *microsoftService) checkOutlookEvents(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.MicrosoftOutlookEventsOptions{DaysAhead: outlookDefaultDaysSpan}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	if options.DaysAhead <= 0 {
+		options.DaysAhead = outlookDefaultDaysSpan
+	}
+	microsoftToken, err := service.getMicrosoftToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.MicrosoftOutlookEventsState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing outlook state:", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	message := "No outlook event change"
+	resync := state.DeltaLink == "" || now.Sub(state.WindowStart) > outlookWindowRefresh
+	if !resync {
+		deltaLink, err := graphDelta(microsoftToken, state.DeltaLink, func(item json.RawMessage) error {
+			event := schemas.MicrosoftCalendarEvent{}
+			err := json.Unmarshal(item, &event)
+			if err != nil {
+				return err
+			}
+			change, changed := applyOutlookEvent(&state, event)
+			if changed && outlookChangeMatches(change, options) {
+				state.Pending = append(state.Pending, change)
+			}
+			return nil
+		})
+		if errors.Is(err, schemas.ErrMicrosoftDeltaExpired) {
+			resync = true
+		} else if err != nil {
+			fmt.Println("Error following outlook events:", err)
+			return err.Error()
+		} else {
+			state.DeltaLink = deltaLink
+		}
+	}
+	if resync {
+		err = syncOutlookEvents(microsoftToken, &state, options, now)
+		if err != nil {
+			fmt.Println("Error synchronizing outlook events:", err)
+			return err.Error()
+		}
+		message = "Outlook calendar synchronized"
+	}
+	for id, known := range state.Known {
+		end, err := time.Parse(time.RFC3339, known.Event.End)
+		if err == nil && end.Before(now.Add(-24*time.Hour)) {
+			delete(state.Known, id)
+		}
+	}
+
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		message = "Outlook event " + string(state.Pending[0].ChangeType)
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling outlook state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+// syncOutlookEvents starts a new delta query on a window from yesterday to
+// days_ahead and records its events without reporting them.
+func syncOutlookEvents(accessToken string, state *schemas.MicrosoftOutlookEventsState, options schemas.MicrosoftOutlookEventsOptions, now time.Time) error {
+	windowStart := now.Add(-24 * time.Hour)
+	windowEnd := now.AddDate(0, 0, options.DaysAhead)
+	query := url.Values{}
+	query.Set("startDateTime", windowStart.Format(time.RFC3339))
+	query.Set("endDateTime", windowEnd.Format(time.RFC3339))
+
+	known := map[string]schemas.MicrosoftOutlookKnownEvent{}
+	deltaLink, err := graphDelta(accessToken, "/me/calendarView/delta?"+query.Encode(), func(item json.RawMessage) error {
+		event := schemas.MicrosoftCalendarEvent{}
+		err := json.Unmarshal(item, &event)
+		if err != nil {
+			return err
+		}
+		if event.Removed == nil {
+			change := outlookEventChange(event)
+			if event.IsCancelled {
+				change.ChangeType = schemas.MicrosoftEventCancelled
+			}
+			known[event.Id] = schemas.MicrosoftOutlookKnownEvent{ChangeKey: event.ChangeKey, Event: change}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	state.DeltaLink = deltaLink
+	state.WindowStart = windowStart
+	state.WindowEnd = windowEnd
+	state.Known = known
+	return nil
+}
+
+// applyOutlookEvent records an event of a delta page and tells whether it
+// is a change to report. Removed and cancelled events are reported once as
+// cancelled, with the details last known for them.
+func applyOutlookEvent(state *schemas.MicrosoftOutlookEventsState, event schemas.MicrosoftCalendarEvent) (schemas.MicrosoftOutlookEventChange, bool) {
+	if state.Known == nil {
+		state.Known = map[string]schemas.MicrosoftOutlookKnownEvent{}
+	}
+	known, isKnown := state.Known[event.Id]
+	alreadyCancelled := isKnown && known.Event.ChangeType == schemas.MicrosoftEventCancelled
+
+	if event.Removed != nil {
+		delete(state.Known, event.Id)
+		if !isKnown || alreadyCancelled {
+			return schemas.MicrosoftOutlookEventChange{}, false
+		}
+		change := known.Event
+		change.ChangeType = schemas.MicrosoftEventCancelled
+		return change, true
+	}
+
+	change := outlookEventChange(event)
+	if event.IsCancelled {
+		change.ChangeType = schemas.MicrosoftEventCancelled
+		state.Known[event.Id] = schemas.MicrosoftOutlookKnownEvent{ChangeKey: event.ChangeKey, Event: change}
+		return change, !alreadyCancelled
+	}
+	state.Known[event.Id] = schemas.MicrosoftOutlookKnownEvent{ChangeKey: event.ChangeKey, Event: change}
+	if !isKnown {
+		change.ChangeType = schemas.MicrosoftEventCreated
+		return change, true
+	}
+	if known.ChangeKey == event.ChangeKey {
+		return change, false
+	}
+	change.ChangeType = schemas.MicrosoftEventUpdated
+	return change, true
+}
+
+func outlookEventChange(event schemas.MicrosoftCalendarEvent) schemas.MicrosoftOutlookEventChange {
+	return schemas.MicrosoftOutlookEventChange{
+		Id:             event.Id,
+		Subject:        event.Subject,
+		Organizer:      event.Organizer.EmailAddress.Name,
+		OrganizerEmail: event.Organizer.EmailAddress.Address,
+		Start:          outlookDateTime(event.Start),
+		End:            outlookDateTime(event.End),
+		IsAllDay:       event.IsAllDay,
+		Location:       event.Location.DisplayName,
+		WebLink:        event.WebLink,
+	}
+}
+
+// outlookDateTime converts a Graph dateTimeTimeZone to RFC3339 in UTC.
+// Unknown time zones, such as Windows names, are read as UTC.
+func outlookDateTime(value schemas.MicrosoftDateTimeTimeZone) string {
+	location, err := time.LoadLocation(value.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	parsedTime, err := time.ParseInLocation(outlookDateTimeLayout, value.DateTime, location)
+	if err != nil {
+		return value.DateTime
+	}
+	return parsedTime.UTC().Format(time.RFC3339)
+}
+
+func outlookChangeMatches(change schemas.MicrosoftOutlookEventChange, options schemas.MicrosoftOutlookEventsOptions) bool {
+	if options.Subject != "" && !strings.Contains(strings.ToLower(change.Subject), strings.ToLower(options.Subject)) {
+		return false
+	}
+	if len(options.ChangeTypes) == 0 {
+		return true
+	}
+	for _, changeType := range options.ChangeTypes {
+		if changeType == change.ChangeType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewOutlookMail follows a mail folder with delta queries and queues the
+// mails received since the workflow started.
+func (service *microsoftService) NewOutlookMail(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkNewOutlookMails(workflowId, actionOption)
+}
+
+func (service *microsoftService) checkNewOutlookMails(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.MicrosoftNewMailOptions{Folder: "inbox"}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	microsoftToken, err := service.getMicrosoftToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.MicrosoftNewMailState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing outlook mail state:", err)
+		}
+	}
+	if state.FolderId == "" {
+		state.FolderId, err = findOutlookMailFolder(microsoftToken, options.Folder)
+		if err != nil {
+			fmt.Println("Error finding outlook folder:", err)
+			return err.Error()
+		}
+	}
+
+	message := "No new outlook mail"
+	initialSync := state.DeltaLink == ""
+	if initialSync {
+		state.Since = time.Now().UTC()
+		message = "Outlook folder synchronized"
+	}
+	handleMail := func(item json.RawMessage) error {
+		mail := schemas.MicrosoftMessage{}
+		err := json.Unmarshal(item, &mail)
+		if err != nil {
+			return err
+		}
+		if mail.Removed != nil || containsString(state.SeenIds, mail.Id) {
+			return nil
+		}
+		receivedAt, err := time.Parse(time.RFC3339, mail.ReceivedDateTime)
+		if err != nil || receivedAt.Before(state.Since) {
+			return nil
+		}
+		state.SeenIds = append(state.SeenIds, mail.Id)
+		if len(state.SeenIds) > outlookMailSeenLimit {
+			state.SeenIds = state.SeenIds[len(state.SeenIds)-outlookMailSeenLimit:]
+		}
+		event := outlookMailEvent(mail, options.Folder)
+		if !initialSync && outlookMailMatches(event, options) {
+			state.Pending = append(state.Pending, event)
+		}
+		return nil
+	}
+
+	link := state.DeltaLink
+	if initialSync {
+		link = outlookMailDeltaLink(state.FolderId, state.Since)
+	}
+	deltaLink, err := graphDelta(microsoftToken, link, handleMail)
+	if errors.Is(err, schemas.ErrMicrosoftDeltaExpired) {
+		// the mails received since Since are listed again, SeenIds skips
+		// the ones already reported
+		deltaLink, err = graphDelta(microsoftToken, outlookMailDeltaLink(state.FolderId, state.Since), handleMail)
+	}
+	if err != nil {
+		fmt.Println("Error following outlook mails:", err)
+		return err.Error()
+	}
+	state.DeltaLink = deltaLink
+
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "New outlook mail"
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling outlook mail state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+func outlookMailDeltaLink(folderId string, since time.Time) string {
+	query := url.Values{}
+	query.Set("$select", outlookMailSelect)
+	query.Set("$filter", "receivedDateTime ge "+since.Format(time.RFC3339))
+	return "/me/mailFolders/" + url.PathEscape(folderId) + "/messages/delta?" + query.Encode()
+}
+
+// findOutlookMailFolder resolves a well-known folder name or a folder id,
+// then falls back to a top level folder with that display name.
+func findOutlookMailFolder(accessToken string, folder string) (string, error) {
+	mailFolder := schemas.MicrosoftMailFolder{}
+	status, err := graphRequest(accessToken, http.MethodGet, "/me/mailFolders/"+url.PathEscape(folder)+"?$select=id,displayName", nil, &mailFolder)
+	if err == nil {
+		return mailFolder.Id, nil
+	}
+	if status != http.StatusNotFound && status != http.StatusBadRequest {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("$filter", "displayName eq '"+strings.ReplaceAll(folder, "'", "''")+"'")
+	query.Set("$select", "id,displayName")
+	folders := schemas.MicrosoftMailFolderListResponse{}
+	_, err = graphRequest(accessToken, http.MethodGet, "/me/mailFolders?"+query.Encode(), nil, &folders)
+	if err != nil {
+		return "", err
+	}
+	if len(folders.Value) == 0 {
+		return "", schemas.ErrMicrosoftFolderNotFound
+	}
+	return folders.Value[0].Id, nil
+}
+
+func outlookMailEvent(mail schemas.MicrosoftMessage, folder string) schemas.MicrosoftMailEvent {
+	recipients := make([]string, 0, len(mail.ToRecipients))
+	for _, recipient := range mail.ToRecipients {
+		recipients = append(recipients, recipient.EmailAddress.Address)
+	}
+	return schemas.MicrosoftMailEvent{
+		Id:             mail.Id,
+		Folder:         folder,
+		Subject:        mail.Subject,
+		From:           mail.From.EmailAddress.Address,
+		FromName:       mail.From.EmailAddress.Name,
+		To:             strings.Join(recipients, ", "),
+		ReceivedAt:     mail.ReceivedDateTime,
+		Preview:        mail.BodyPreview,
+		HasAttachments: mail.HasAttachments,
+		WebLink:        mail.WebLink,
+	}
+}
+
+func outlookMailMatches(event schemas.MicrosoftMailEvent, options schemas.MicrosoftNewMailOptions) bool {
+	if options.SubjectContains != "" && !strings.Contains(strings.ToLower(event.Subject), strings.ToLower(options.SubjectContains)) {
+		return false
+	}
+	if options.FromContains != "" {
+		from := strings.ToLower(event.FromName + " " + event.From)
+		if !strings.Contains(from, strings.ToLower(options.FromContains)) {
+			return false
+		}
+	}
+	return true
+}
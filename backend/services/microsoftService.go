@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 )
 
 type MicrosoftService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
@@ -24,11 +26,12 @@ type MicrosoftService interface {
 }
 
 type microsoftService struct {
-	serviceToken       TokenService
-	userService        UserService
-	workflowRepository repository.WorkflowRepository
-	serviceRepository  repository.ServiceRepository
-	mutex              sync.Mutex
+	serviceToken                TokenService
+	userService                 UserService
+	workflowRepository          repository.WorkflowRepository
+	serviceRepository           repository.ServiceRepository
+	reactionResponseDataService ReactionResponseDataService
+	mutex                       sync.Mutex
 }
 
 func NewMicrosoftService(
@@ -36,12 +39,165 @@ func NewMicrosoftService(
 	userService UserService,
 	workflowRepository repository.WorkflowRepository,
 	serviceRepository repository.ServiceRepository,
+	reactionResponseDataService ReactionResponseDataService,
 ) MicrosoftService {
 	return &microsoftService{
-		serviceToken:       serviceToken,
-		userService:        userService,
-		workflowRepository: workflowRepository,
-		serviceRepository:  serviceRepository,
+		serviceToken:                serviceToken,
+		userService:                 userService,
+		workflowRepository:          workflowRepository,
+		serviceRepository:           serviceRepository,
+		reactionResponseDataService: reactionResponseDataService,
+	}
+}
+
+func (service *microsoftService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Microsoft,
+			Description: "This is the Microsoft Service",
+			Image:       "https://upload.wikimedia.org/wikipedia/commons/thumb/4/44/Microsoft_logo.svg/1024px-Microsoft_logo.svg.png",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.MicrosoftOutlookEventsAction),
+				Description: "An event of the Outlook calendar is created, updated or cancelled",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"subject": schemas.StringSchema("Only events whose subject contains this text, case insensitive"),
+					"change_types": schemas.ArraySchema(
+						"Changes to trigger on, all of them when empty",
+						schemas.EnumSchema("Kind of change",
+							string(schemas.MicrosoftEventCreated),
+							string(schemas.MicrosoftEventUpdated),
+							string(schemas.MicrosoftEventCancelled),
+						),
+					),
+					"days_ahead": schemas.IntegerSchema("How many days ahead of today events are followed").WithMinimum(1).WithMaximum(365).WithDefault(30),
+				}),
+				Options: toolbox.RealObject(schemas.MicrosoftOutlookEventsOptions{
+					Subject:     "Réunion de travail",
+					ChangeTypes: []schemas.MicrosoftOutlookEventChangeType{schemas.MicrosoftEventCreated, schemas.MicrosoftEventCancelled},
+					DaysAhead:   30,
+				}),
+			},
+			{
+				Name:        string(schemas.MicrosoftNewMailAction),
+				Description: "A new mail arrives in an Outlook folder",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"folder":           schemas.StringSchema("Folder to watch: inbox, another well-known name, a folder name or id").NonEmpty().WithDefault("inbox"),
+					"from_contains":    schemas.StringSchema("Only mails whose sender contains this text, case insensitive"),
+					"subject_contains": schemas.StringSchema("Only mails whose subject contains this text, case insensitive"),
+				}, "folder"),
+				Options: toolbox.RealObject(schemas.MicrosoftNewMailOptions{
+					Folder:          "inbox",
+					SubjectContains: "invoice",
+				}),
+			},
+			{
+				Name:        string(schemas.MicrosoftTeamGroup),
+				Description: "Modify a Teams group",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"name": schemas.StringSchema("Topic of the Teams group chat").NonEmpty(),
+				}, "name"),
+				Options: toolbox.RealObject(schemas.MicrosoftTeamsGroupOptionsInfos{
+					Name: "Area51",
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.MicrosoftMailReaction),
+				Description: "Send an email",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"message": schemas.ObjectSchema("Mail to send", map[string]*schemas.JsonSchema{
+						"subject": schemas.StringSchema("Subject of the mail").NonEmpty(),
+						"body": schemas.ObjectSchema("Body of the mail", map[string]*schemas.JsonSchema{
+							"contentType": schemas.EnumSchema("Format of the content", "Text", "HTML").WithDefault("Text"),
+							"content":     schemas.StringSchema("Content of the mail"),
+						}, "contentType", "content"),
+						"address": schemas.StringSchema("Recipient of the mail").WithFormat("email"),
+					}, "subject", "body", "address"),
+					"saveToSentItems": schemas.EnumSchema("Whether to keep a copy in sent items", "true", "false").WithDefault("true"),
+				}, "message"),
+				Options: toolbox.RealObject(schemas.MicrosoftSendMailOptionsSchema{
+					Message: schemas.MicrosoftSendMailMainMessageOptionsSchema{
+						Subject: "We are going to Chicoutimi ?",
+						Body: schemas.MicrosoftSendMailBodyOptions{
+							ContentType: "Text",
+							Content:     "This email is to confirm our trip to Chicoutimi",
+						},
+						Address: "my.email@gmail.com",
+					},
+					SaveToSentItems: "true",
+				}),
+			},
+			{
+				Name:        string(schemas.MicrosoftTeamsChatMessageReaction),
+				Description: "Post a message to a Teams chat",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"chat":         schemas.StringSchema("Topic or identifier of the chat").NonEmpty(),
+					"content":      schemas.StringSchema("Message template, e.g. {{.event.subject}}").NonEmpty(),
+					"content_type": schemas.EnumSchema("Format of the content", "text", "html").WithDefault("text"),
+				}, "chat", "content"),
+				Options: toolbox.RealObject(schemas.MicrosoftTeamsChatMessageOptions{
+					Chat:        "Area51",
+					Content:     "{{.workflow.name}} triggered at {{.now}}",
+					ContentType: "text",
+				}),
+			},
+			{
+				Name:        string(schemas.MicrosoftTeamsChannelMessageReaction),
+				Description: "Post a message to a channel of a team",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"team":         schemas.StringSchema("Name or identifier of the team").NonEmpty(),
+					"channel":      schemas.StringSchema("Name or identifier of the channel").NonEmpty().WithDefault("General"),
+					"content":      schemas.StringSchema("Message template, e.g. {{.event.subject}}").NonEmpty(),
+					"content_type": schemas.EnumSchema("Format of the content", "text", "html").WithDefault("text"),
+				}, "team", "channel", "content"),
+				Options: toolbox.RealObject(schemas.MicrosoftTeamsChannelMessageOptions{
+					Team:        "Area51",
+					Channel:     "General",
+					Content:     "{{.workflow.name}} triggered at {{.now}}",
+					ContentType: "text",
+				}),
+			},
+			{
+				Name:        string(schemas.MicrosoftTodoTaskReaction),
+				Description: "Create a task in a Microsoft To Do list",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"list":       schemas.StringSchema("Name of the list, your default list when empty"),
+					"title":      schemas.StringSchema("Title template of the task").NonEmpty(),
+					"body":       schemas.StringSchema("Note template of the task"),
+					"due_date":   schemas.StringSchema("Due date template, as YYYY-MM-DD"),
+					"importance": schemas.EnumSchema("Importance of the task", "low", "normal", "high").WithDefault("normal"),
+				}, "title"),
+				Options: toolbox.RealObject(schemas.MicrosoftTodoTaskOptions{
+					List:       "Tasks",
+					Title:      "Answer {{.event.from}}",
+					Body:       "{{.event.preview}}",
+					Importance: "normal",
+				}),
+			},
+		},
+		OAuth: &schemas.ServiceOAuth{
+			AuthorizationUrl: "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
+			Scopes: []string{
+				"openid",
+				"profile",
+				"Calendars.Read",
+				"Calendars.ReadWrite",
+				"Calendars.ReadWrite.Shared",
+				"Calendars.Read.Shared",
+				"Chat.Read",
+				"Mail.Send",
+				"Mail.Read",
+				"ChatMessage.Send",
+				"Team.ReadBasic.All",
+				"Channel.ReadBasic.All",
+				"ChannelMessage.Send",
+				"Tasks.ReadWrite",
+				"https://graph.microsoft.com/User.Read",
+			},
+		},
 	}
 }
 
@@ -120,6 +276,8 @@ func (service *microsoftService) FindActionByName(name string) func(channel chan
 		return service.GetOutlookEvents
 	case string(schemas.MicrosoftTeamGroup):
 		return service.ModifyTeamGroup
+	case string(schemas.MicrosoftNewMailAction):
+		return service.NewOutlookMail
 	default:
 		return nil
 	}
@@ -129,11 +287,107 @@ func (service *microsoftService) FindReactionByName(name string) func(channel ch
 	switch name {
 	case string(schemas.MicrosoftMailReaction):
 		return service.SendMail
+	case string(schemas.MicrosoftTeamsChatMessageReaction):
+		return service.PostTeamsChatMessage
+	case string(schemas.MicrosoftTeamsChannelMessageReaction):
+		return service.PostTeamsChannelMessage
+	case string(schemas.MicrosoftTodoTaskReaction):
+		return service.CreateTodoTask
 	default:
 		return nil
 	}
 }
 
+const microsoftGraphApiUrl = "https://graph.microsoft.com/v1.0"
+
+func (service *microsoftService) getMicrosoftToken(userId uint64) (string, error) {
+	allTokens, err := service.serviceToken.GetTokenByUserId(userId)
+	if err != nil {
+		return "", err
+	}
+	searchedService := service.serviceRepository.FindByName(schemas.Microsoft)
+	for _, token := range allTokens {
+		if token.ServiceId == searchedService.Id {
+			return token.Token, nil
+		}
+	}
+	return "", schemas.ErrMicrosoftTokenNotFound
+}
+
+// graphRequest calls Microsoft Graph, requestUrl being a path under the v1.0
+// endpoint or a full link such as a delta link. Times are asked in UTC.
+// result may be nil; the status code is returned with any non 2xx status
+// as an error.
+func graphRequest(accessToken string, method string, requestUrl string, body interface{}, result interface{}) (int, error) {
+	if strings.HasPrefix(requestUrl, "/") {
+		requestUrl = microsoftGraphApiUrl + requestUrl
+	}
+	var requestBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		requestBody = bytes.NewReader(jsonData)
+	}
+	request, err := http.NewRequest(method, requestUrl, requestBody)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Prefer", `outlook.timezone="UTC", odata.maxpagesize=50`)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("microsoft graph answered %d: %s", response.StatusCode, string(bodyBytes))
+	}
+	if result != nil && len(bodyBytes) != 0 {
+		err = json.Unmarshal(bodyBytes, result)
+		if err != nil {
+			return response.StatusCode, err
+		}
+	}
+	return response.StatusCode, nil
+}
+
+// graphDelta follows a delta query from link until its delta link, handing
+// every item to handle. An expired link gives ErrMicrosoftDeltaExpired.
+func graphDelta(accessToken string, link string, handle func(item json.RawMessage) error) (string, error) {
+	for {
+		page := schemas.MicrosoftDeltaResponse{}
+		status, err := graphRequest(accessToken, http.MethodGet, link, nil, &page)
+		if status == http.StatusGone {
+			return "", schemas.ErrMicrosoftDeltaExpired
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, item := range page.Value {
+			err = handle(item)
+			if err != nil {
+				return "", err
+			}
+		}
+		if page.DeltaLink != "" {
+			return page.DeltaLink, nil
+		}
+		if page.NextLink == "" {
+			return "", fmt.Errorf("microsoft delta page without next or delta link")
+		}
+		link = page.NextLink
+	}
+}
+
 func (service *microsoftService) ModifyTeamGroup(channel chan string, workflowId uint64, actionOption json.RawMessage) {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
@@ -232,70 +486,6 @@ func (service *microsoftService) ModifyTeamGroup(channel chan string, workflowId
 	channel <- "Action of modifying teams finished"
 }
 
-func (service *microsoftService) GetOutlookEvents(channel chan string, workflowId uint64, actionOption json.RawMessage) {
-	service.mutex.Lock()
-	defer service.mutex.Unlock()
-
-	workflow := service.workflowRepository.FindById(workflowId)
-	user := service.userService.GetUserById(workflow.UserId)
-	allTokens, err := service.serviceToken.GetTokenByUserId(user.Id)
-	if err != nil {
-		channel <- err.Error()
-		return
-	}
-
-	url := "https://graph.microsoft.com/v1.0/me/events"
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		channel <- err.Error()
-		return
-	}
-	searchedService := service.serviceRepository.FindByName(schemas.Microsoft)
-	for _, token := range allTokens {
-		if token.ServiceId == searchedService.Id {
-			request.Header.Set("Authorization", "Bearer "+token.Token)
-		}
-	}
-
-	options := schemas.MicrosoftOutlookEventsOptions{}
-	err = json.Unmarshal([]byte(actionOption), &options)
-	if err != nil {
-		fmt.Println(err)
-		time.Sleep(30 * time.Second)
-		return
-	}
-
-	client := &http.Client{}
-	request.Header.Set("Accept", "application/json")
-	response, err := client.Do(request)
-	if err != nil {
-		channel <- err.Error()
-		return
-	}
-	defer response.Body.Close()
-
-	microsoftEventsSubjects := schemas.MicrosoftOutlookEventsResponse{}
-	bodyBytes, _ := io.ReadAll(response.Body)
-	err = json.Unmarshal(bodyBytes, &microsoftEventsSubjects)
-	if err != nil {
-		fmt.Printf("Error %s\n", err)
-		return
-	}
-
-	var chosenSubject *string
-	for _, subject := range microsoftEventsSubjects.Value {
-		if subject.Subject == options.Subject {
-			chosenSubject = &subject.Subject
-		}
-	}
-
-	if chosenSubject != nil {
-		workflow.ReactionTrigger = true
-		service.workflowRepository.Update(workflow)
-	}
-	channel <- "Finishing outlook action workflow"
-}
-
 func (service *microsoftService) SendMail(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+func (service *microsoftService) PostTeamsChatMessage(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.MicrosoftTeamsChatMessageOptions{ContentType: "text"}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.MicrosoftReactionResponse{}
+	err = service.postTeamsChatMessage(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error posting teams chat message:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveMicrosoftReaction(workflow, result)
+}
+
+func (service *microsoftService) postTeamsChatMessage(workflow schemas.Workflow, options schemas.MicrosoftTeamsChatMessageOptions, result *schemas.MicrosoftReactionResponse) error {
+	microsoftToken, err := service.getMicrosoftToken(workflow.UserId)
+	if err != nil {
+		return err
+	}
+	message, err := teamsMessage(workflow, options.Content, options.ContentType)
+	if err != nil {
+		return err
+	}
+	result.Content = message.Body.Content
+	chatId, err := findTeamsChat(microsoftToken, options.Chat)
+	if err != nil {
+		return err
+	}
+	result.ChatId = chatId
+
+	response := schemas.MicrosoftChatMessageResponse{}
+	_, err = graphRequest(microsoftToken, http.MethodPost, "/chats/"+url.PathEscape(chatId)+"/messages", message, &response)
+	result.Id = response.Id
+	result.WebUrl = response.WebUrl
+	return err
+}
+
+func (service *microsoftService) PostTeamsChannelMessage(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.MicrosoftTeamsChannelMessageOptions{Channel: "General", ContentType: "text"}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.MicrosoftReactionResponse{}
+	err = service.postTeamsChannelMessage(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error posting teams channel message:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveMicrosoftReaction(workflow, result)
+}
+
+func (service *microsoftService) postTeamsChannelMessage(workflow schemas.Workflow, options schemas.MicrosoftTeamsChannelMessageOptions, result *schemas.MicrosoftReactionResponse) error {
+	microsoftToken, err := service.getMicrosoftToken(workflow.UserId)
+	if err != nil {
+		return err
+	}
+	message, err := teamsMessage(workflow, options.Content, options.ContentType)
+	if err != nil {
+		return err
+	}
+	result.Content = message.Body.Content
+	team, err := findGraphResource(microsoftToken, "/me/joinedTeams?$select=id,displayName", options.Team)
+	if err != nil {
+		return err
+	}
+	if team.Id == "" {
+		return schemas.ErrMicrosoftTeamNotFound
+	}
+	result.TeamId = team.Id
+	teamChannel, err := findGraphResource(microsoftToken, "/teams/"+url.PathEscape(team.Id)+"/channels?$select=id,displayName", options.Channel)
+	if err != nil {
+		return err
+	}
+	if teamChannel.Id == "" {
+		return fmt.Errorf("channel %q not found in team %q", options.Channel, team.DisplayName)
+	}
+	result.ChannelId = teamChannel.Id
+
+	response := schemas.MicrosoftChatMessageResponse{}
+	_, err = graphRequest(microsoftToken, http.MethodPost, "/teams/"+url.PathEscape(team.Id)+"/channels/"+url.PathEscape(teamChannel.Id)+"/messages", message, &response)
+	result.Id = response.Id
+	result.WebUrl = response.WebUrl
+	return err
+}
+
+func (service *microsoftService) CreateTodoTask(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.MicrosoftTodoTaskOptions{Importance: "normal"}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.MicrosoftReactionResponse{}
+	err = service.createTodoTask(workflow, options, &result)
+	if err != nil {
+		fmt.Println("Error creating to do task:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+	service.saveMicrosoftReaction(workflow, result)
+}
+
+func (service *microsoftService) createTodoTask(workflow schemas.Workflow, options schemas.MicrosoftTodoTaskOptions, result *schemas.MicrosoftReactionResponse) error {
+	microsoftToken, err := service.getMicrosoftToken(workflow.UserId)
+	if err != nil {
+		return err
+	}
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	title, err := toolbox.RenderTemplate(options.Title, templateData)
+	if err != nil {
+		return fmt.Errorf("title: %w", err)
+	}
+	task := schemas.MicrosoftTodoTask{
+		Title:      strings.TrimSpace(title),
+		Importance: options.Importance,
+	}
+	if task.Title == "" {
+		return fmt.Errorf("title is empty")
+	}
+	result.Content = task.Title
+	body, err := toolbox.RenderTemplate(options.Body, templateData)
+	if err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	if body != "" {
+		task.Body = &schemas.MicrosoftItemBody{ContentType: "text", Content: body}
+	}
+	dueDate, err := toolbox.RenderTemplate(options.DueDate, templateData)
+	if err != nil {
+		return fmt.Errorf("due_date: %w", err)
+	}
+	if dueDate = strings.TrimSpace(dueDate); dueDate != "" {
+		parsedDate, err := time.Parse(time.DateOnly, dueDate)
+		if err != nil {
+			return fmt.Errorf("due_date must be YYYY-MM-DD: %w", err)
+		}
+		task.DueDateTime = &schemas.MicrosoftDateTimeTimeZone{
+			DateTime: parsedDate.Format("2006-01-02T15:04:05"),
+			TimeZone: "UTC",
+		}
+	}
+
+	list, err := findGraphResource(microsoftToken, "/me/todo/lists", options.List)
+	if err != nil {
+		return err
+	}
+	if list.Id == "" {
+		return schemas.ErrMicrosoftListNotFound
+	}
+	result.ListId = list.Id
+
+	response := schemas.MicrosoftChatMessageResponse{}
+	_, err = graphRequest(microsoftToken, http.MethodPost, "/me/todo/lists/"+url.PathEscape(list.Id)+"/tasks", task, &response)
+	result.Id = response.Id
+	return err
+}
+
+// teamsMessage renders the content of a Teams message, escaping the event
+// values in HTML messages.
+func teamsMessage(workflow schemas.Workflow, content string, contentType string) (schemas.MicrosoftChatMessage, error) {
+	templateData := toolbox.WorkflowTemplateData(workflow)
+	render := toolbox.RenderTemplate
+	if strings.EqualFold(contentType, "html") {
+		contentType = "html"
+		render = toolbox.RenderHtmlTemplate
+	} else {
+		contentType = "text"
+	}
+	renderedContent, err := render(content, templateData)
+	if err != nil {
+		return schemas.MicrosoftChatMessage{}, fmt.Errorf("content: %w", err)
+	}
+	if strings.TrimSpace(renderedContent) == "" {
+		return schemas.MicrosoftChatMessage{}, fmt.Errorf("content is empty")
+	}
+	return schemas.MicrosoftChatMessage{
+		Body: schemas.MicrosoftItemBody{ContentType: contentType, Content: renderedContent},
+	}, nil
+}
+
+// findTeamsChat returns the id of the chat of the user with this id or
+// topic.
+func findTeamsChat(accessToken string, chat string) (string, error) {
+	link := "/me/chats?$select=id,topic,chatType"
+	for link != "" {
+		chats := schemas.MicrosoftTeamsResponse{}
+		_, err := graphRequest(accessToken, http.MethodGet, link, nil, &chats)
+		if err != nil {
+			return "", err
+		}
+		for _, existingChat := range chats.Value {
+			if existingChat.Id == chat || strings.EqualFold(existingChat.Topic, chat) {
+				return existingChat.Id, nil
+			}
+		}
+		link = chats.NextLink
+	}
+	return "", schemas.ErrMicrosoftChatNotFound
+}
+
+// findGraphResource walks a Graph collection for the item with this id or
+// display name. An empty name matches the default To Do list. The zero
+// value is returned when nothing matches.
+func findGraphResource(accessToken string, link string, name string) (schemas.MicrosoftNamedResource, error) {
+	for link != "" {
+		resources := schemas.MicrosoftNamedResourceListResponse{}
+		_, err := graphRequest(accessToken, http.MethodGet, link, nil, &resources)
+		if err != nil {
+			return schemas.MicrosoftNamedResource{}, err
+		}
+		for _, resource := range resources.Value {
+			if name == "" && resource.WellknownListName == "defaultList" {
+				return resource, nil
+			}
+			if name != "" && (resource.Id == name || strings.EqualFold(resource.DisplayName, name)) {
+				return resource, nil
+			}
+		}
+		link = resources.NextLink
+	}
+	return schemas.MicrosoftNamedResource{}, nil
+}
+
+func (service *microsoftService) saveMicrosoftReaction(workflow schemas.Workflow, result schemas.MicrosoftReactionResponse) {
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflow.Id,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
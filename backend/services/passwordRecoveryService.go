@@ -0,0 +1,145 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"area51/database"
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	passwordRecoveryCodeDigits  = 8
+	passwordRecoveryLifetime    = 15 * time.Minute
+	passwordRecoveryMaxAttempts = 5
+	passwordRecoveryHourlyLimit = 3
+	passwordMinLength           = 8
+)
+
+type PasswordRecoveryService interface {
+	RequestRecovery(email string) error
+	ResetPassword(credentials schemas.ResetPasswordCredentials) error
+}
+
+type passwordRecoveryService struct {
+	repository  repository.PasswordRecoveryRepository
+	userService UserService
+	mailSender  MailSender
+	limiter     *toolbox.RateLimiter
+}
+
+func NewPasswordRecoveryService(
+	repository repository.PasswordRecoveryRepository,
+	userService UserService,
+	mailSender MailSender,
+) PasswordRecoveryService {
+	return &passwordRecoveryService{
+		repository:  repository,
+		userService: userService,
+		mailSender:  mailSender,
+		limiter:     toolbox.NewRateLimiter(time.Hour),
+	}
+}
+
+func generateRecoveryCode() (string, error) {
+	max := big.NewInt(1)
+	for index := 0; index < passwordRecoveryCodeDigits; index++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	number, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", passwordRecoveryCodeDigits, number), nil
+}
+
+// RequestRecovery mails a new code, replacing the previous ones. Unknown
+// emails are answered the same way so the route does not tell which accounts
+// exist; only the rate limit, counted per email, is reported. The code is
+// hashed and mailed after answering, so the response time does not tell
+// either.
+func (service *passwordRecoveryService) RequestRecovery(email string) error {
+	email = strings.TrimSpace(email)
+	if !service.limiter.Allow(strings.ToLower(email), passwordRecoveryHourlyLimit) {
+		return schemas.ErrPasswordRecoveryRateLimited
+	}
+	user := service.userService.GetUserByEmail(&email)
+	if user.Id == 0 || user.Email == nil {
+		return nil
+	}
+	go service.sendRecoveryCode(user)
+	return nil
+}
+
+func (service *passwordRecoveryService) sendRecoveryCode(user schemas.User) {
+	code, err := generateRecoveryCode()
+	if err != nil {
+		fmt.Println("Error generating password recovery code:", err)
+		return
+	}
+	hashedCode, err := database.HashPassword(code)
+	if err != nil {
+		fmt.Println("Error hashing password recovery code:", err)
+		return
+	}
+	service.repository.DeleteByUserId(user.Id)
+	service.repository.Save(schemas.PasswordRecovery{
+		UserId:    user.Id,
+		Code:      hashedCode,
+		ExpiresAt: time.Now().Add(passwordRecoveryLifetime),
+	})
+	err = service.mailSender.Send(schemas.MailMessage{
+		To:      []string{*user.Email},
+		Subject: "Area51 password recovery",
+		TextBody: fmt.Sprintf(
+			"Hello %s,\n\nYour password recovery code is %s, it expires in %d minutes.\n"+
+				"If you did not ask for it, you can ignore this mail.\n",
+			user.Username, code, int(passwordRecoveryLifetime.Minutes()),
+		),
+	})
+	if err != nil {
+		fmt.Println("Error sending password recovery mail:", err)
+	}
+}
+
+// ResetPassword consumes the code, sets the new password and revokes the
+// sessions opened before the reset.
+func (service *passwordRecoveryService) ResetPassword(credentials schemas.ResetPasswordCredentials) error {
+	if len(credentials.Password) < passwordMinLength {
+		return schemas.ErrPasswordTooShort
+	}
+	email := strings.TrimSpace(credentials.Email)
+	user := service.userService.GetUserByEmail(&email)
+	if user.Id == 0 {
+		return schemas.ErrInvalidRecoveryCode
+	}
+	recovery := service.repository.FindLatestByUserId(user.Id)
+	if recovery.Id == 0 || recovery.IsValidated ||
+		recovery.Attempts >= passwordRecoveryMaxAttempts || time.Now().After(recovery.ExpiresAt) {
+		return schemas.ErrInvalidRecoveryCode
+	}
+	code := strings.TrimSpace(credentials.Code)
+	if !database.CompareHashAndPassword(&recovery.Code, &code) {
+		recovery.Attempts++
+		service.repository.Update(recovery)
+		return schemas.ErrInvalidRecoveryCode
+	}
+	recovery.IsValidated = true
+	service.repository.Update(recovery)
+
+	hashedPassword, err := database.HashPassword(credentials.Password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return service.userService.UpdateUserInfos(schemas.User{
+		Id:                user.Id,
+		Password:          &hashedPassword,
+		SessionsValidFrom: &now,
+	})
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	rssBodyLimit    = 5 << 20
+	rssSeenGuidsCap = 1000
+)
+
+type RssService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type rssService struct {
+	workflowRepository repository.WorkflowRepository
+	mutex              sync.Mutex
+}
+
+func NewRssService(
+	workflowRepository repository.WorkflowRepository,
+) RssService {
+	return &rssService{
+		workflowRepository: workflowRepository,
+	}
+}
+
+func (service *rssService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Rss,
+			Description: "Follow RSS and Atom feeds",
+			Image:       "https://img.icons8.com/?size=100&id=12710&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.RssNewItemAction),
+				Description: "A new item is published in a feed",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"url": schemas.StringSchema("URL of the RSS 2.0 or Atom feed").WithFormat("uri"),
+				}, "url"),
+				Options: toolbox.RealObject(schemas.RssFeedOptions{
+					Url: "https://github.com/golang/go/releases.atom",
+				}),
+			},
+		},
+	}
+}
+
+func (service *rssService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.RssNewItemAction):
+		return service.NewFeedItem
+	default:
+		return nil
+	}
+}
+
+func (service *rssService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	return nil
+}
+
+func (service *rssService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+// NewFeedItem fetches the feed and queues the items not seen yet. Items
+// present on the first fetch are only marked as seen. Queued items are
+// handed to the reaction one at a time.
+func (service *rssService) NewFeedItem(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkFeed(workflowId, actionOption)
+}
+
+func (service *rssService) checkFeed(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	var options schemas.RssFeedOptions
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	var state schemas.RssState
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing feed state:", err)
+		}
+	}
+
+	// A failed fetch leaves the seen items and the first run as they are,
+	// the items already queued are still handed out.
+	items, err := service.fetchFeed(options.Url, &state)
+	if err != nil {
+		fmt.Println("Error fetching feed:", err)
+	} else {
+		queueNewRssItems(&state, items)
+		state.Initialized = true
+	}
+
+	message := "No new feed item"
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = toolbox.RealObject(state.Pending[0])
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "New feed item"
+	}
+
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling feed state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+// queueNewRssItems records the items not seen yet and queues them, except
+// on the first run which only records them.
+func queueNewRssItems(state *schemas.RssState, items []schemas.RssItem) {
+	seen := map[string]bool{}
+	for _, guid := range state.SeenGuids {
+		seen[guid] = true
+	}
+	// feeds list the newest items first, queue them oldest first
+	for index := len(items) - 1; index >= 0; index-- {
+		item := items[index]
+		if seen[item.Guid] {
+			continue
+		}
+		seen[item.Guid] = true
+		state.SeenGuids = append(state.SeenGuids, item.Guid)
+		if state.Initialized {
+			state.Pending = append(state.Pending, item)
+		}
+	}
+	if len(state.SeenGuids) > rssSeenGuidsCap {
+		state.SeenGuids = state.SeenGuids[len(state.SeenGuids)-rssSeenGuidsCap:]
+	}
+}
+
+// fetchFeed does a conditional GET of the feed, updating the validators of
+// state. It returns no item when the feed did not change.
+func (service *rssService) fetchFeed(feedUrl string, state *schemas.RssState) ([]schemas.RssItem, error) {
+	request, err := http.NewRequest("GET", feedUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8")
+	request.Header.Set("User-Agent", "Area51")
+	if state.ETag != "" {
+		request.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		request.Header.Set("If-Modified-Since", state.LastModified)
+	}
+	client := &http.Client{Timeout: 20 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed answered with status %d", response.StatusCode)
+	}
+	bodyBytes, err := io.ReadAll(io.LimitReader(response.Body, rssBodyLimit))
+	if err != nil {
+		return nil, err
+	}
+	items, err := parseFeed(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	state.ETag = response.Header.Get("ETag")
+	state.LastModified = response.Header.Get("Last-Modified")
+	return items, nil
+}
+
+func parseFeed(body []byte) (items []schemas.RssItem, err error) {
+	var document schemas.RssDocument
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	err = decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+
+	switch document.XMLName.Local {
+	case "rss":
+		for _, item := range document.Channel.Items {
+			author := item.Author
+			if author == "" {
+				author = item.Creator
+			}
+			items = append(items, schemas.RssItem{
+				Guid:      firstNonEmpty(item.Guid, item.Link, item.Title),
+				Title:     strings.TrimSpace(item.Title),
+				Link:      strings.TrimSpace(item.Link),
+				Author:    strings.TrimSpace(author),
+				Summary:   strings.TrimSpace(item.Description),
+				Published: item.PubDate,
+				FeedTitle: strings.TrimSpace(document.Channel.Title),
+			})
+		}
+	case "feed":
+		for _, entry := range document.Entries {
+			link := ""
+			for _, entryLink := range entry.Links {
+				if entryLink.Rel == "" || entryLink.Rel == "alternate" {
+					link = entryLink.Href
+					break
+				}
+			}
+			items = append(items, schemas.RssItem{
+				Guid:      firstNonEmpty(entry.Id, link, entry.Title),
+				Title:     strings.TrimSpace(entry.Title),
+				Link:      link,
+				Author:    strings.TrimSpace(entry.Author.Name),
+				Summary:   strings.TrimSpace(firstNonEmpty(entry.Summary, entry.Content)),
+				Published: firstNonEmpty(entry.Published, entry.Updated),
+				FeedTitle: strings.TrimSpace(document.Title),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed format <%s>", document.XMLName.Local)
+	}
+	return items, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -13,89 +13,80 @@ type ServicesService interface {
 	FindById(serviceId uint64) schemas.Service
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
-	GetServices() []interface{}
+	GetRegistrations() []schemas.ServiceRegistration
+	GetRegistration(serviceName schemas.ServiceName) schemas.ServiceRegistration
 	GetAllServices() (allServicesJson []schemas.ServiceJson, err error)
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
 }
 
 type ServiceInterface interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
 }
 
 type servicesService struct {
-	repository        repository.ServiceRepository
-	allServices       []interface{}
-	allServicesSchema []schemas.Service
+	repository     repository.ServiceRepository
+	registrations  []schemas.ServiceRegistration
+	providers      map[schemas.ServiceName]ServiceInterface
+	actionOwners   map[string]ServiceInterface
+	reactionOwners map[string]ServiceInterface
 }
 
 func NewServicesService(
 	repository repository.ServiceRepository,
-	githubService GithubService,
-	spotifyService SpotifyService,
-	googleService GoogleService,
-	microsoftService MicrosoftService,
-	weatherService WeatherService,
-	interpolService InterpolService,
+	providers ...ServiceInterface,
 ) ServicesService {
 	newService := servicesService{
-		repository: repository,
-		allServicesSchema: []schemas.Service{
-			{
-				Name:        schemas.Github,
-				Description: "This is the Github service",
-				Image:       "https://pngimg.com/uploads/github/github_PNG80.png",
-				IsOAuth:     true,
-			},
-			{
-				Name:        schemas.Spotify,
-				Description: "This is the Spotify Service",
-				Image:       "https://www.freepnglogos.com/uploads/spotify-logo-png/spotify-logo-spotify-symbol-3.png",
-				IsOAuth:     true,
-			},
-			{
-				Name:        schemas.Google,
-				Description: "This is the Google service",
-				Image:       "https://pngimg.com/uploads/google/google_PNG19630.png",
-				IsOAuth:     true,
-			},
-			{
-				Name:        schemas.Microsoft,
-				Description: "This is the Microsoft Service",
-				Image:       "https://upload.wikimedia.org/wikipedia/commons/thumb/4/44/Microsoft_logo.svg/1024px-Microsoft_logo.svg.png",
-				IsOAuth:     true,
-			},
-			{
-				Name:        schemas.Weather,
-				Description: "This is the Weather Service",
-				Image:       "https://img.icons8.com/?size=100&id=15359&format=png&color=000000",
-			},
-			{
-				Name:        schemas.Interpol,
-				Description: "This is the Interpol Service",
-				Image:       "https://img.icons8.com/?size=100&id=vlYbYJMp9Ixb&format=png&color=000000",
-			},
-		},
-		allServices: []interface{}{
-			githubService,
-			spotifyService,
-			googleService,
-			microsoftService,
-			weatherService,
-			interpolService,
-		},
+		repository:     repository,
+		providers:      map[schemas.ServiceName]ServiceInterface{},
+		actionOwners:   map[string]ServiceInterface{},
+		reactionOwners: map[string]ServiceInterface{},
+	}
+	for _, provider := range providers {
+		newService.register(provider)
 	}
 	newService.InitialSaveService()
 	return &newService
 }
 
+func (service *servicesService) register(provider ServiceInterface) {
+	registration := provider.GetServiceRegistration()
+	registration.Service.IsOAuth = registration.OAuth != nil
+
+	if _, exists := service.providers[registration.Service.Name]; exists {
+		panic("service " + string(registration.Service.Name) + " is registered twice")
+	}
+	for _, action := range registration.Actions {
+		if _, exists := service.actionOwners[action.Name]; exists {
+			panic("action " + action.Name + " is registered twice")
+		}
+		service.actionOwners[action.Name] = provider
+	}
+	for _, reaction := range registration.Reactions {
+		if _, exists := service.reactionOwners[reaction.Name]; exists {
+			panic("reaction " + reaction.Name + " is registered twice")
+		}
+		service.reactionOwners[reaction.Name] = provider
+	}
+	service.providers[registration.Service.Name] = provider
+	service.registrations = append(service.registrations, registration)
+}
+
 func (service *servicesService) InitialSaveService() {
-	for _, oneService := range service.allServicesSchema {
+	for _, registration := range service.registrations {
+		oneService := registration.Service
 		serviceByName := service.repository.FindAllByName(oneService.Name)
 		if len(serviceByName) == 0 {
 			service.repository.Save(oneService)
+			continue
 		}
+		existingService := serviceByName[0]
+		existingService.Description = oneService.Description
+		existingService.Image = oneService.Image
+		existingService.IsOAuth = oneService.IsOAuth
+		service.repository.Update(existingService)
 	}
 }
 
@@ -107,8 +98,17 @@ func (service *servicesService) FindByName(serviceName schemas.ServiceName) sche
 	return service.repository.FindByName(serviceName)
 }
 
-func (service *servicesService) GetServices() []interface{} {
-	return service.allServices
+func (service *servicesService) GetRegistrations() []schemas.ServiceRegistration {
+	return service.registrations
+}
+
+func (service *servicesService) GetRegistration(serviceName schemas.ServiceName) schemas.ServiceRegistration {
+	for _, registration := range service.registrations {
+		if registration.Service.Name == serviceName {
+			return registration
+		}
+	}
+	return schemas.ServiceRegistration{}
 }
 
 func (service *servicesService) GetAllServices() (allServicesJson []schemas.ServiceJson, err error) {
@@ -123,21 +123,19 @@ func (service *servicesService) GetAllServices() (allServicesJson []schemas.Serv
 }
 
 func (service *servicesService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
-	for _, oneService := range service.allServices {
-		if oneService.(ServiceInterface).FindActionByName(name) != nil {
-			return oneService.(ServiceInterface).FindActionByName(name)
-		}
+	provider, exists := service.actionOwners[name]
+	if !exists {
+		return nil
 	}
-	return nil
+	return provider.FindActionByName(name)
 }
 
 func (service *servicesService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
-	for _, oneService := range service.allServices {
-		if oneService.(ServiceInterface).FindReactionByName(name) != nil {
-			return oneService.(ServiceInterface).FindReactionByName(name)
-		}
+	provider, exists := service.reactionOwners[name]
+	if !exists {
+		return nil
 	}
-	return nil
+	return provider.FindReactionByName(name)
 }
 
 func (service *servicesService) FindById(serviceId uint64) schemas.Service {
@@ -145,16 +143,9 @@ func (service *servicesService) FindById(serviceId uint64) schemas.Service {
 }
 
 func (service *servicesService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
-	for i, s := range service.allServicesSchema {
-		for j, oneService := range service.allServices {
-			if s.Name == serviceName {
-				if j == i {
-					if oneService.(ServiceInterface).GetUserInfosByToken(accessToken, serviceName) != nil {
-						return oneService.(ServiceInterface).GetUserInfosByToken(accessToken, serviceName)
-					}
-				}
-			}
-		}
+	provider, exists := service.providers[serviceName]
+	if !exists {
+		return nil
 	}
-	return nil
+	return provider.GetUserInfosByToken(accessToken, serviceName)
 }
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type SlackService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type slackService struct {
+	chatWebhookReaction
+}
+
+func NewSlackService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) SlackService {
+	return &slackService{
+		chatWebhookReaction: chatWebhookReaction{
+			platform:                    schemas.Slack,
+			workflowRepository:          workflowRepository,
+			reactionResponseDataService: reactionResponseDataService,
+			userSecretService:           userSecretService,
+		},
+	}
+}
+
+func (service *slackService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Slack,
+			Description: "Post messages to Slack channels",
+			Image:       "https://img.icons8.com/?size=100&id=kikFWjCZIkgp&format=png&color=000000",
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.SlackPostMessageReaction),
+				Description: "Post a message made of blocks on a Slack webhook",
+				Schema: chatOptionsSchema(map[string]*schemas.JsonSchema{
+					"title":     schemas.StringSchema("Header template"),
+					"text":      schemas.StringSchema("Message template, supports Slack mrkdwn").NonEmpty(),
+					"link_url":  schemas.StringSchema("URL template of a button added under the message"),
+					"link_text": schemas.StringSchema("Label of the button").WithDefault("Open"),
+				}, "text"),
+				Options: toolbox.RealObject(schemas.SlackPostMessageOptions{
+					ChatWebhookTarget: schemas.ChatWebhookTarget{WebhookSecret: "slack_webhook"},
+					Title:             "{{.workflow.name}}",
+					Text:              "Workflow *{{.workflow.name}}* was triggered at {{.now}}",
+				}),
+			},
+		},
+	}
+}
+
+func (service *slackService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	return nil
+}
+
+func (service *slackService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.SlackPostMessageReaction):
+		return service.PostMessage
+	default:
+		return nil
+	}
+}
+
+func (service *slackService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+func (service *slackService) PostMessage(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.post(workflowId, func(workflow schemas.Workflow, templateData map[string]interface{}) (schemas.ChatWebhookTarget, interface{}, error) {
+		var options schemas.SlackPostMessageOptions
+		err := json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return options.ChatWebhookTarget, nil, fmt.Errorf("invalid options: %w", err)
+		}
+		renderer := chatTemplateRenderer{data: templateData}
+		title := renderer.render("title", options.Title)
+		text := renderer.render("text", options.Text)
+		linkUrl := renderer.render("link_url", options.LinkUrl)
+
+		// text is the notification fallback of the blocks
+		payload := schemas.SlackWebhookPayload{Text: text}
+		if title != "" {
+			payload.Blocks = append(payload.Blocks, schemas.SlackBlock{
+				Type: "header",
+				Text: &schemas.SlackText{Type: "plain_text", Text: title},
+			})
+		}
+		payload.Blocks = append(payload.Blocks, schemas.SlackBlock{
+			Type: "section",
+			Text: &schemas.SlackText{Type: "mrkdwn", Text: text},
+		})
+		if linkUrl != "" {
+			linkText := options.LinkText
+			if linkText == "" {
+				linkText = "Open"
+			}
+			payload.Blocks = append(payload.Blocks, schemas.SlackBlock{
+				Type: "actions",
+				Elements: []interface{}{schemas.SlackButton{
+					Type: "button",
+					Text: schemas.SlackText{Type: "plain_text", Text: linkText},
+					Url:  linkUrl,
+				}},
+			})
+		}
+		return options.ChatWebhookTarget, payload, renderer.err
+	})
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	spotifyApiUrl = "https://api.spotify.com/v1"
+	// spotifyMaxRetryAfter is the longest Retry-After waited for before
+	// retrying; longer ones make the requests of the token fail until then.
+	spotifyMaxRetryAfter   = 5 * time.Second
+	spotifyMaxAttempts     = 3
+	spotifySavedTrackPages = 5
+	spotifyArtistsPerCheck = 5
+	spotifyArtistsRefresh  = time.Hour
+	spotifySeenAlbumsLimit = 1000
+)
+
+var spotifyIdPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+func (service *spotifyService) getSpotifyToken(userId uint64) (string, error) {
+	user := service.userService.GetUserById(userId)
+	allTokens := service.tokenRepository.FindByUserId(user)
+	searchedService := service.serviceRepository.FindByName(schemas.Spotify)
+	for _, token := range allTokens {
+		if token.ServiceId == searchedService.Id {
+			return token.Token, nil
+		}
+	}
+	return "", schemas.ErrSpotifyTokenNotFound
+}
+
+// spotifyRequest calls the Spotify Web API, requestUrl being a path under
+// v1 or a full next link. 429 answers are retried when Retry-After is short,
+// otherwise the token is rate limited until then and ErrSpotifyRateLimited
+// returned. result may be nil; any non 2xx status is an error.
+func (service *spotifyService) spotifyRequest(accessToken string, method string, requestUrl string, body interface{}, result interface{}) (int, error) {
+	if until, limited := service.rateLimitedUntil[accessToken]; limited {
+		if time.Now().Before(until) {
+			return http.StatusTooManyRequests, schemas.ErrSpotifyRateLimited
+		}
+		delete(service.rateLimitedUntil, accessToken)
+	}
+	if strings.HasPrefix(requestUrl, "/") {
+		requestUrl = spotifyApiUrl + requestUrl
+	}
+	var jsonData []byte
+	if body != nil {
+		var err error
+		jsonData, err = json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	for attempt := 1; ; attempt++ {
+		request, err := http.NewRequest(method, requestUrl, bytes.NewReader(jsonData))
+		if err != nil {
+			return 0, err
+		}
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+		request.Header.Set("Accept", "application/json")
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return 0, err
+		}
+		bodyBytes, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if response.StatusCode == http.StatusTooManyRequests {
+			delay := retryAfterDelay(response.Header.Get("Retry-After"))
+			if delay <= spotifyMaxRetryAfter && attempt < spotifyMaxAttempts {
+				time.Sleep(delay)
+				continue
+			}
+			service.rateLimitedUntil[accessToken] = time.Now().Add(delay)
+			return response.StatusCode, schemas.ErrSpotifyRateLimited
+		}
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return response.StatusCode, fmt.Errorf("spotify answered %d: %s", response.StatusCode, string(bodyBytes))
+		}
+		if result != nil && len(bodyBytes) != 0 {
+			err = json.Unmarshal(bodyBytes, result)
+			if err != nil {
+				return response.StatusCode, err
+			}
+		}
+		return response.StatusCode, nil
+	}
+}
+
+// spotifyIdFromReference reads the id of a kind of item (track, playlist,
+// ...) from its open.spotify.com link, its spotify: uri or the id itself.
+func spotifyIdFromReference(reference string, kind string) (string, error) {
+	reference = strings.TrimSpace(reference)
+	if strings.HasPrefix(reference, "spotify:"+kind+":") {
+		reference = strings.TrimPrefix(reference, "spotify:"+kind+":")
+	} else if parsedUrl, err := url.Parse(reference); err == nil && parsedUrl.Host == "open.spotify.com" {
+		parts := strings.Split(strings.Trim(parsedUrl.Path, "/"), "/")
+		if len(parts) < 2 || parts[len(parts)-2] != kind {
+			return "", schemas.ErrSpotifyInvalidId
+		}
+		reference = parts[len(parts)-1]
+	}
+	if !spotifyIdPattern.MatchString(reference) {
+		return "", schemas.ErrSpotifyInvalidId
+	}
+	return reference, nil
+}
+
+func spotifyArtistNames(artists []schemas.SpotifyArtist) string {
+	names := make([]string, 0, len(artists))
+	for _, artist := range artists {
+		names = append(names, artist.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func spotifyTrackEvent(track schemas.SpotifyTrack) schemas.SpotifyTrackEvent {
+	return schemas.SpotifyTrackEvent{
+		Id:         track.Id,
+		Uri:        track.Uri,
+		Name:       track.Name,
+		Artists:    spotifyArtistNames(track.Artists),
+		Album:      track.Album.Name,
+		Url:        track.ExternalUrls.Spotify,
+		DurationMs: track.DurationMs,
+	}
+}
+
+// popSpotifyEvent hands the first pending event to the reaction when the
+// previous one was handled, and saves the state.
+func (service *spotifyService) popSpotifyEvent(workflow schemas.Workflow, pending interface{}, state interface{}) bool {
+	triggered := false
+	if !workflow.ReactionTrigger && pending != nil {
+		workflow.Event = toolbox.RealObject(pending)
+		workflow.ReactionTrigger = true
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		triggered = true
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling spotify state:", err)
+		return triggered
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return triggered
+}
+
+// NewSavedTrack queues the tracks saved to the library since the last
+// check. The first check only records the last saved track.
+func (service *spotifyService) NewSavedTrack(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkSavedTracks(workflowId)
+}
+
+func (service *spotifyService) checkSavedTracks(workflowId uint64) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	spotifyToken, err := service.getSpotifyToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.SpotifySavedTrackState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing spotify state:", err)
+		}
+	}
+
+	initialized := state.LastAddedAt != ""
+	var newTracks []schemas.SpotifyTrackEvent
+	link := "/me/tracks?limit=50"
+	for page := 0; page < spotifySavedTrackPages && link != ""; page++ {
+		savedTracks := schemas.SpotifySavedTracksResponse{}
+		_, err = service.spotifyRequest(spotifyToken, http.MethodGet, link, nil, &savedTracks)
+		if err != nil {
+			fmt.Println("Error listing saved tracks:", err)
+			return err.Error()
+		}
+		link = savedTracks.Next
+		for _, item := range savedTracks.Items {
+			if item.AddedAt < state.LastAddedAt || !initialized {
+				link = ""
+				break
+			}
+			if containsString(state.SeenIds, item.Track.Id) {
+				continue
+			}
+			event := spotifyTrackEvent(item.Track)
+			event.AddedAt = item.AddedAt
+			newTracks = append(newTracks, event)
+		}
+		if !initialized && len(savedTracks.Items) != 0 {
+			state.LastAddedAt = savedTracks.Items[0].AddedAt
+			state.SeenIds = []string{savedTracks.Items[0].Track.Id}
+		}
+	}
+	if !initialized && state.LastAddedAt == "" {
+		state.LastAddedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	// the library lists the newest first
+	for index := len(newTracks) - 1; index >= 0; index-- {
+		track := newTracks[index]
+		if track.AddedAt != state.LastAddedAt {
+			state.LastAddedAt = track.AddedAt
+			state.SeenIds = nil
+		}
+		state.SeenIds = append(state.SeenIds, track.Id)
+		state.Pending = append(state.Pending, track)
+	}
+
+	var pending interface{}
+	if len(state.Pending) != 0 && !workflow.ReactionTrigger {
+		pending = state.Pending[0]
+		state.Pending = state.Pending[1:]
+	}
+	if service.popSpotifyEvent(workflow, pending, state) {
+		return "New saved track"
+	}
+	return "No new saved track"
+}
+
+// FollowedArtistRelease checks a few followed artists per poll and queues
+// their albums released since the workflow started.
+func (service *spotifyService) FollowedArtistRelease(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkArtistReleases(workflowId, actionOption)
+}
+
+func (service *spotifyService) checkArtistReleases(workflowId uint64, actionOption json.RawMessage) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.SpotifyArtistReleaseOptions{}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	spotifyToken, err := service.getSpotifyToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.SpotifyArtistReleaseState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing spotify state:", err)
+		}
+	}
+	if state.Since == "" {
+		state.Since = time.Now().UTC().Format(time.DateOnly)
+	}
+
+	if time.Since(state.ArtistsAt) > spotifyArtistsRefresh {
+		state.ArtistIds, err = service.listFollowedArtists(spotifyToken)
+		if err != nil {
+			fmt.Println("Error listing followed artists:", err)
+			return err.Error()
+		}
+		state.ArtistsAt = time.Now()
+	}
+	includeGroups := "album"
+	if options.IncludeSingles {
+		includeGroups = "album,single"
+	}
+	for checked := 0; checked < spotifyArtistsPerCheck && checked < len(state.ArtistIds); checked++ {
+		if state.NextArtist >= len(state.ArtistIds) {
+			state.NextArtist = 0
+		}
+		artistId := state.ArtistIds[state.NextArtist]
+		albums := schemas.SpotifyAlbumsResponse{}
+		_, err = service.spotifyRequest(spotifyToken, http.MethodGet,
+			"/artists/"+url.PathEscape(artistId)+"/albums?limit=20&include_groups="+includeGroups, nil, &albums)
+		if err != nil {
+			fmt.Println("Error listing artist albums:", err)
+			break
+		}
+		state.NextArtist++
+		for _, album := range albums.Items {
+			// release dates may only be a year or a month, they sort before
+			// the day the workflow started and are ignored
+			if album.ReleaseDate < state.Since || containsString(state.SeenAlbumIds, album.Id) {
+				continue
+			}
+			state.SeenAlbumIds = append(state.SeenAlbumIds, album.Id)
+			event := schemas.SpotifyReleaseEvent{
+				Id:          album.Id,
+				Name:        album.Name,
+				AlbumType:   album.AlbumType,
+				Artists:     spotifyArtistNames(album.Artists),
+				ReleaseDate: album.ReleaseDate,
+				TotalTracks: album.TotalTracks,
+				Url:         album.ExternalUrls.Spotify,
+			}
+			if len(album.Images) != 0 {
+				event.Image = album.Images[0].Url
+			}
+			state.Pending = append(state.Pending, event)
+		}
+	}
+	if len(state.SeenAlbumIds) > spotifySeenAlbumsLimit {
+		state.SeenAlbumIds = state.SeenAlbumIds[len(state.SeenAlbumIds)-spotifySeenAlbumsLimit:]
+	}
+
+	var pending interface{}
+	if len(state.Pending) != 0 && !workflow.ReactionTrigger {
+		pending = state.Pending[0]
+		state.Pending = state.Pending[1:]
+	}
+	if service.popSpotifyEvent(workflow, pending, state) {
+		return "New release of a followed artist"
+	}
+	return "No new release"
+}
+
+func (service *spotifyService) listFollowedArtists(accessToken string) (artistIds []string, err error) {
+	link := "/me/following?type=artist&limit=50"
+	for link != "" {
+		followed := schemas.SpotifyFollowedArtistsResponse{}
+		_, err = service.spotifyRequest(accessToken, http.MethodGet, link, nil, &followed)
+		if err != nil {
+			return nil, err
+		}
+		for _, artist := range followed.Artists.Items {
+			artistIds = append(artistIds, artist.Id)
+		}
+		link = followed.Artists.Next
+	}
+	return artistIds, nil
+}
+
+// StartedPlaying triggers when the track playing changes to a new one.
+func (service *spotifyService) StartedPlaying(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkStartedPlaying(workflowId)
+}
+
+func (service *spotifyService) checkStartedPlaying(workflowId uint64) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	spotifyToken, err := service.getSpotifyToken(workflow.UserId)
+	if err != nil {
+		return err.Error()
+	}
+	state := schemas.SpotifyPlayingState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing spotify state:", err)
+		}
+	}
+
+	playing := schemas.SpotifyCurrentlyPlaying{}
+	_, err = service.spotifyRequest(spotifyToken, http.MethodGet, "/me/player/currently-playing", nil, &playing)
+	if err != nil {
+		fmt.Println("Error reading currently playing:", err)
+		return err.Error()
+	}
+	// 204 leaves playing empty: nothing plays
+	var pending interface{}
+	if playing.IsPlaying && playing.Item != nil && playing.Item.Id != "" && playing.Item.Id != state.TrackId {
+		if state.Initialized {
+			event := spotifyTrackEvent(*playing.Item)
+			if playing.Device != nil {
+				event.Device = playing.Device.Name
+			}
+			if playing.Context != nil {
+				event.ContextUrl = playing.Context.ExternalUrls.Spotify
+			}
+			pending = event
+		}
+		state.TrackId = playing.Item.Id
+	}
+	state.Initialized = true
+	if service.popSpotifyEvent(workflow, pending, state) {
+		return "Started playing a track"
+	}
+	return "No new track playing"
+}
+
+func (service *spotifyService) SaveTrackReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	options := schemas.SpotifySaveTrackOptions{}
+	err := json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+	service.runSpotifyReaction(workflowId,
+		func(workflow schemas.Workflow, spotifyToken string, result *schemas.SpotifyReactionResponse) error {
+			track, err := toolbox.RenderTemplate(options.Track, toolbox.WorkflowTemplateData(workflow))
+			if err != nil {
+				return fmt.Errorf("track: %w", err)
+			}
+			trackId, err := spotifyIdFromReference(track, "track")
+			if err != nil {
+				return err
+			}
+			result.TrackId = trackId
+			_, err = service.spotifyRequest(spotifyToken, http.MethodPut, "/me/tracks", map[string][]string{
+				"ids": {trackId},
+			}, nil)
+			return err
+		})
+}
+
+func (service *spotifyService) ControlPlaybackReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	options := schemas.SpotifyPlaybackOptions{}
+	err := json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+	service.runSpotifyReaction(workflowId,
+		func(workflow schemas.Workflow, spotifyToken string, result *schemas.SpotifyReactionResponse) error {
+			result.Command = string(options.Command)
+			method, path := "", ""
+			switch options.Command {
+			case schemas.SpotifySkipNext:
+				method, path = http.MethodPost, "/me/player/next"
+			case schemas.SpotifySkipPrevious:
+				method, path = http.MethodPost, "/me/player/previous"
+			case schemas.SpotifyPause:
+				method, path = http.MethodPut, "/me/player/pause"
+			case schemas.SpotifyResume:
+				method, path = http.MethodPut, "/me/player/play"
+			default:
+				return fmt.Errorf("unknown command %q", options.Command)
+			}
+			status, err := service.spotifyRequest(spotifyToken, method, path, nil, nil)
+			if status == http.StatusNotFound {
+				return fmt.Errorf("no active spotify device: %w", err)
+			}
+			return err
+		})
+}
+
+func (service *spotifyService) AddPlayingTrackReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	options := schemas.SpotifyAddPlayingTrackOptions{}
+	err := json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+	service.runSpotifyReaction(workflowId,
+		func(workflow schemas.Workflow, spotifyToken string, result *schemas.SpotifyReactionResponse) error {
+			playlistId, err := spotifyIdFromReference(options.PlaylistURL, "playlist")
+			if err != nil {
+				return err
+			}
+			result.PlaylistId = playlistId
+			playing := schemas.SpotifyCurrentlyPlaying{}
+			_, err = service.spotifyRequest(spotifyToken, http.MethodGet, "/me/player/currently-playing", nil, &playing)
+			if err != nil {
+				return err
+			}
+			if playing.Item == nil || playing.Item.Uri == "" || playing.CurrentlyPlayingType != "track" {
+				return schemas.ErrSpotifyNothingPlaying
+			}
+			result.TrackId = playing.Item.Id
+			result.TrackName = playing.Item.Name
+			_, err = service.spotifyRequest(spotifyToken, http.MethodPost, "/playlists/"+url.PathEscape(playlistId)+"/tracks", map[string][]string{
+				"uris": {playing.Item.Uri},
+			}, nil)
+			return err
+		})
+}
+
+// runSpotifyReaction does what the Spotify reactions share: checking the
+// trigger, finding the token and recording the result.
+func (service *spotifyService) runSpotifyReaction(
+	workflowId uint64,
+	run func(workflow schemas.Workflow, spotifyToken string, result *schemas.SpotifyReactionResponse) error,
+) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+
+	result := schemas.SpotifyReactionResponse{}
+	spotifyToken, err := service.getSpotifyToken(workflow.UserId)
+	if err == nil {
+		err = run(workflow, spotifyToken, &result)
+	}
+	if err != nil {
+		fmt.Println("Error running spotify reaction:", err)
+		result.Error = err.Error()
+	}
+	result.Done = err == nil
+
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflow.Id,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
@@ -5,9 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +18,7 @@ import (
 )
 
 type SpotifyService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	AuthGetServiceAccessToken(code string, path string) (schemas.SpotifyResponseToken, error)
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
@@ -25,14 +26,18 @@ type SpotifyService interface {
 }
 
 type spotifyService struct {
-	userService        UserService
-	spotifyRepository  repository.SpotifyRepository
-	workflowRepository repository.WorkflowRepository
-	actionRepository   repository.ActionRepository
-	reactionRepository repository.ReactionRepository
-	tokenRepository    repository.TokenRepository
-	serviceRepository  repository.ServiceRepository
-	mutex              sync.Mutex
+	userService                 UserService
+	spotifyRepository           repository.SpotifyRepository
+	workflowRepository          repository.WorkflowRepository
+	actionRepository            repository.ActionRepository
+	reactionRepository          repository.ReactionRepository
+	tokenRepository             repository.TokenRepository
+	serviceRepository           repository.ServiceRepository
+	reactionResponseDataService ReactionResponseDataService
+	// rateLimitedUntil holds, per access token, when Spotify accepts
+	// requests again after a long Retry-After.
+	rateLimitedUntil map[string]time.Time
+	mutex            sync.Mutex
 }
 
 func NewSpotifyService(
@@ -43,15 +48,145 @@ func NewSpotifyService(
 	reactionRepository repository.ReactionRepository,
 	tokenRepository repository.TokenRepository,
 	serviceRepository repository.ServiceRepository,
+	reactionResponseDataService ReactionResponseDataService,
 ) SpotifyService {
 	return &spotifyService{
-		userService:        userService,
-		spotifyRepository:  spotifyRepository,
-		workflowRepository: workflowRepository,
-		actionRepository:   actionRepository,
-		reactionRepository: reactionRepository,
-		tokenRepository:    tokenRepository,
-		serviceRepository:  serviceRepository,
+		userService:                 userService,
+		spotifyRepository:           spotifyRepository,
+		workflowRepository:          workflowRepository,
+		actionRepository:            actionRepository,
+		reactionRepository:          reactionRepository,
+		tokenRepository:             tokenRepository,
+		serviceRepository:           serviceRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		rateLimitedUntil:            map[string]time.Time{},
+	}
+}
+
+func (service *spotifyService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Spotify,
+			Description: "This is the Spotify Service",
+			Image:       "https://www.freepnglogos.com/uploads/spotify-logo-png/spotify-logo-spotify-symbol-3.png",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.SpotifyAddTrackAction),
+				Description: "A track is added to a playlist",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"playlist_url":    schemas.StringSchema("Link of the playlist to watch").WithPattern(`^https://open\.spotify\.com/playlist/`),
+					"include_removed": schemas.BooleanSchema("Also trigger when a track is removed").WithDefault(false),
+				}, "playlist_url"),
+				Options: toolbox.RealObject(schemas.SpotifyActionOptionsInfo{
+					PlaylistURL:    "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
+					IncludeRemoved: true,
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifySavedTrackAction),
+				Description: "A track is saved to your library",
+				Schema:      schemas.OptionsSchema(map[string]*schemas.JsonSchema{}),
+				Options:     toolbox.RealObject(map[string]interface{}{}),
+			},
+			{
+				Name:        string(schemas.SpotifyArtistReleaseAction),
+				Description: "An artist you follow releases an album",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"include_singles": schemas.BooleanSchema("Also trigger on singles and EPs").WithDefault(false),
+				}),
+				Options: toolbox.RealObject(schemas.SpotifyArtistReleaseOptions{
+					IncludeSingles: true,
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifyStartedPlayingAction),
+				Description: "You start playing a track",
+				Schema:      schemas.OptionsSchema(map[string]*schemas.JsonSchema{}),
+				Options:     toolbox.RealObject(map[string]interface{}{}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.SpotifyAddTrackReaction),
+				Description: "Add a track to a playlist",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"playlist_url": schemas.StringSchema("Link of the playlist to add the track to").WithPattern(`^https://open\.spotify\.com/playlist/`),
+					"track_url":    schemas.StringSchema("Link of the track to add").WithPattern(`^https://open\.spotify\.com/track/`),
+				}, "playlist_url", "track_url"),
+				Options: toolbox.RealObject(schemas.SpotifyReactionOptions{
+					PlaylistURL: "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
+					TrackURL:    "https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8",
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifyCreatePlaylist),
+				Description: "Create a new Playlist",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"name":          schemas.StringSchema("Name of the new playlist").NonEmpty(),
+					"description":   schemas.StringSchema("Description of the new playlist"),
+					"public":        schemas.EnumSchema("Whether the playlist is public", "true", "false").WithDefault("true"),
+					"collaborative": schemas.EnumSchema("Whether other users can edit the playlist", "true", "false").WithDefault("false"),
+				}, "name", "public", "collaborative"),
+				Options: toolbox.RealObject(schemas.SpotifyPlaylistOptionsSchema{
+					Name:          "Playlist Hardstyle",
+					Description:   "BOOM BOOM BOOM",
+					Public:        "true",
+					Collaborative: "false",
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifySaveTrackReaction),
+				Description: "Save a track to your library",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"track": schemas.StringSchema("Link, uri or id of the track, {{.event.id}} for the track of a Spotify action").NonEmpty().WithDefault("{{.event.id}}"),
+				}, "track"),
+				Options: toolbox.RealObject(schemas.SpotifySaveTrackOptions{
+					Track: "https://open.spotify.com/track/4PTG3Z6ehGkBFwjybzWkR8",
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifyPlaybackReaction),
+				Description: "Skip, pause or resume the playback, needs Spotify Premium",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"command": schemas.EnumSchema("What to do",
+						string(schemas.SpotifySkipNext),
+						string(schemas.SpotifySkipPrevious),
+						string(schemas.SpotifyPause),
+						string(schemas.SpotifyResume),
+					),
+				}, "command"),
+				Options: toolbox.RealObject(schemas.SpotifyPlaybackOptions{
+					Command: schemas.SpotifyPause,
+				}),
+			},
+			{
+				Name:        string(schemas.SpotifyAddPlayingTrackReaction),
+				Description: "Add the track you are listening to to a playlist",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"playlist_url": schemas.StringSchema("Link of the playlist to add the track to").WithPattern(`^https://open\.spotify\.com/playlist/`),
+				}, "playlist_url"),
+				Options: toolbox.RealObject(schemas.SpotifyAddPlayingTrackOptions{
+					PlaylistURL: "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
+				}),
+			},
+		},
+		OAuth: &schemas.ServiceOAuth{
+			AuthorizationUrl: "https://accounts.spotify.com/authorize",
+			Scopes: []string{
+				"playlist-read-private",
+				"playlist-modify-public",
+				"playlist-modify-private",
+				"user-read-private",
+				"user-read-email",
+				"user-library-read",
+				"user-library-modify",
+				"user-follow-read",
+				"user-read-currently-playing",
+				"user-read-playback-state",
+				"user-modify-playback-state",
+			},
+		},
 	}
 }
 
@@ -100,6 +235,12 @@ func (service *spotifyService) FindActionByName(name string) func(channel chan s
 	switch name {
 	case string(schemas.SpotifyAddTrackAction):
 		return service.AddTrackAction
+	case string(schemas.SpotifySavedTrackAction):
+		return service.NewSavedTrack
+	case string(schemas.SpotifyArtistReleaseAction):
+		return service.FollowedArtistRelease
+	case string(schemas.SpotifyStartedPlayingAction):
+		return service.StartedPlaying
 	default:
 		return nil
 	}
@@ -111,109 +252,161 @@ func (service *spotifyService) FindReactionByName(name string) func(channel chan
 		return service.AddTrackReaction
 	case string(schemas.SpotifyCreatePlaylist):
 		return service.CreatePlaylist
+	case string(schemas.SpotifySaveTrackReaction):
+		return service.SaveTrackReaction
+	case string(schemas.SpotifyPlaybackReaction):
+		return service.ControlPlaybackReaction
+	case string(schemas.SpotifyAddPlayingTrackReaction):
+		return service.AddPlayingTrackReaction
 	default:
 		return nil
 	}
 }
 
+// AddTrackAction compares the tracks of the playlist with the ones listed
+// at the previous snapshot and queues one event per track added, or
+// removed when include_removed is set. The first check only lists them.
 func (service *spotifyService) AddTrackAction(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkPlaylistTracks(workflowId, actionOption)
+}
+
+func (service *spotifyService) checkPlaylistTracks(workflowId uint64, actionOption json.RawMessage) string {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
 
 	workflow, err := service.workflowRepository.FindByIds(workflowId)
 	if err != nil {
 		fmt.Println(err)
-		return
+		return err.Error()
 	}
-
-	user := service.userService.GetUserById(workflow.UserId)
-	accessToken := service.tokenRepository.FindByUserId(user)
-
-	options := schemas.SpotifyActionOptions{}
+	options := schemas.SpotifyActionOptionsInfo{}
 	err = json.Unmarshal([]byte(actionOption), &options)
 	if err != nil {
-		fmt.Println(err)
-		return
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
 	}
-	playlistId := ""
-	parts := strings.Split(options.PlaylistURL, "?")
-	_, err = fmt.Sscanf(parts[0], "https://open.spotify.com/playlist/%s", &playlistId)
+	playlistId, err := spotifyIdFromReference(options.PlaylistURL, "playlist")
 	if err != nil {
-		fmt.Printf("unable to create request because: %s", err)
-		return
+		return err.Error()
 	}
-
-	request, err := http.NewRequest("GET", "https://api.spotify.com/v1/playlists/"+playlistId, nil)
+	spotifyToken, err := service.getSpotifyToken(workflow.UserId)
 	if err != nil {
-		fmt.Printf("unable to create request because: %s", err)
-		return
+		return err.Error()
 	}
-	client := &http.Client{}
-	searchedService := service.serviceRepository.FindByName(schemas.Spotify)
-	for _, token := range accessToken {
-		if token.ServiceId == searchedService.Id {
-			request.Header.Set("Authorization", "Bearer "+token.Token)
+	state := schemas.SpotifyPlaylistState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			// workflows made before the diffing kept a track count
+			fmt.Println("Error parsing spotify state, listing the playlist again:", err)
+			state = schemas.SpotifyPlaylistState{}
 		}
 	}
 
-	response, err := client.Do(request)
+	playlist := schemas.SpotifyPlaylist{}
+	_, err = service.spotifyRequest(spotifyToken, http.MethodGet, "/playlists/"+url.PathEscape(playlistId)+"?fields=id,name,snapshot_id,external_urls", nil, &playlist)
 	if err != nil {
-		fmt.Println(err)
-		return
+		fmt.Println("Error reading playlist:", err)
+		return err.Error()
 	}
-
-	defer response.Body.Close()
-	result := schemas.SpotifyPlaylistInfos{}
-	bodyBytes, _ := io.ReadAll(response.Body)
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	existingRecords := map[string]interface{}{}
-	if string(workflow.Utils) != "" {
-		err = json.Unmarshal([]byte(workflow.Utils), &existingRecords)
+	if playlist.SnapshotId != state.SnapshotId {
+		tracks, err := service.listPlaylistTracks(spotifyToken, playlistId)
 		if err != nil {
-			fmt.Println("Error unmarshaliing existingRecords: ", err)
-			return
+			fmt.Println("Error listing playlist tracks:", err)
+			return err.Error()
 		}
+		if state.Known != nil {
+			for _, change := range diffPlaylistTracks(state.Known, tracks) {
+				if change.ChangeType == schemas.SpotifyTrackRemoved && !options.IncludeRemoved {
+					continue
+				}
+				change.PlaylistId = playlistId
+				change.PlaylistName = playlist.Name
+				state.Pending = append(state.Pending, change)
+			}
+		}
+		state.Known = tracks
+		state.SnapshotId = playlist.SnapshotId
+	}
+
+	var pending interface{}
+	if len(state.Pending) != 0 && !workflow.ReactionTrigger {
+		pending = state.Pending[0]
+		state.Pending = state.Pending[1:]
 	}
+	if service.popSpotifyEvent(workflow, pending, state) {
+		return "Playlist tracks changed"
+	}
+	return "No playlist change"
+}
 
-	if existingRecords["Tracks"] == nil {
-		existingRecords["Tracks"] = 0
-		jsonData, err := json.Marshal(existingRecords)
+// listPlaylistTracks lists every track of the playlist by id, uri for local
+// files, with the number of times it is in it.
+func (service *spotifyService) listPlaylistTracks(accessToken string, playlistId string) (map[string]schemas.SpotifyPlaylistTrack, error) {
+	tracks := map[string]schemas.SpotifyPlaylistTrack{}
+	query := url.Values{}
+	query.Set("limit", "100")
+	query.Set("additional_types", "track")
+	query.Set("fields", "next,items(added_at,added_by.id,track(id,uri,name,type,duration_ms,artists(name),album(name),external_urls))")
+	link := "/playlists/" + url.PathEscape(playlistId) + "/tracks?" + query.Encode()
+	for link != "" {
+		page := schemas.SpotifyPlaylistTracksResponse{}
+		_, err := service.spotifyRequest(accessToken, http.MethodGet, link, nil, &page)
 		if err != nil {
-			fmt.Println("Error marshalling existingRecords:", err)
-			return
+			return nil, err
 		}
-		workflow.Utils = jsonData
-		service.workflowRepository.Update(workflow)
+		for _, item := range page.Items {
+			if item.Track == nil || item.Track.Uri == "" {
+				continue
+			}
+			key := firstNonEmpty(item.Track.Id, item.Track.Uri)
+			track := tracks[key]
+			// a track in the playlist several times is described by its
+			// last addition
+			if track.Count == 0 || item.AddedAt > track.Track.AddedAt {
+				track.Track = spotifyTrackEvent(*item.Track)
+				track.Track.AddedAt = item.AddedAt
+				track.AddedBy = ""
+				if item.AddedBy != nil {
+					track.AddedBy = item.AddedBy.Id
+				}
+			}
+			track.Count++
+			tracks[key] = track
+		}
+		link = page.Next
 	}
+	return tracks, nil
+}
 
-	var nbTracks uint64
-	switch v := existingRecords["Tracks"].(type) {
-	case float64:
-		nbTracks = uint64(v)
-	case uint64:
-		nbTracks = v
-	default:
-		fmt.Println("Error asserting nbTracks to int or float64")
-		return
-	}
-	existingRecords["Tracks"] = result.Tracks.Total
-	jsonData, err := json.Marshal(existingRecords)
-	if err != nil {
-		fmt.Println(err)
-		return
+// diffPlaylistTracks returns an event per occurrence of a track added or
+// removed between the two listings, removals first then additions from the
+// oldest.
+func diffPlaylistTracks(before map[string]schemas.SpotifyPlaylistTrack, after map[string]schemas.SpotifyPlaylistTrack) (changes []schemas.SpotifyPlaylistTrackEvent) {
+	for key, track := range before {
+		for removed := track.Count - after[key].Count; removed > 0; removed-- {
+			changes = append(changes, schemas.SpotifyPlaylistTrackEvent{
+				SpotifyTrackEvent: track.Track,
+				ChangeType:        schemas.SpotifyTrackRemoved,
+			})
+		}
 	}
-	workflow.Utils = jsonData
-	if result.Tracks.Total > nbTracks {
-		workflow.ReactionTrigger = true
-		service.workflowRepository.UpdateReactionTrigger(workflow)
+	for key, track := range after {
+		for added := track.Count - before[key].Count; added > 0; added-- {
+			changes = append(changes, schemas.SpotifyPlaylistTrackEvent{
+				SpotifyTrackEvent: track.Track,
+				ChangeType:        schemas.SpotifyTrackAdded,
+				AddedBy:           track.AddedBy,
+			})
+		}
 	}
-	service.workflowRepository.UpdateUtils(workflow)
-	channel <- "Action workflow done"
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].ChangeType != changes[j].ChangeType {
+			return changes[i].ChangeType == schemas.SpotifyTrackRemoved
+		}
+		return changes[i].AddedAt < changes[j].AddedAt
+	})
+	return changes
 }
 
 func (service *spotifyService) AddTrackReaction(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
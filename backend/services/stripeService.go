@@ -0,0 +1,411 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	stripeApiUrl             = "https://api.stripe.com/v1/"
+	stripeSignatureTolerance = 5 * time.Minute
+	stripeWebhookRateLimit   = 300
+)
+
+type StripeService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+	ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error
+}
+
+type stripeService struct {
+	workflowRepository          repository.WorkflowRepository
+	webhookRepository           repository.WebhookRepository
+	reactionResponseDataService ReactionResponseDataService
+	userSecretService           UserSecretService
+	rateLimiter                 *toolbox.RateLimiter
+	mutex                       sync.Mutex
+}
+
+func NewStripeService(
+	workflowRepository repository.WorkflowRepository,
+	webhookRepository repository.WebhookRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) StripeService {
+	return &stripeService{
+		workflowRepository:          workflowRepository,
+		webhookRepository:           webhookRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		userSecretService:           userSecretService,
+		rateLimiter:                 toolbox.NewRateLimiter(time.Minute),
+	}
+}
+
+func stripeWebhookSchema() schemas.JsonSchema {
+	return schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+		"secret_name": schemas.StringSchema("Name of the secret holding the signing secret (whsec_...) of the Stripe endpoint").NonEmpty(),
+	}, "secret_name")
+}
+
+func (service *stripeService) GetServiceRegistration() schemas.ServiceRegistration {
+	webhookOptions := toolbox.RealObject(schemas.StripeWebhookOptions{
+		SecretName: "stripe_webhook_secret",
+	})
+	apiKeyOption := schemas.StripeApiKeyOption{ApiKeySecret: "stripe_api_key"}
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Stripe,
+			Description: "Follow Stripe payments and manage customers",
+			Image:       "https://img.icons8.com/?size=100&id=18980&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.StripePaymentSucceededAction),
+				Description: "A payment succeeded",
+				Schema:      stripeWebhookSchema(),
+				Options:     webhookOptions,
+			},
+			{
+				Name:        string(schemas.StripeSubscriptionCancelledAction),
+				Description: "A subscription is cancelled",
+				Schema:      stripeWebhookSchema(),
+				Options:     webhookOptions,
+			},
+			{
+				Name:        string(schemas.StripeInvoiceFailedAction),
+				Description: "The payment of an invoice failed",
+				Schema:      stripeWebhookSchema(),
+				Options:     webhookOptions,
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.StripeCreateCustomerReaction),
+				Description: "Create a Stripe customer",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"api_key_secret": schemas.StringSchema("Name of the secret holding the Stripe API key").NonEmpty(),
+					"email":          schemas.StringSchema("Email template of the customer").NonEmpty(),
+					"name":           schemas.StringSchema("Name template of the customer"),
+					"description":    schemas.StringSchema("Description template of the customer"),
+				}, "api_key_secret", "email"),
+				Options: toolbox.RealObject(schemas.StripeCreateCustomerOptions{
+					StripeApiKeyOption: apiKeyOption,
+					Email:              "customer@example.com",
+					Name:               "Jane Doe",
+					Description:        "Created by {{.workflow.name}}",
+				}),
+			},
+			{
+				Name:        string(schemas.StripeCreatePaymentLinkReaction),
+				Description: "Create a payment link for a price",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"api_key_secret": schemas.StringSchema("Name of the secret holding the Stripe API key").NonEmpty(),
+					"price_id":       schemas.StringSchema("Id of the price to sell (price_...)").NonEmpty(),
+					"quantity":       schemas.IntegerSchema("Quantity of the price").WithMinimum(1).WithDefault(1),
+				}, "api_key_secret", "price_id"),
+				Options: toolbox.RealObject(schemas.StripeCreatePaymentLinkOptions{
+					StripeApiKeyOption: apiKeyOption,
+					PriceId:            "price_1234",
+					Quantity:           1,
+				}),
+			},
+		},
+	}
+}
+
+func (service *stripeService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.StripePaymentSucceededAction),
+		string(schemas.StripeSubscriptionCancelledAction),
+		string(schemas.StripeInvoiceFailedAction):
+		return service.StripeEventReceived
+	default:
+		return nil
+	}
+}
+
+func (service *stripeService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.StripeCreateCustomerReaction):
+		return service.CreateCustomer
+	case string(schemas.StripeCreatePaymentLinkReaction):
+		return service.CreatePaymentLink
+	default:
+		return nil
+	}
+}
+
+func (service *stripeService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+// StripeEventReceived hands the oldest verified Stripe event to the reaction,
+// the events are received on the hook of the workflow.
+func (service *stripeService) StripeEventReceived(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	channel <- popWebhookDelivery(service.workflowRepository, service.webhookRepository, workflowId)
+}
+
+// ReceiveWebhook verifies the Stripe-Signature of an event and keeps it as a
+// delivery of the workflow when its type is the one of the action. Other
+// event types are acknowledged and dropped so Stripe does not retry them.
+func (service *stripeService) ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error {
+	hook := service.webhookRepository.FindHookByToken(token)
+	if hook.Id == 0 {
+		return schemas.ErrWebhookNotFound
+	}
+	workflow, err := service.workflowRepository.FindByIds(hook.WorkflowId)
+	if err != nil {
+		return schemas.ErrWebhookNotFound
+	}
+	eventType, isStripe := schemas.StripeEventTypes[workflow.Action.Name]
+	if !isStripe {
+		return schemas.ErrWebhookNotFound
+	}
+	options := schemas.StripeWebhookOptions{}
+	err = json.Unmarshal(workflow.ActionOptions, &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+	}
+
+	delivery := schemas.WebhookDelivery{
+		WorkflowId:    workflow.Id,
+		Status:        schemas.WebhookDeliveryRejected,
+		RemoteAddress: remoteAddress,
+		Payload:       json.RawMessage("null"),
+	}
+	if !service.rateLimiter.Allow(token, stripeWebhookRateLimit) {
+		delivery.Status = schemas.WebhookDeliveryRateLimited
+		delivery.Error = schemas.ErrWebhookRateLimited.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookRateLimited
+	}
+	if !workflow.IsActive {
+		delivery.Error = schemas.ErrWebhookInactive.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInactive
+	}
+	secret, err := service.userSecretService.GetSecretValue(workflow.UserId, options.SecretName)
+	if err == nil {
+		err = verifyStripeSignature(header.Get("Stripe-Signature"), body, secret, time.Now())
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookSignature
+	}
+
+	event := schemas.StripeEvent{}
+	err = json.Unmarshal(body, &event)
+	if err != nil || event.Id == "" {
+		delivery.Error = schemas.ErrWebhookInvalidEvent.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInvalidEvent
+	}
+	if event.Type != eventType {
+		return nil
+	}
+	// Stripe delivers at least once and retries until it gets a 2xx, so an
+	// event already accepted is acknowledged without running again.
+	if service.webhookRepository.FindAcceptedDeliveryByEventId(workflow.Id, event.Id).Id != 0 {
+		return nil
+	}
+	delivery.Status = schemas.WebhookDeliveryAccepted
+	delivery.EventId = event.Id
+	delivery.Payload = toolbox.RealObject(schemas.StripeEventPayload{
+		Id:       event.Id,
+		Type:     event.Type,
+		Created:  event.Created,
+		Livemode: event.Livemode,
+		Object:   event.Data.Object,
+	})
+	service.webhookRepository.SaveDelivery(delivery)
+	return nil
+}
+
+// verifyStripeSignature checks a Stripe-Signature header of the form
+// t=<timestamp>,v1=<signature>[,v1=...]: one v1 must be the HMAC-SHA256 of
+// "<timestamp>.<body>" and the timestamp must be recent.
+func verifyStripeSignature(signatureHeader string, body []byte, secret string, now time.Time) error {
+	timestamp := ""
+	signatures := [][]byte{}
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature, err := hex.DecodeString(value)
+			if err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return schemas.ErrStripeSignature
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", schemas.ErrStripeSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return schemas.ErrStripeSignature
+}
+
+func (service *stripeService) CreateCustomer(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.runReaction(workflowId, func(workflow schemas.Workflow) (result schemas.StripeReactionResponse, err error) {
+		options := schemas.StripeCreateCustomerOptions{}
+		err = json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return result, err
+		}
+		templateData := toolbox.WorkflowTemplateData(workflow)
+		form := url.Values{}
+		for name, text := range map[string]string{
+			"email":       options.Email,
+			"name":        options.Name,
+			"description": options.Description,
+		} {
+			value, err := toolbox.RenderTemplate(text, templateData)
+			if err != nil {
+				return result, fmt.Errorf("%s: %w", name, err)
+			}
+			if value != "" {
+				form.Set(name, value)
+			}
+		}
+		form.Set("metadata[workflow_id]", strconv.FormatUint(workflowId, 10))
+
+		customer := schemas.StripeObject{}
+		err = service.stripeRequest(workflow.UserId, options.ApiKeySecret, "customers", form, &customer)
+		if err != nil {
+			return result, err
+		}
+		result.Id = customer.Id
+		result.Email = customer.Email
+		return result, nil
+	})
+}
+
+func (service *stripeService) CreatePaymentLink(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.runReaction(workflowId, func(workflow schemas.Workflow) (result schemas.StripeReactionResponse, err error) {
+		options := schemas.StripeCreatePaymentLinkOptions{Quantity: 1}
+		err = json.Unmarshal([]byte(reactionOption), &options)
+		if err != nil {
+			return result, err
+		}
+		if options.Quantity < 1 {
+			options.Quantity = 1
+		}
+		form := url.Values{}
+		form.Set("line_items[0][price]", options.PriceId)
+		form.Set("line_items[0][quantity]", strconv.Itoa(options.Quantity))
+		form.Set("metadata[workflow_id]", strconv.FormatUint(workflowId, 10))
+
+		paymentLink := schemas.StripeObject{}
+		err = service.stripeRequest(workflow.UserId, options.ApiKeySecret, "payment_links", form, &paymentLink)
+		if err != nil {
+			return result, err
+		}
+		result.Id = paymentLink.Id
+		result.Url = paymentLink.Url
+		return result, nil
+	})
+}
+
+// runReaction runs a Stripe reaction once its workflow is triggered and saves
+// its result, errors included.
+func (service *stripeService) runReaction(workflowId uint64, run func(workflow schemas.Workflow) (schemas.StripeReactionResponse, error)) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+
+	result, err := run(workflow)
+	if err != nil {
+		fmt.Println("Error running stripe reaction:", err)
+		result.Error = err.Error()
+	} else {
+		result.Done = true
+	}
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+// stripeRequest posts form to a Stripe API endpoint with the API key stored
+// in the given user secret.
+func (service *stripeService) stripeRequest(userId uint64, apiKeySecret string, endpoint string, form url.Values, result interface{}) error {
+	apiKey, err := service.userSecretService.GetSecretValue(userId, apiKeySecret)
+	if err != nil {
+		return fmt.Errorf("secret %q: %w", apiKeySecret, err)
+	}
+	request, err := http.NewRequest("POST", stripeApiUrl+endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+apiKey)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		stripeError := schemas.StripeErrorResponse{}
+		err = json.NewDecoder(response.Body).Decode(&stripeError)
+		if err != nil || stripeError.Error.Message == "" {
+			return fmt.Errorf("stripe api returned %s", response.Status)
+		}
+		return errors.New(stripeError.Error.Message)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
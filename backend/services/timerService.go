@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type TimerService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type timerService struct {
+	workflowRepository repository.WorkflowRepository
+	mutex              sync.Mutex
+}
+
+// timerNext returns the run following last, or the first one when last is
+// zero. false means the timer will never fire again.
+type timerNext func(last time.Time, now time.Time) (time.Time, bool)
+
+func NewTimerService(
+	workflowRepository repository.WorkflowRepository,
+) TimerService {
+	return &timerService{
+		workflowRepository: workflowRepository,
+	}
+}
+
+func (service *timerService) GetServiceRegistration() schemas.ServiceRegistration {
+	timeZoneSchema := func() *schemas.JsonSchema {
+		return schemas.StringSchema("IANA time zone, e.g. Europe/Paris").WithDefault("UTC")
+	}
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Timer,
+			Description: "Trigger workflows at given times",
+			Image:       "https://img.icons8.com/?size=100&id=16152&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.TimerEveryMinutesAction),
+				Description: "Every N minutes",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"minutes":   schemas.IntegerSchema("Number of minutes between two runs").WithMinimum(1),
+					"time_zone": timeZoneSchema(),
+				}, "minutes"),
+				Options: toolbox.RealObject(schemas.TimerEveryMinutesOptions{
+					Minutes:  15,
+					TimeZone: "Europe/Paris",
+				}),
+			},
+			{
+				Name:        string(schemas.TimerCronAction),
+				Description: "On a cron schedule",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"expression": schemas.StringSchema("Cron expression: minute hour day-of-month month day-of-week, or @hourly, @daily, ...").WithPattern(`^\s*(@[a-z]+|\S+(\s+\S+){4})\s*$`),
+					"time_zone":  timeZoneSchema(),
+				}, "expression"),
+				Options: toolbox.RealObject(schemas.TimerCronOptions{
+					Expression: "0 9 * * MON",
+					TimeZone:   "Europe/Paris",
+				}),
+			},
+			{
+				Name:        string(schemas.TimerAtDateTimeAction),
+				Description: "Once at a specific date and time",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"datetime":  schemas.StringSchema("Date and time of the run, as YYYY-MM-DDTHH:MM").WithPattern(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}$`),
+					"time_zone": timeZoneSchema(),
+				}, "datetime"),
+				Options: toolbox.RealObject(schemas.TimerAtDateTimeOptions{
+					DateTime: "2025-01-20T09:00",
+					TimeZone: "Europe/Paris",
+				}),
+			},
+		},
+	}
+}
+
+func (service *timerService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.TimerEveryMinutesAction):
+		return service.EveryMinutes
+	case string(schemas.TimerCronAction):
+		return service.CronSchedule
+	case string(schemas.TimerAtDateTimeAction):
+		return service.AtDateTime
+	default:
+		return nil
+	}
+}
+
+func (service *timerService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	return nil
+}
+
+func (service *timerService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+func (service *timerService) EveryMinutes(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	var options schemas.TimerEveryMinutesOptions
+	err := json.Unmarshal([]byte(actionOption), &options)
+	if err != nil || options.Minutes <= 0 {
+		fmt.Println("Error parsing actionOption:", err)
+		channel <- "Invalid timer options"
+		return
+	}
+	interval := time.Duration(options.Minutes) * time.Minute
+	channel <- service.runTimer(workflowId, options.TimeZone, func(last time.Time, now time.Time) (time.Time, bool) {
+		if last.IsZero() {
+			last = now
+		}
+		next := last.Add(interval)
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+		return next, true
+	})
+}
+
+func (service *timerService) CronSchedule(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	var options schemas.TimerCronOptions
+	err := json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		channel <- "Invalid timer options"
+		return
+	}
+	schedule, err := toolbox.ParseCron(options.Expression)
+	if err != nil {
+		fmt.Println("Error parsing cron expression:", err)
+		channel <- err.Error()
+		return
+	}
+	channel <- service.runTimer(workflowId, options.TimeZone, func(last time.Time, now time.Time) (time.Time, bool) {
+		next := schedule.Next(now)
+		return next, !next.IsZero()
+	})
+}
+
+func (service *timerService) AtDateTime(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	var options schemas.TimerAtDateTimeOptions
+	err := json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		channel <- "Invalid timer options"
+		return
+	}
+	location, err := loadTimerLocation(options.TimeZone)
+	if err != nil {
+		channel <- err.Error()
+		return
+	}
+	runAt, err := time.ParseInLocation(schemas.TimerDateTimeLayout, options.DateTime, location)
+	if err != nil {
+		fmt.Println("Error parsing datetime:", err)
+		channel <- err.Error()
+		return
+	}
+	// A date already in the past fires once on the next check.
+	channel <- service.runTimer(workflowId, options.TimeZone, func(last time.Time, now time.Time) (time.Time, bool) {
+		return runAt, last.IsZero()
+	})
+}
+
+func (service *timerService) runTimer(workflowId uint64, timeZone string, next timerNext) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	location, err := loadTimerLocation(timeZone)
+	if err != nil {
+		return err.Error()
+	}
+
+	var state schemas.TimerState
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing timer state:", err)
+		}
+	}
+	now := time.Now().In(location)
+
+	if state.NextRun.IsZero() && !state.Done {
+		var scheduled bool
+		state.NextRun, scheduled = next(time.Time{}, now)
+		state.Done = !scheduled
+		service.saveTimerState(workflow, state)
+		return "Timer scheduled"
+	}
+	if state.Done {
+		return "Timer already fired"
+	}
+	if now.Before(state.NextRun) {
+		return "Timer waiting"
+	}
+
+	workflow.Event = toolbox.RealObject(schemas.TimerEvent{
+		ScheduledAt: state.NextRun.In(location).Format(time.RFC3339),
+		FiredAt:     now.Format(time.RFC3339),
+		TimeZone:    location.String(),
+	})
+	workflow.ReactionTrigger = true
+	service.workflowRepository.UpdateEvent(workflow)
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+
+	nextRun, scheduled := next(state.NextRun, now)
+	state.Done = !scheduled
+	if scheduled {
+		state.NextRun = nextRun
+	}
+	service.saveTimerState(workflow, state)
+	return "Timer fired"
+}
+
+func (service *timerService) saveTimerState(workflow schemas.Workflow, state schemas.TimerState) {
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling timer state:", err)
+		return
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+}
+
+func loadTimerLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		fmt.Println("Error loading time zone:", err)
+		return nil, err
+	}
+	return location, nil
+}
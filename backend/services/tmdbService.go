@@ -0,0 +1,540 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	tmdbApiUrl          = "https://api.themoviedb.org/3/"
+	tmdbImageUrl        = "https://image.tmdb.org/t/p/w500"
+	tmdbSiteUrl         = "https://www.themoviedb.org/"
+	tmdbCheckInterval   = 15 * time.Minute
+	tmdbMaxSeen         = 2000
+	tmdbWatchlistPages  = 5
+	tmdbDefaultLanguage = "en-US"
+)
+
+// tmdbReleaseTypes names the release types of /movie/{id}/release_dates that
+// count as a release, premieres do not.
+var tmdbReleaseTypes = map[int]string{
+	2: "limited theatrical",
+	3: "theatrical",
+	4: "digital",
+	5: "physical",
+	6: "tv",
+}
+
+type TmdbService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+}
+
+type tmdbService struct {
+	workflowRepository          repository.WorkflowRepository
+	reactionResponseDataService ReactionResponseDataService
+	userSecretService           UserSecretService
+	mutex                       sync.Mutex
+}
+
+// tmdbItem is something an action currently sees, reported once under Key.
+type tmdbItem struct {
+	Key   string
+	Event interface{}
+}
+
+func NewTmdbService(
+	workflowRepository repository.WorkflowRepository,
+	reactionResponseDataService ReactionResponseDataService,
+	userSecretService UserSecretService,
+) TmdbService {
+	return &tmdbService{
+		workflowRepository:          workflowRepository,
+		reactionResponseDataService: reactionResponseDataService,
+		userSecretService:           userSecretService,
+	}
+}
+
+func (service *tmdbService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Tmdb,
+			Description: "Track movie and TV releases on The Movie Database",
+			Image:       "https://img.icons8.com/?size=100&id=Q8c8ouRPWSNh&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.TmdbNewEpisodeAction),
+				Description: "A new episode of a TV show aired",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"tv_id":    schemas.IntegerSchema("TMDB id of the TV show").WithMinimum(1),
+					"language": schemas.StringSchema("Language of the texts, e.g. fr-FR").WithPattern(`^[a-z]{2}(-[A-Z]{2})?$`),
+				}, "tv_id"),
+				Options: toolbox.RealObject(schemas.TmdbNewEpisodeOptions{
+					TvId:     1399,
+					Language: "en-US",
+				}),
+			},
+			{
+				Name:        string(schemas.TmdbWatchlistReleasedAction),
+				Description: "A movie of the watchlist is released in a region",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"session_secret": schemas.StringSchema("Name of the secret holding the TMDB session id").NonEmpty(),
+					"region":         schemas.StringSchema("Region as a two letters country code, e.g. FR").WithPattern(`^[A-Z]{2}$`),
+				}, "session_secret", "region"),
+				Options: toolbox.RealObject(schemas.TmdbWatchlistOptions{
+					SessionSecret: "tmdb_session",
+					Region:        "FR",
+				}),
+			},
+			{
+				Name:        string(schemas.TmdbPersonNewCreditAction),
+				Description: "A person has a new movie or TV credit",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"person_id":   schemas.IntegerSchema("TMDB id of the person").WithMinimum(1),
+					"credit_type": schemas.EnumSchema("Credits to follow", "all", "cast", "crew").WithDefault("all"),
+					"language":    schemas.StringSchema("Language of the titles, e.g. fr-FR").WithPattern(`^[a-z]{2}(-[A-Z]{2})?$`),
+				}, "person_id"),
+				Options: toolbox.RealObject(schemas.TmdbPersonCreditOptions{
+					PersonId:   287,
+					CreditType: "cast",
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.TmdbTrendingTodayReaction),
+				Description: "Save the movies, shows or people trending today",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"media_type": schemas.EnumSchema("Kind of trending items", "all", "movie", "tv", "person").WithDefault("all"),
+					"language":   schemas.StringSchema("Language of the texts, e.g. fr-FR").WithPattern(`^[a-z]{2}(-[A-Z]{2})?$`),
+					"limit":      schemas.IntegerSchema("Number of items to keep").WithMinimum(1).WithMaximum(20).WithDefault(10),
+				}, "media_type"),
+				Options: toolbox.RealObject(schemas.TmdbTrendingOptions{
+					MediaType: "movie",
+					Language:  "fr-FR",
+					Limit:     10,
+				}),
+			},
+		},
+	}
+}
+
+func (service *tmdbService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.TmdbNewEpisodeAction):
+		return service.NewEpisode
+	case string(schemas.TmdbWatchlistReleasedAction):
+		return service.WatchlistMovieReleased
+	case string(schemas.TmdbPersonNewCreditAction):
+		return service.PersonNewCredit
+	default:
+		return nil
+	}
+}
+
+func (service *tmdbService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	switch name {
+	case string(schemas.TmdbTrendingTodayReaction):
+		return service.TrendingToday
+	default:
+		return nil
+	}
+}
+
+func (service *tmdbService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+// NewEpisode reports the last aired episode of the show each time it
+// changes.
+func (service *tmdbService) NewEpisode(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkTmdb(workflowId, "new episode", func(workflow schemas.Workflow, seen map[string]bool) ([]tmdbItem, error) {
+		options := schemas.TmdbNewEpisodeOptions{}
+		err := json.Unmarshal([]byte(actionOption), &options)
+		if err != nil {
+			return nil, err
+		}
+		query := url.Values{}
+		query.Set("language", firstNonEmpty(options.Language, tmdbDefaultLanguage))
+		show := schemas.TmdbTvShow{}
+		err = tmdbRequest("tv/"+strconv.FormatUint(options.TvId, 10), query, &show)
+		if err != nil {
+			return nil, err
+		}
+		episode := show.LastEpisodeToAir
+		if episode == nil || episode.AirDate > time.Now().UTC().Format(time.DateOnly) {
+			return nil, nil
+		}
+		return []tmdbItem{{
+			Key: strconv.FormatUint(episode.Id, 10),
+			Event: schemas.TmdbEpisodeEvent{
+				TvId:          show.Id,
+				Show:          show.Name,
+				EpisodeId:     episode.Id,
+				Name:          episode.Name,
+				Overview:      episode.Overview,
+				AirDate:       episode.AirDate,
+				SeasonNumber:  episode.SeasonNumber,
+				EpisodeNumber: episode.EpisodeNumber,
+				Image:         tmdbImage(episode.StillPath),
+				Url: fmt.Sprintf("%stv/%d/season/%d/episode/%d", tmdbSiteUrl,
+					show.Id, episode.SeasonNumber, episode.EpisodeNumber),
+			},
+		}}, nil
+	})
+}
+
+// WatchlistMovieReleased reports the movies of the watchlist that get a
+// release in the region. Only the movies not released yet are checked.
+func (service *tmdbService) WatchlistMovieReleased(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkTmdb(workflowId, "watchlist movie released", func(workflow schemas.Workflow, seen map[string]bool) ([]tmdbItem, error) {
+		options := schemas.TmdbWatchlistOptions{}
+		err := json.Unmarshal([]byte(actionOption), &options)
+		if err != nil {
+			return nil, err
+		}
+		sessionId, err := service.userSecretService.GetSecretValue(workflow.UserId, options.SessionSecret)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", options.SessionSecret, err)
+		}
+		movies, err := listTmdbWatchlist(sessionId)
+		if err != nil {
+			return nil, err
+		}
+		region := strings.ToUpper(options.Region)
+		items := []tmdbItem{}
+		for _, movie := range movies {
+			key := strconv.FormatUint(movie.Id, 10)
+			if seen[key] {
+				items = append(items, tmdbItem{Key: key})
+				continue
+			}
+			releaseDate, releaseType, err := tmdbRegionRelease(movie.Id, region)
+			if err != nil {
+				return nil, err
+			}
+			if releaseDate == "" {
+				continue
+			}
+			items = append(items, tmdbItem{
+				Key: key,
+				Event: schemas.TmdbMovieReleasedEvent{
+					MovieId:     movie.Id,
+					Title:       movie.Title,
+					Overview:    movie.Overview,
+					Region:      region,
+					ReleaseDate: releaseDate,
+					ReleaseType: releaseType,
+					Poster:      tmdbImage(movie.PosterPath),
+					Url:         tmdbSiteUrl + "movie/" + key,
+				},
+			})
+		}
+		return items, nil
+	})
+}
+
+// PersonNewCredit reports the credits added to the person since the last
+// check.
+func (service *tmdbService) PersonNewCredit(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkTmdb(workflowId, "new credit", func(workflow schemas.Workflow, seen map[string]bool) ([]tmdbItem, error) {
+		options := schemas.TmdbPersonCreditOptions{}
+		err := json.Unmarshal([]byte(actionOption), &options)
+		if err != nil {
+			return nil, err
+		}
+		personPath := "person/" + strconv.FormatUint(options.PersonId, 10)
+		query := url.Values{}
+		query.Set("language", firstNonEmpty(options.Language, tmdbDefaultLanguage))
+		person := schemas.TmdbPerson{}
+		err = tmdbRequest(personPath, query, &person)
+		if err != nil {
+			return nil, err
+		}
+		credits := schemas.TmdbCombinedCredits{}
+		err = tmdbRequest(personPath+"/combined_credits", query, &credits)
+		if err != nil {
+			return nil, err
+		}
+
+		items := []tmdbItem{}
+		addCredits := func(creditType string, list []schemas.TmdbCredit) {
+			for _, credit := range list {
+				items = append(items, tmdbItem{
+					Key: credit.CreditId,
+					Event: schemas.TmdbCreditEvent{
+						PersonId:    person.Id,
+						Person:      person.Name,
+						CreditId:    credit.CreditId,
+						CreditType:  creditType,
+						MediaType:   credit.MediaType,
+						Id:          credit.Id,
+						Title:       firstNonEmpty(credit.Title, credit.Name),
+						Character:   credit.Character,
+						Job:         credit.Job,
+						ReleaseDate: firstNonEmpty(credit.ReleaseDate, credit.FirstAirDate),
+						Poster:      tmdbImage(credit.PosterPath),
+						Url:         tmdbSiteUrl + credit.MediaType + "/" + strconv.FormatUint(credit.Id, 10),
+					},
+				})
+			}
+		}
+		if options.CreditType != "crew" {
+			addCredits("cast", credits.Cast)
+		}
+		if options.CreditType != "cast" {
+			addCredits("crew", credits.Crew)
+		}
+		return items, nil
+	})
+}
+
+// checkTmdb polls the action every tmdbCheckInterval and queues the events of
+// the items whose key was never seen, the first poll only records the keys.
+// One pending event is handed to the reaction per call.
+func (service *tmdbService) checkTmdb(workflowId uint64, name string, poll func(workflow schemas.Workflow, seen map[string]bool) ([]tmdbItem, error)) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	state := schemas.TmdbState{}
+	if len(workflow.Utils) != 0 {
+		err = json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing tmdb state:", err)
+		}
+	}
+
+	message := "No " + name
+	if time.Since(state.CheckedAt) >= tmdbCheckInterval {
+		seen := map[string]bool{}
+		for _, key := range state.Seen {
+			seen[key] = true
+		}
+		items, err := poll(workflow, seen)
+		if err != nil {
+			fmt.Println("Error polling tmdb:", err)
+			return err.Error()
+		}
+		state.CheckedAt = time.Now()
+		for _, item := range items {
+			if seen[item.Key] {
+				continue
+			}
+			seen[item.Key] = true
+			state.Seen = append(state.Seen, item.Key)
+			if state.Initialized && item.Event != nil {
+				state.Pending = append(state.Pending, toolbox.RealObject(item.Event))
+			}
+		}
+		if len(state.Seen) > tmdbMaxSeen {
+			state.Seen = state.Seen[len(state.Seen)-tmdbMaxSeen:]
+		}
+		if !state.Initialized {
+			state.Initialized = true
+			message = "TMDB " + name + " initialized"
+		}
+	}
+
+	if !workflow.ReactionTrigger && len(state.Pending) != 0 {
+		workflow.Event = state.Pending[0]
+		workflow.ReactionTrigger = true
+		state.Pending = state.Pending[1:]
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+		message = "TMDB " + name
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling tmdb state:", err)
+		return err.Error()
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return message
+}
+
+// listTmdbWatchlist returns the movies of the watchlist of the account of
+// the session, up to tmdbWatchlistPages pages.
+func listTmdbWatchlist(sessionId string) (movies []schemas.TmdbMovie, err error) {
+	query := url.Values{}
+	query.Set("session_id", sessionId)
+	account := schemas.TmdbAccount{}
+	err = tmdbRequest("account", query, &account)
+	if err != nil {
+		return nil, err
+	}
+	for page := 1; page <= tmdbWatchlistPages; page++ {
+		query.Set("page", strconv.Itoa(page))
+		result := schemas.TmdbMoviePage{}
+		err = tmdbRequest("account/"+strconv.FormatUint(account.Id, 10)+"/watchlist/movies", query, &result)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, result.Results...)
+		if page >= result.TotalPages {
+			break
+		}
+	}
+	return movies, nil
+}
+
+// tmdbRegionRelease returns the earliest past release of the movie in the
+// region, or an empty date when it is not released there yet.
+func tmdbRegionRelease(movieId uint64, region string) (releaseDate string, releaseType string, err error) {
+	releases := schemas.TmdbReleaseDates{}
+	err = tmdbRequest("movie/"+strconv.FormatUint(movieId, 10)+"/release_dates", nil, &releases)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	for _, country := range releases.Results {
+		if country.Iso31661 != region {
+			continue
+		}
+		for _, release := range country.ReleaseDates {
+			typeName, counts := tmdbReleaseTypes[release.Type]
+			date, err := time.Parse(time.RFC3339, release.ReleaseDate)
+			if !counts || err != nil || date.After(now) {
+				continue
+			}
+			if releaseDate == "" || release.ReleaseDate < releaseDate {
+				releaseDate = release.ReleaseDate
+				releaseType = typeName
+			}
+		}
+	}
+	return releaseDate, releaseType, nil
+}
+
+func (service *tmdbService) TrendingToday(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		return
+	}
+	options := schemas.TmdbTrendingOptions{MediaType: "all", Limit: 10}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+
+	result := schemas.TmdbTrendingResponse{MediaType: options.MediaType}
+	items, err := trendingToday(options)
+	if err != nil {
+		fmt.Println("Error fetching tmdb trending:", err)
+		result.Error = err.Error()
+	} else {
+		result.Items = items
+		result.Done = true
+	}
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+func trendingToday(options schemas.TmdbTrendingOptions) (items []schemas.TmdbTrendingItem, err error) {
+	mediaType := firstNonEmpty(options.MediaType, "all")
+	query := url.Values{}
+	query.Set("language", firstNonEmpty(options.Language, tmdbDefaultLanguage))
+	page := schemas.TmdbTrendingPage{}
+	err = tmdbRequest("trending/"+mediaType+"/day", query, &page)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(page.Results, func(i, j int) bool {
+		return page.Results[i].Popularity > page.Results[j].Popularity
+	})
+	for _, trending := range page.Results {
+		if options.Limit > 0 && len(items) >= options.Limit {
+			break
+		}
+		itemType := firstNonEmpty(trending.MediaType, mediaType)
+		items = append(items, schemas.TmdbTrendingItem{
+			Id:          trending.Id,
+			MediaType:   itemType,
+			Title:       firstNonEmpty(trending.Title, trending.Name),
+			Overview:    trending.Overview,
+			ReleaseDate: firstNonEmpty(trending.ReleaseDate, trending.FirstAirDate),
+			VoteAverage: trending.VoteAverage,
+			Popularity:  trending.Popularity,
+			Image:       tmdbImage(firstNonEmpty(trending.PosterPath, trending.ProfilePath)),
+			Url:         tmdbSiteUrl + itemType + "/" + strconv.FormatUint(trending.Id, 10),
+		})
+	}
+	return items, nil
+}
+
+func tmdbImage(path string) string {
+	if path == "" {
+		return ""
+	}
+	return tmdbImageUrl + path
+}
+
+// tmdbRequest gets a TMDB API path with the application key from
+// TMDB_API_KEY and decodes the JSON response in result.
+func tmdbRequest(path string, query url.Values, result interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api_key", toolbox.GetInEnv("TMDB_API_KEY"))
+	request, err := http.NewRequest("GET", tmdbApiUrl+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", path, schemas.ErrTmdbNotFound)
+	}
+	if response.StatusCode != http.StatusOK {
+		tmdbError := schemas.TmdbErrorResponse{}
+		err = json.NewDecoder(response.Body).Decode(&tmdbError)
+		if err != nil || tmdbError.StatusMessage == "" {
+			return fmt.Errorf("tmdb api returned %s", response.Status)
+		}
+		return errors.New(tmdbError.StatusMessage)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"regexp"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+type UserSecretService interface {
+	SaveSecret(userId uint64, name string, value string) error
+	DeleteSecret(userId uint64, name string) error
+	GetSecretNames(userId uint64) []schemas.UserSecret
+	GetSecretValue(userId uint64, name string) (string, error)
+}
+
+type userSecretService struct {
+	repository repository.UserSecretRepository
+}
+
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+func NewUserSecretService(
+	repository repository.UserSecretRepository,
+) UserSecretService {
+	return &userSecretService{
+		repository: repository,
+	}
+}
+
+func (service *userSecretService) SaveSecret(userId uint64, name string, value string) error {
+	if !secretNamePattern.MatchString(name) {
+		return schemas.ErrInvalidSecretName
+	}
+	encryptedValue, err := toolbox.EncryptSecret(value)
+	if err != nil {
+		return err
+	}
+	existingSecret := service.repository.FindByUserIdAndName(userId, name)
+	if existingSecret.Id != 0 {
+		existingSecret.Value = encryptedValue
+		service.repository.Update(existingSecret)
+		return nil
+	}
+	service.repository.Save(schemas.UserSecret{
+		UserId: userId,
+		Name:   name,
+		Value:  encryptedValue,
+	})
+	return nil
+}
+
+func (service *userSecretService) DeleteSecret(userId uint64, name string) error {
+	existingSecret := service.repository.FindByUserIdAndName(userId, name)
+	if existingSecret.Id == 0 {
+		return schemas.ErrSecretNotFound
+	}
+	service.repository.Delete(existingSecret)
+	return nil
+}
+
+// GetSecretNames lists the secrets of a user. Values never leave the
+// service: the returned secrets only carry their name and dates.
+func (service *userSecretService) GetSecretNames(userId uint64) []schemas.UserSecret {
+	secrets := service.repository.FindByUserId(userId)
+	for index := range secrets {
+		secrets[index].Value = ""
+	}
+	return secrets
+}
+
+func (service *userSecretService) GetSecretValue(userId uint64, name string) (string, error) {
+	existingSecret := service.repository.FindByUserIdAndName(userId, name)
+	if existingSecret.Id == 0 {
+		return "", schemas.ErrSecretNotFound
+	}
+	return toolbox.DecryptSecret(existingSecret.Value)
+}
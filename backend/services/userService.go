@@ -20,6 +20,8 @@ type UserService interface {
 	GetUserByEmail(email *string) schemas.User
 	CreateUser(newUser schemas.User) error
 	DeleteUser(userId uint64) error
+	MarkEmailVerified(userId uint64)
+	SetMfaEnabled(userId uint64, enabled bool)
 	AddServiceToUser(user schemas.User, serviceToAdd schemas.ServiceToken) error
 	GetAllServicesForUser(userId uint64) ([]schemas.ServiceToken, error)
 	GetServiceByIdForUser(user schemas.User, serviceId uint64) (schemas.ServiceToken, error)
@@ -33,17 +35,20 @@ type userService struct {
 	authorizedPassword string
 	repository         repository.UserRepository
 	serviceJWT         JWTService
+	apiKeyService      ApiKeyService
 }
 
 func NewUserService(
 	repository repository.UserRepository,
 	serviceJWT JWTService,
+	apiKeyService ApiKeyService,
 ) UserService {
 	return &userService{
 		authorizedUsername: "root",
 		authorizedPassword: "password",
 		repository:         repository,
 		serviceJWT:         serviceJWT,
+		apiKeyService:      apiKeyService,
 	}
 }
 
@@ -54,6 +59,10 @@ func (service *userService) Login(newUser schemas.User, actualService schemas.Se
 	}
 
 	if database.CompareHashAndPassword(user.Password, newUser.Password) {
+		service.rehashPassword(user, *newUser.Password)
+		if user.MfaEnabled {
+			return service.serviceJWT.GenerateMfaPendingToken(strconv.FormatUint(user.Id, 10)), schemas.ErrMfaRequired
+		}
 		return service.serviceJWT.GenerateJWTToken(
 			strconv.FormatUint(user.Id, 10),
 			user.Username,
@@ -63,6 +72,11 @@ func (service *userService) Login(newUser schemas.User, actualService schemas.Se
 
 	if user.Username == newUser.Username {
 		serviceToken, _ := service.GetServiceByIdForUser(user, actualService.Id)
+		// A service login stands for the password only, the second factor
+		// is still asked for.
+		if serviceToken.Id != 0 && user.MfaEnabled {
+			return service.serviceJWT.GenerateMfaPendingToken(strconv.FormatUint(user.Id, 10)), schemas.ErrMfaRequired
+		}
 		if serviceToken.Id != 0 {
 			return service.serviceJWT.GenerateJWTToken(
 				strconv.FormatUint(user.Id, 10),
@@ -90,16 +104,34 @@ func (service *userService) Register(newUser schemas.User) (JWTtoken string, err
 	}
 
 	service.repository.Save(newUser)
+	newUser = service.repository.FindByUsername(newUser.Username)
 	return service.serviceJWT.GenerateJWTToken(fmt.Sprint(newUser.Id), newUser.Username, false), nil
 }
 
 func (service *userService) GetUserInfos(token string) (userInfos schemas.User, err error) {
+	if IsApiKey(token) {
+		apiKey, err := service.apiKeyService.Authenticate(token)
+		if err != nil {
+			return schemas.User{}, err
+		}
+		return service.repository.FindById(apiKey.UserId), nil
+	}
 	userId, err := service.serviceJWT.GetUserIdFromToken(token)
 	if err != nil {
 		return schemas.User{}, err
 	}
 
 	userInfos = service.repository.FindById(userId)
+	if userInfos.SessionsValidFrom != nil {
+		issuedAt, err := service.serviceJWT.GetIssuedAtFromToken(token)
+		if err != nil {
+			return schemas.User{}, err
+		}
+		// iat has a one second precision, as SessionsValidFrom is compared to it.
+		if issuedAt.Unix() < userInfos.SessionsValidFrom.Unix() {
+			return schemas.User{}, schemas.ErrSessionRevoked
+		}
+	}
 	return userInfos, nil
 }
 
@@ -156,3 +188,28 @@ func (service *userService) DeleteUser(userId uint64) error {
 	service.repository.Delete(user)
 	return nil
 }
+
+func (service *userService) MarkEmailVerified(userId uint64) {
+	service.repository.UpdateEmailVerificationPending(userId, false)
+}
+
+func (service *userService) SetMfaEnabled(userId uint64, enabled bool) {
+	service.repository.UpdateMfaEnabled(userId, enabled)
+}
+
+// rehashPassword upgrades a stored hash to the configured algorithm and cost
+// while the clear password is at hand, i.e. on a successful login.
+func (service *userService) rehashPassword(user schemas.User, password string) {
+	if !database.NeedsRehash(*user.Password) {
+		return
+	}
+	hashedPassword, err := database.HashPassword(password)
+	if err != nil {
+		fmt.Println("Error rehashing password:", err)
+		return
+	}
+	service.repository.Update(schemas.User{
+		Id:       user.Id,
+		Password: &hashedPassword,
+	})
+}
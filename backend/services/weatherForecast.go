@@ -0,0 +1,253 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	weatherApiUrl       = "https://api.weatherapi.com/v1/"
+	weatherForecastDays = 3
+)
+
+func (service *weatherService) RainExpected(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkForecastThreshold(workflowId, actionOption, "chance_of_rain", func(hour schemas.WeatherForecastHour) float64 {
+		return hour.ChanceOfRain
+	})
+}
+
+func (service *weatherService) WindAbove(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkForecastThreshold(workflowId, actionOption, "wind_kph", func(hour schemas.WeatherForecastHour) float64 {
+		return hour.WindKph
+	})
+}
+
+func (service *weatherService) UvAbove(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkForecastThreshold(workflowId, actionOption, "uv", func(hour schemas.WeatherForecastHour) float64 {
+		return hour.Uv
+	})
+}
+
+// checkForecastThreshold fires when one of the forecast hours of the next
+// options.Hours hours reaches the threshold while none did on the previous
+// check. The measure of the first matching hour is the event.
+func (service *weatherService) checkForecastThreshold(workflowId uint64, actionOption json.RawMessage, measure string, value func(schemas.WeatherForecastHour) float64) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	options := schemas.WeatherForecastOptions{}
+	err = json.Unmarshal([]byte(actionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+		return err.Error()
+	}
+	forecast, err := weatherForecast(options.CityName, "", weatherForecastDays)
+	if err != nil {
+		fmt.Println("Error fetching forecast:", err)
+		return err.Error()
+	}
+
+	var matchedHour *schemas.WeatherForecastHour
+	for _, hour := range forecastHours(forecast, options.Hours) {
+		if value(hour) >= options.Threshold {
+			matchedHour = &hour
+			break
+		}
+	}
+	event := schemas.WeatherThresholdEvent{}
+	if matchedHour != nil {
+		event = schemas.WeatherThresholdEvent{
+			City:      forecast.Location.Name,
+			Country:   forecast.Location.Country,
+			Measure:   measure,
+			Value:     value(*matchedHour),
+			Threshold: options.Threshold,
+			Time:      matchedHour.Time,
+			Condition: matchedHour.Condition.Text,
+		}
+	}
+	if service.crossThreshold(workflow, matchedHour != nil, event) {
+		return "Forecast threshold reached"
+	}
+	return "Forecast threshold not reached"
+}
+
+// crossThreshold records in the workflow whether the condition holds and
+// triggers the reaction with event when it did not hold on the previous
+// check. The first check only records the condition. A crossing met while
+// the reaction is still running is not recorded, so the next check sees it
+// again.
+func (service *weatherService) crossThreshold(workflow schemas.Workflow, matched bool, event interface{}) bool {
+	state := schemas.WeatherThresholdState{}
+	if len(workflow.Utils) != 0 {
+		err := json.Unmarshal(workflow.Utils, &state)
+		if err != nil {
+			fmt.Println("Error parsing weather state:", err)
+		}
+	}
+	crossed := state.Initialized && !state.Matched && matched
+	if crossed && workflow.ReactionTrigger {
+		return false
+	}
+	state.Initialized = true
+	state.Matched = matched
+
+	if crossed {
+		workflow.Event = toolbox.RealObject(event)
+		workflow.ReactionTrigger = true
+		service.workflowRepository.UpdateEvent(workflow)
+		service.workflowRepository.UpdateReactionTrigger(workflow)
+	}
+	jsonData, err := json.Marshal(state)
+	if err != nil {
+		fmt.Println("Error marshalling weather state:", err)
+		return false
+	}
+	workflow.Utils = jsonData
+	service.workflowRepository.UpdateUtils(workflow)
+	return crossed
+}
+
+// forecastHours returns the forecast hours from the current hour to the
+// given number of hours ahead.
+func forecastHours(forecast schemas.WeatherForecastResponse, hours int) (result []schemas.WeatherForecastHour) {
+	now := time.Now().Unix()
+	from := now - now%3600
+	until := now + int64(hours)*3600
+	for _, day := range forecast.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			if hour.TimeEpoch >= from && hour.TimeEpoch <= until {
+				result = append(result, hour)
+			}
+		}
+	}
+	return result
+}
+
+func (service *weatherService) DailyForecast(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !workflow.ReactionTrigger {
+		fmt.Println("Trigger is already false, skipping reaction.")
+		return
+	}
+	options := schemas.WeatherDailyForecastOptions{}
+	err = json.Unmarshal([]byte(reactionOption), &options)
+	if err != nil {
+		fmt.Println("Error parsing reactionOption:", err)
+		return
+	}
+	if options.Days == 0 {
+		options.Days = 1
+	}
+
+	result := schemas.WeatherDailyForecastResponse{City: options.CityName}
+	forecast, err := weatherForecast(options.CityName, options.LanguageCode, options.Days)
+	if err != nil {
+		fmt.Println("Error fetching forecast:", err)
+		result.Error = err.Error()
+	} else {
+		result.City = forecast.Location.Name
+		result.Country = forecast.Location.Country
+		lines := []string{}
+		for _, day := range forecast.Forecast.Forecastday {
+			dayForecast := schemas.WeatherDayForecast{
+				Date:         day.Date,
+				Condition:    day.Day.Condition.Text,
+				MinTempC:     day.Day.MintempC,
+				MaxTempC:     day.Day.MaxtempC,
+				ChanceOfRain: day.Day.DailyChanceOfRain,
+				PrecipMm:     day.Day.TotalprecipMm,
+				MaxWindKph:   day.Day.MaxwindKph,
+				Uv:           day.Day.Uv,
+				Sunrise:      day.Astro.Sunrise,
+				Sunset:       day.Astro.Sunset,
+			}
+			result.Days = append(result.Days, dayForecast)
+			lines = append(lines, formatDayForecast(forecast.Location, dayForecast))
+		}
+		result.Summary = strings.Join(lines, "\n")
+		result.Done = true
+	}
+
+	jsonValue, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		return
+	}
+	service.reactionResponseDataService.Save(schemas.ReactionResponseData{
+		WorkflowId:  workflowId,
+		ApiResponse: jsonValue,
+	})
+	workflow.ReactionTrigger = false
+	service.workflowRepository.UpdateReactionTrigger(workflow)
+}
+
+func formatDayForecast(location schemas.WeatherLocation, day schemas.WeatherDayForecast) string {
+	return fmt.Sprintf("%s, %s - %s: %s, %s°C to %s°C, %s%% chance of rain (%smm), wind up to %s km/h, UV %s, sunrise %s, sunset %s",
+		location.Name, location.Country, day.Date, day.Condition,
+		formatMeasure(day.MinTempC), formatMeasure(day.MaxTempC),
+		formatMeasure(day.ChanceOfRain), formatMeasure(day.PrecipMm),
+		formatMeasure(day.MaxWindKph), formatMeasure(day.Uv),
+		day.Sunrise, day.Sunset)
+}
+
+func formatMeasure(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+// weatherForecast calls the forecast endpoint for the given number of days,
+// the response also holds the current conditions.
+func weatherForecast(cityName string, languageCode string, days int) (forecast schemas.WeatherForecastResponse, err error) {
+	query := url.Values{}
+	query.Set("key", toolbox.GetInEnv("WEATHER_API_KEY"))
+	query.Set("q", cityName)
+	query.Set("days", strconv.Itoa(days))
+	if languageCode != "" {
+		query.Set("lang", strings.ToLower(languageCode))
+	}
+	request, err := http.NewRequest("GET", weatherApiUrl+"forecast.json?"+query.Encode(), nil)
+	if err != nil {
+		return forecast, err
+	}
+	request.Header.Set("Accept", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return forecast, err
+	}
+	defer response.Body.Close()
+
+	err = json.NewDecoder(response.Body).Decode(&forecast)
+	if err != nil {
+		return forecast, err
+	}
+	if forecast.Error != nil {
+		return forecast, errors.New(forecast.Error.Message)
+	}
+	if response.StatusCode != http.StatusOK {
+		return forecast, fmt.Errorf("weather api returned %s", response.Status)
+	}
+	return forecast, nil
+}
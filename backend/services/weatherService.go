@@ -14,6 +14,7 @@ import (
 )
 
 type WeatherService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
 	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
 	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
 	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
@@ -38,12 +39,128 @@ func NewWeatherService(
 	}
 }
 
+func (service *weatherService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Weather,
+			Description: "This is the Weather Service",
+			Image:       "https://img.icons8.com/?size=100&id=15359&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.WeatherCurrentAction),
+				Description: "The feeling temperature crosses a threshold",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name":     schemas.StringSchema("City to watch").NonEmpty(),
+					"language_code": schemas.StringSchema("Language of the condition text (FR, EN, ...)").WithDefault("FR"),
+					"temperature":   schemas.StringSchema("Feeling temperature in °C to compare with").WithPattern(`^-?\d+(\.\d+)?$`),
+					"compare_sign":  schemas.EnumSchema("Comparison between the current and the given temperature", ">", "<", "="),
+				}, "city_name", "temperature", "compare_sign"),
+				Options: toolbox.RealObject(schemas.WeatherCurrentOptions{
+					CityName:     "Bordeaux",
+					LanguageCode: "FR",
+					Temperature:  "0",
+					CompareSign:  ">",
+				}),
+			},
+			{
+				Name:        string(schemas.WeatherTimeAction),
+				Description: "Wait for a specific time",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name": schemas.StringSchema("City to watch").NonEmpty(),
+					"dt":        schemas.StringSchema("Day of the sunrise").WithFormat("date"),
+				}, "city_name", "dt"),
+				Options: toolbox.RealObject(schemas.WeatherSpecificTimeOption{
+					DateTime: "2025-01-18",
+					CityName: "Bordeaux",
+				}),
+			},
+			{
+				Name:        string(schemas.WeatherRainAction),
+				Description: "Rain is expected in the next hours",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name": schemas.StringSchema("City to watch").NonEmpty(),
+					"hours":     schemas.IntegerSchema("Number of hours to look ahead").WithMinimum(1).WithMaximum(48),
+					"threshold": schemas.NumberSchema("Chance of rain in percent").WithMinimum(0).WithMaximum(100).WithDefault(50),
+				}, "city_name", "hours", "threshold"),
+				Options: toolbox.RealObject(schemas.WeatherForecastOptions{
+					CityName:  "Bordeaux",
+					Hours:     3,
+					Threshold: 50,
+				}),
+			},
+			{
+				Name:        string(schemas.WeatherWindAction),
+				Description: "The wind is expected above a speed in the next hours",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name": schemas.StringSchema("City to watch").NonEmpty(),
+					"hours":     schemas.IntegerSchema("Number of hours to look ahead").WithMinimum(1).WithMaximum(48),
+					"threshold": schemas.NumberSchema("Wind speed in km/h").WithMinimum(0),
+				}, "city_name", "hours", "threshold"),
+				Options: toolbox.RealObject(schemas.WeatherForecastOptions{
+					CityName:  "Bordeaux",
+					Hours:     6,
+					Threshold: 50,
+				}),
+			},
+			{
+				Name:        string(schemas.WeatherUvAction),
+				Description: "The UV index is expected above a value in the next hours",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name": schemas.StringSchema("City to watch").NonEmpty(),
+					"hours":     schemas.IntegerSchema("Number of hours to look ahead").WithMinimum(1).WithMaximum(48),
+					"threshold": schemas.NumberSchema("UV index").WithMinimum(0),
+				}, "city_name", "hours", "threshold"),
+				Options: toolbox.RealObject(schemas.WeatherForecastOptions{
+					CityName:  "Bordeaux",
+					Hours:     12,
+					Threshold: 6,
+				}),
+			},
+		},
+		Reactions: []schemas.Reaction{
+			{
+				Name:        string(schemas.WeatherCurrentReaction),
+				Description: "Get the current weather of a city",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name":     schemas.StringSchema("City to fetch the weather of").NonEmpty(),
+					"language_code": schemas.StringSchema("Language of the condition text (FR, EN, ...)").WithDefault("FR"),
+				}, "city_name"),
+				Options: toolbox.RealObject(schemas.WeatherCurrentReactionOptions{
+					CityName:     "Bordeaux",
+					LanguageCode: "FR",
+				}),
+			},
+			{
+				Name:        string(schemas.WeatherDailyForecastReaction),
+				Description: "Save a summary of the forecast of a city",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"city_name":     schemas.StringSchema("City to fetch the forecast of").NonEmpty(),
+					"language_code": schemas.StringSchema("Language of the condition text (FR, EN, ...)").WithDefault("FR"),
+					"days":          schemas.IntegerSchema("Number of days to forecast").WithMinimum(1).WithMaximum(3).WithDefault(1),
+				}, "city_name"),
+				Options: toolbox.RealObject(schemas.WeatherDailyForecastOptions{
+					CityName:     "Bordeaux",
+					LanguageCode: "FR",
+					Days:         1,
+				}),
+			},
+		},
+	}
+}
+
 func (service *weatherService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
 	switch name {
 	case string(schemas.WeatherCurrentAction):
 		return service.VerifyFeelingTemperature
 	case string(schemas.WeatherTimeAction):
 		return service.SunriseEvents
+	case string(schemas.WeatherRainAction):
+		return service.RainExpected
+	case string(schemas.WeatherWindAction):
+		return service.WindAbove
+	case string(schemas.WeatherUvAction):
+		return service.UvAbove
 	default:
 		return nil
 	}
@@ -53,77 +170,69 @@ func (service *weatherService) FindReactionByName(name string) func(channel chan
 	switch name {
 	case string(schemas.WeatherCurrentReaction):
 		return service.GetCurrentWeather
+	case string(schemas.WeatherDailyForecastReaction):
+		return service.DailyForecast
 	default:
 		return nil
 	}
 }
 
 func (service *weatherService) VerifyFeelingTemperature(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.checkFeelingTemperature(workflowId, actionOption)
+}
+
+// checkFeelingTemperature fires when the comparison between the feeling
+// temperature and the given one becomes true, not on every check while it
+// stays true.
+func (service *weatherService) checkFeelingTemperature(workflowId uint64, actionOption json.RawMessage) string {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
 
 	workflow, err := service.workflowRepository.FindByIds(workflowId)
 	if err != nil {
 		fmt.Println(err)
-		return
+		return err.Error()
 	}
-
-	apiKey := toolbox.GetInEnv("WEATHER_API_KEY")
-
 	var actionData schemas.WeatherCurrentOptions
 	err = json.Unmarshal([]byte(actionOption), &actionData)
 	if err != nil {
 		fmt.Println("Error parsing actionOption:", err)
-		return
+		return err.Error()
 	}
-	requestedUrl := "https://api.weatherapi.com/v1/current.json?key=" + apiKey + "&q=" + actionData.CityName + "&lang=" + actionData.LanguageCode
-	request, err := http.NewRequest("GET", requestedUrl, nil)
-	if err != nil {
-		channel <- err.Error()
-		return
-	}
-	client := &http.Client{}
-	request.Header.Set("Accept", "application/json")
-	response, err := client.Do(request)
+	realTemperature, err := toolbox.StringToFloat64(actionData.Temperature)
 	if err != nil {
-		channel <- err.Error()
-		return
+		return err.Error()
 	}
-	defer response.Body.Close()
-
-	var weatherResponse schemas.WeatherActionOptions
-	bodyBytes, _ := io.ReadAll(response.Body)
-
-	err = json.Unmarshal(bodyBytes, &weatherResponse)
+	forecast, err := weatherForecast(actionData.CityName, actionData.LanguageCode, 1)
 	if err != nil {
-		fmt.Printf("Error: %s\n", err)
-		channel <- err.Error()
-		return
+		fmt.Println("Error fetching forecast:", err)
+		return err.Error()
 	}
 
-	realTemperature, err := toolbox.StringToFloat64(actionData.Temperature)
-	if err != nil {
-		return
-	}
+	feelsLike := forecast.Current.FeelslikeC
+	matched := false
 	switch actionData.CompareSign {
 	case ">":
-		if realTemperature < weatherResponse.Current.Feelslike_c {
-			service.UpdateWorkflowForAction(workflow, actionData)
-			channel <- "Current weather"
-		}
+		matched = feelsLike > realTemperature
 	case "<":
-		if realTemperature > weatherResponse.Current.Feelslike_c {
-			service.UpdateWorkflowForAction(workflow, actionData)
-			channel <- "Current weather"
-		}
+		matched = feelsLike < realTemperature
 	case "=":
-		{
-			if realTemperature == weatherResponse.Current.Feelslike_c {
-				service.UpdateWorkflowForAction(workflow, actionData)
-				channel <- "Current weather"
-			}
-		}
+		matched = feelsLike == realTemperature
+	}
+	event := schemas.WeatherThresholdEvent{
+		City:        forecast.Location.Name,
+		Country:     forecast.Location.Country,
+		Measure:     "feelslike_c",
+		Value:       feelsLike,
+		Threshold:   realTemperature,
+		CompareSign: actionData.CompareSign,
+		Time:        forecast.Location.Localtime,
+		Condition:   forecast.Current.Condition.Text,
+	}
+	if service.crossThreshold(workflow, matched, event) {
+		return "Current weather"
 	}
+	return "Temperature threshold not crossed"
 }
 
 func (service *weatherService) GetCurrentWeather(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
@@ -199,12 +308,6 @@ func (service *weatherService) GetCurrentWeather(channel chan string, workflowId
 	service.workflowRepository.UpdateReactionTrigger(workflow)
 }
 
-func (service *weatherService) UpdateWorkflowForAction(workflow schemas.Workflow, actionData schemas.WeatherCurrentOptions) {
-	workflow.ReactionTrigger = true
-	workflow.ActionOptions = toolbox.RealObject(actionData)
-	service.workflowRepository.Update(workflow)
-}
-
 func (service *weatherService) SunriseEvents(channel chan string, workflowId uint64, actionOption json.RawMessage) {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
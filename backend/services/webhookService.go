@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"area51/repository"
+	"area51/schemas"
+	"area51/toolbox"
+)
+
+const (
+	webhookDeliveriesHistory = 50
+	// webhookFailureRateLimit caps the requests failing verification per
+	// minute, counted apart from the rate limit of the verified ones.
+	webhookFailureRateLimit = 30
+)
+
+// webhookHookMutex guards the creation of hooks, which both the webhook and
+// the Stripe services do.
+var webhookHookMutex sync.Mutex
+
+type WebhookService interface {
+	GetServiceRegistration() schemas.ServiceRegistration
+	FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage)
+	FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage)
+	GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos)
+	ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error
+	GetWebhook(userId uint64, workflowId uint64) (schemas.WebhookJson, error)
+}
+
+type webhookService struct {
+	workflowRepository repository.WorkflowRepository
+	webhookRepository  repository.WebhookRepository
+	userSecretService  UserSecretService
+	rateLimiter        *toolbox.RateLimiter
+	failureLimiter     *toolbox.RateLimiter
+	mutex              sync.Mutex
+}
+
+func NewWebhookService(
+	workflowRepository repository.WorkflowRepository,
+	webhookRepository repository.WebhookRepository,
+	userSecretService UserSecretService,
+) WebhookService {
+	return &webhookService{
+		workflowRepository: workflowRepository,
+		webhookRepository:  webhookRepository,
+		userSecretService:  userSecretService,
+		rateLimiter:        toolbox.NewRateLimiter(time.Minute),
+		failureLimiter:     toolbox.NewRateLimiter(time.Minute),
+	}
+}
+
+func (service *webhookService) GetServiceRegistration() schemas.ServiceRegistration {
+	return schemas.ServiceRegistration{
+		Service: schemas.Service{
+			Name:        schemas.Webhook,
+			Description: "Start a workflow from any external system",
+			Image:       "https://img.icons8.com/?size=100&id=OYvqHkIfCF37&format=png&color=000000",
+		},
+		Actions: []schemas.Action{
+			{
+				Name:        string(schemas.WebhookReceivedAction),
+				Description: "A request is received on the webhook URL",
+				Schema: schemas.OptionsSchema(map[string]*schemas.JsonSchema{
+					"verification": schemas.EnumSchema(
+						"How incoming requests are authenticated",
+						schemas.WebhookVerificationNone,
+						schemas.WebhookVerificationSharedSecret,
+						schemas.WebhookVerificationHmacSha256,
+					).WithDefault(schemas.WebhookVerificationNone),
+					"secret_name":      schemas.StringSchema("Name of the secret used to verify requests"),
+					"signature_header": schemas.StringSchema("Header carrying the shared secret or the HMAC signature"),
+					"rate_limit":       schemas.IntegerSchema("Maximum number of requests accepted per minute").WithMinimum(1).WithMaximum(600).WithDefault(60),
+				}),
+				Options: toolbox.RealObject(schemas.WebhookOptions{
+					Verification:    schemas.WebhookVerificationHmacSha256,
+					SecretName:      "my_webhook_secret",
+					SignatureHeader: "X-Webhook-Signature",
+					RateLimit:       60,
+				}),
+			},
+		},
+	}
+}
+
+func (service *webhookService) FindActionByName(name string) func(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	switch name {
+	case string(schemas.WebhookReceivedAction):
+		return service.WebhookReceived
+	default:
+		return nil
+	}
+}
+
+func (service *webhookService) FindReactionByName(name string) func(channel chan string, workflowId uint64, accessToken []schemas.ServiceToken, reactionOption json.RawMessage) {
+	return nil
+}
+
+func (service *webhookService) GetUserInfosByToken(accessToken string, serviceName schemas.ServiceName) func(*schemas.ServicesUserInfos) {
+	return nil
+}
+
+// WebhookReceived hands the oldest accepted delivery to the reaction as the
+// workflow event, once the previous one has been consumed.
+func (service *webhookService) WebhookReceived(channel chan string, workflowId uint64, actionOption json.RawMessage) {
+	channel <- service.popDelivery(workflowId)
+}
+
+func (service *webhookService) popDelivery(workflowId uint64) string {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return popWebhookDelivery(service.workflowRepository, service.webhookRepository, workflowId)
+}
+
+// popWebhookDelivery makes the oldest accepted delivery of the workflow hook
+// its event. The Stripe actions receive their deliveries the same way.
+func popWebhookDelivery(workflowRepository repository.WorkflowRepository, webhookRepository repository.WebhookRepository, workflowId uint64) string {
+	workflow, err := workflowRepository.FindByIds(workflowId)
+	if err != nil {
+		fmt.Println(err)
+		return err.Error()
+	}
+	ensureWebhookHook(webhookRepository, workflowId)
+	if workflow.ReactionTrigger {
+		return "Previous webhook not handled yet"
+	}
+	delivery := webhookRepository.FindNextPendingDelivery(workflowId)
+	if delivery.Id == 0 {
+		return "No webhook received"
+	}
+	workflow.Event = delivery.Payload
+	workflow.ReactionTrigger = true
+	workflowRepository.UpdateEvent(workflow)
+	workflowRepository.UpdateReactionTrigger(workflow)
+	delivery.Processed = true
+	webhookRepository.UpdateDeliveryProcessed(delivery)
+	return "Webhook received"
+}
+
+func ensureWebhookHook(webhookRepository repository.WebhookRepository, workflowId uint64) schemas.WebhookHook {
+	webhookHookMutex.Lock()
+	defer webhookHookMutex.Unlock()
+
+	hook := webhookRepository.FindHookByWorkflowId(workflowId)
+	if hook.Id != 0 {
+		return hook
+	}
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		panic(err)
+	}
+	webhookRepository.SaveHook(schemas.WebhookHook{
+		WorkflowId: workflowId,
+		Token:      hex.EncodeToString(token),
+	})
+	return webhookRepository.FindHookByWorkflowId(workflowId)
+}
+
+func (service *webhookService) GetWebhook(userId uint64, workflowId uint64) (schemas.WebhookJson, error) {
+	workflow, err := service.workflowRepository.FindByIds(workflowId)
+	if err != nil || workflow.UserId != userId {
+		return schemas.WebhookJson{}, schemas.ErrorNoWorkflowFound
+	}
+	hookPath, found := webhookHookPath(workflow.Action.Name)
+	if !found {
+		return schemas.WebhookJson{}, schemas.ErrWebhookNotFound
+	}
+
+	hook := ensureWebhookHook(service.webhookRepository, workflowId)
+
+	return schemas.WebhookJson{
+		WorkflowId: workflowId,
+		Url:        webhookUrl(hookPath, hook.Token),
+		Deliveries: service.webhookRepository.FindDeliveriesByWorkflowId(workflowId, webhookDeliveriesHistory),
+	}, nil
+}
+
+// webhookHookPath returns the path receiving the requests of the hooks of an
+// action, the Stripe and GitLab actions verify their own signatures.
+func webhookHookPath(actionName string) (string, bool) {
+	switch {
+	case actionName == string(schemas.WebhookReceivedAction):
+		return "/api/hooks/", true
+	case schemas.StripeEventTypes[actionName] != "":
+		return "/api/hooks/stripe/", true
+	case schemas.GitlabHookEvents[actionName] != "":
+		return "/api/hooks/gitlab/", true
+	}
+	return "", false
+}
+
+func webhookUrl(hookPath string, token string) string {
+	appAdressHost := toolbox.GetInEnv("APP_HOST_ADDRESS")
+	appPort := toolbox.GetInEnv("APP_PORT")
+	return appAdressHost + appPort + hookPath + token
+}
+
+func (service *webhookService) ReceiveWebhook(token string, header http.Header, body []byte, remoteAddress string) error {
+	hook := service.webhookRepository.FindHookByToken(token)
+	if hook.Id == 0 {
+		return schemas.ErrWebhookNotFound
+	}
+	workflow, err := service.workflowRepository.FindByIds(hook.WorkflowId)
+	if err != nil {
+		return schemas.ErrWebhookNotFound
+	}
+
+	options := schemas.WebhookOptions{
+		Verification: schemas.WebhookVerificationNone,
+		RateLimit:    60,
+	}
+	err = json.Unmarshal(workflow.ActionOptions, &options)
+	if err != nil {
+		fmt.Println("Error parsing actionOption:", err)
+	}
+
+	delivery := schemas.WebhookDelivery{
+		WorkflowId:    workflow.Id,
+		Status:        schemas.WebhookDeliveryRejected,
+		RemoteAddress: remoteAddress,
+		Payload:       json.RawMessage("null"),
+	}
+	// Unverified requests have their own budget, so anyone knowing the URL
+	// can not spend the one of the legitimate sender. Past it they are
+	// dropped without being recorded.
+	err = service.verifyRequest(workflow.UserId, options, header, body)
+	if err != nil {
+		if !service.failureLimiter.Allow(token, webhookFailureRateLimit) {
+			return schemas.ErrWebhookRateLimited
+		}
+		delivery.Error = err.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookSignature
+	}
+	if !service.rateLimiter.Allow(token, options.RateLimit) {
+		delivery.Status = schemas.WebhookDeliveryRateLimited
+		delivery.Error = schemas.ErrWebhookRateLimited.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookRateLimited
+	}
+	if !workflow.IsActive {
+		delivery.Error = schemas.ErrWebhookInactive.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInactive
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+	if !json.Valid(body) {
+		delivery.Error = schemas.ErrWebhookInvalidEvent.Error()
+		service.webhookRepository.SaveDelivery(delivery)
+		return schemas.ErrWebhookInvalidEvent
+	}
+	delivery.Status = schemas.WebhookDeliveryAccepted
+	delivery.Payload = body
+	service.webhookRepository.SaveDelivery(delivery)
+	return nil
+}
+
+func (service *webhookService) verifyRequest(userId uint64, options schemas.WebhookOptions, header http.Header, body []byte) error {
+	if options.Verification == "" || options.Verification == schemas.WebhookVerificationNone {
+		return nil
+	}
+	secret, err := service.userSecretService.GetSecretValue(userId, options.SecretName)
+	if err != nil {
+		return fmt.Errorf("secret %q: %w", options.SecretName, err)
+	}
+
+	switch options.Verification {
+	case schemas.WebhookVerificationSharedSecret:
+		headerName := options.SignatureHeader
+		if headerName == "" {
+			headerName = "X-Webhook-Secret"
+		}
+		if subtle.ConstantTimeCompare([]byte(header.Get(headerName)), []byte(secret)) != 1 {
+			return schemas.ErrWebhookSignature
+		}
+		return nil
+	case schemas.WebhookVerificationHmacSha256:
+		headerName := options.SignatureHeader
+		if headerName == "" {
+			headerName = "X-Webhook-Signature"
+		}
+		signature, err := hex.DecodeString(strings.TrimPrefix(header.Get(headerName), "sha256="))
+		if err != nil {
+			return schemas.ErrWebhookSignature
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return schemas.ErrWebhookSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown verification %q", options.Verification)
+}
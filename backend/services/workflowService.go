@@ -85,6 +85,9 @@ func (service *workflowService) CreateWorkflow(ctx *gin.Context) (string, error)
 	if err != nil {
 		return "", schemas.ErrUserNotFound
 	}
+	if user.EmailVerificationPending {
+		return "", schemas.ErrEmailNotVerified
+	}
 
 	workflowName := result.Name
 	workflowValue := "1"
@@ -109,6 +112,10 @@ func (service *workflowService) CreateWorkflow(ctx *gin.Context) (string, error)
 	if action.Id == 0 {
 		return "", schemas.ErrActionNotFound
 	}
+	err = validateWorkflowOptions(action, reaction, result.ActionOption, result.ReactionOption)
+	if err != nil {
+		return "", err
+	}
 	serviceToken, err := service.serviceToken.GetTokenByUserId(user.Id)
 	if err != nil {
 		return "", err
@@ -140,6 +147,15 @@ func (service *workflowService) CreateWorkflow(ctx *gin.Context) (string, error)
 
 }
 
+func validateWorkflowOptions(action schemas.Action, reaction schemas.Reaction, actionOption json.RawMessage, reactionOption json.RawMessage) error {
+	fieldErrors := toolbox.ValidateJsonSchema(action.Schema, actionOption, "action_option")
+	fieldErrors = append(fieldErrors, toolbox.ValidateJsonSchema(reaction.Schema, reactionOption, "reaction_option")...)
+	if len(fieldErrors) != 0 {
+		return &schemas.OptionsValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
 func (service *workflowService) ActivateWorkflow(ctx *gin.Context) error {
 	var result schemas.WorkflowActivate
 	err := json.NewDecoder(ctx.Request.Body).Decode(&result)
@@ -155,6 +171,9 @@ func (service *workflowService) ActivateWorkflow(ctx *gin.Context) error {
 	if err != nil {
 		return schemas.ErrUserNotFound
 	}
+	if result.WorkflowState && user.EmailVerificationPending {
+		return schemas.ErrEmailNotVerified
+	}
 	workflow, err := service.repository.FindByIds(result.WorkflowId)
 	if err != nil || workflow.Id == 0 {
 		return schemas.ErrorNoWorkflowFound
@@ -191,7 +210,9 @@ func (service *workflowService) WorkflowActionChannel(workflowStartingPoint sche
 				fmt.Println("Action not found", workflow.Action.Name)
 				return
 			}
-			if workflow.IsActive {
+			// The reaction waits on the action, so skipping the action is
+			// enough to hold the workflows of an unverified user.
+			if workflow.IsActive && !service.userService.GetUserById(workflow.UserId).EmailVerificationPending {
 				action(channel, workflow.Id, actionOption)
 			}
 			time.Sleep(30 * time.Second)
@@ -334,6 +355,15 @@ func (service *workflowService) Update(ctx *gin.Context) error {
 		return schemas.ErrorNoWorkflowFound
 	}
 	if workflow.Id == result.WorkflowId && user.Id == workflow.UserId {
+		err = validateWorkflowOptions(
+			service.actionService.FindById(workflow.ActionId),
+			service.reactionService.FindById(workflow.ReactionId),
+			result.ActionOption,
+			result.ReactionOption,
+		)
+		if err != nil {
+			return err
+		}
 		workflow.ActionOptions = json.RawMessage(result.ActionOption)
 		workflow.ReactionOptions = json.RawMessage(result.ReactionOption)
 		workflow.Name = result.Name
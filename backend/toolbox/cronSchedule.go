@@ -0,0 +1,175 @@
+package toolbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard 5 fields cron expression
+// (minute hour day-of-month month day-of-week).
+type CronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	anyDay      bool
+	anyWeekday  bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses expressions such as "0 9 * * MON", "*/15 8-18 * * 1-5"
+// or one of the @daily like macros.
+func ParseCron(expression string) (CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, exists := cronMacros[strings.ToLower(expression)]; exists {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return CronSchedule{}, fmt.Errorf("cron expression must have %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	bits := make([]uint64, len(cronFields))
+	for index, field := range fields {
+		value, err := parseCronField(field, cronFields[index])
+		if err != nil {
+			return CronSchedule{}, err
+		}
+		bits[index] = value
+	}
+	// 7 is an alias of sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return CronSchedule{
+		minutes:     bits[0],
+		hours:       bits[1],
+		daysOfMonth: bits[2],
+		months:      bits[3],
+		daysOfWeek:  bits[4],
+		anyDay:      strings.HasPrefix(fields[2], "*"),
+		anyWeekday:  strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, spec cronField) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, found := strings.Cut(part, "/"); found {
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, spec.name)
+			}
+			part = rangePart
+		}
+
+		start, end := spec.min, spec.max
+		if part != "*" {
+			startPart, endPart, isRange := strings.Cut(part, "-")
+			start, err = parseCronValue(startPart, spec)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				end, err = parseCronValue(endPart, spec)
+				if err != nil {
+					return 0, err
+				}
+			} else if step != 1 {
+				end = spec.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q in %s field", part, spec.name)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, spec cronField) (int, error) {
+	if number, exists := spec.names[strings.ToUpper(value)]; exists {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < spec.min || number > spec.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", value, spec.name, spec.min, spec.max)
+	}
+	return number, nil
+}
+
+// Next returns the first matching minute strictly after after, in the
+// location of after. It returns the zero time when nothing matches within
+// the next five years (e.g. "0 0 30 2 *").
+func (schedule CronSchedule) Next(after time.Time) time.Time {
+	location := after.Location()
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if schedule.months&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !schedule.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if schedule.hours&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if schedule.minutes&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// matchesDay follows cron: when both day fields are restricted, either
+// of them matching is enough.
+func (schedule CronSchedule) matchesDay(date time.Time) bool {
+	dayOfMonth := schedule.daysOfMonth&(1<<uint(date.Day())) != 0
+	dayOfWeek := schedule.daysOfWeek&(1<<uint(date.Weekday())) != 0
+	if schedule.anyDay || schedule.anyWeekday {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
@@ -1,6 +1,7 @@
 package toolbox
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,14 @@ type ErrorManagement interface {
 }
 
 func HandleError(ctx *gin.Context, err error, statusOKValue interface{}) {
+	var validationErr *schemas.OptionsValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, schemas.OptionsErrorResponse{
+			Message: schemas.ErrInvalidOptions.Error(),
+			Errors:  validationErr.Errors,
+		})
+		return
+	}
 	switch err {
 	case schemas.ErrorBadParameter:
 		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
@@ -40,6 +49,122 @@ func HandleError(ctx *gin.Context, err error, statusOKValue interface{}) {
 		ctx.JSON(http.StatusNotFound, schemas.ErrorResponse{
 			Message: err.Error(),
 		})
+	case schemas.ErrSecretNotFound:
+		ctx.JSON(http.StatusNotFound, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidSecretName:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrApiKeyNotFound:
+		ctx.JSON(http.StatusNotFound, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrApiKeyForbidden:
+		ctx.JSON(http.StatusForbidden, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidApiKeyName:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidApiKeyScopes:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidApiKeyExpiry:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidRecoveryCode:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrPasswordTooShort:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrPasswordRecoveryRateLimited:
+		ctx.JSON(http.StatusTooManyRequests, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrSessionRevoked:
+		ctx.JSON(http.StatusUnauthorized, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidVerificationToken:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrEmailAlreadyVerified:
+		ctx.JSON(http.StatusConflict, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrEmailNotVerified:
+		ctx.JSON(http.StatusForbidden, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrVerificationRateLimited:
+		ctx.JSON(http.StatusTooManyRequests, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrVerificationMail:
+		ctx.JSON(http.StatusServiceUnavailable, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaPendingToken:
+		ctx.JSON(http.StatusUnauthorized, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrInvalidMfaCode:
+		ctx.JSON(http.StatusUnauthorized, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaNotEnrolled:
+		ctx.JSON(http.StatusConflict, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaAlreadyEnabled:
+		ctx.JSON(http.StatusConflict, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaNotEnabled:
+		ctx.JSON(http.StatusConflict, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaRateLimited:
+		ctx.JSON(http.StatusTooManyRequests, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrMfaForbidden:
+		ctx.JSON(http.StatusForbidden, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrAdminRequired:
+		ctx.JSON(http.StatusForbidden, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrWebhookNotFound:
+		ctx.JSON(http.StatusNotFound, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrWebhookInactive:
+		ctx.JSON(http.StatusConflict, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrWebhookSignature:
+		ctx.JSON(http.StatusUnauthorized, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrWebhookRateLimited:
+		ctx.JSON(http.StatusTooManyRequests, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
+	case schemas.ErrWebhookInvalidEvent:
+		ctx.JSON(http.StatusBadRequest, schemas.ErrorResponse{
+			Message: err.Error(),
+		})
 	case schemas.ErrNoAuthorizationHeaderFound:
 		return
 	default:
@@ -0,0 +1,166 @@
+package toolbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"area51/schemas"
+)
+
+// ValidateJsonSchema checks value against schema and returns one error per
+// offending field, each prefixed by field (e.g. "action_option.repo").
+func ValidateJsonSchema(schema schemas.JsonSchema, value json.RawMessage, field string) []schemas.OptionFieldError {
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	err := decoder.Decode(&decoded)
+	if err != nil {
+		return []schemas.OptionFieldError{{Field: field, Message: "must be valid JSON"}}
+	}
+	return validateJsonValue(&schema, decoded, field)
+}
+
+func validateJsonValue(schema *schemas.JsonSchema, value interface{}, field string) (fieldErrors []schemas.OptionFieldError) {
+	fail := func(format string, args ...interface{}) []schemas.OptionFieldError {
+		return append(fieldErrors, schemas.OptionFieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if schema.Type != "" && !jsonTypeMatches(schema.Type, value) {
+		return fail("must be of type %s", schema.Type)
+	}
+
+	if len(schema.Enum) != 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowedValues := []string{}
+			for _, allowed := range schema.Enum {
+				allowedValues = append(allowedValues, fmt.Sprint(allowed))
+			}
+			return fail("must be one of: %s", strings.Join(allowedValues, ", "))
+		}
+	}
+
+	switch typedValue := value.(type) {
+	case string:
+		if schema.MinLength != nil && len([]rune(typedValue)) < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				return fail("must not be empty")
+			}
+			return fail("must be at least %d characters long", *schema.MinLength)
+		}
+		if schema.Pattern != "" {
+			matched, err := regexp.MatchString(schema.Pattern, typedValue)
+			if err != nil || !matched {
+				return fail("must match the pattern %s", schema.Pattern)
+			}
+		}
+		if schema.Format != "" && !jsonFormatMatches(schema.Format, typedValue) {
+			return fail("must be a valid %s", schema.Format)
+		}
+	case json.Number:
+		number, _ := typedValue.Float64()
+		if schema.Minimum != nil && number < *schema.Minimum {
+			return fail("must be greater than or equal to %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			return fail("must be less than or equal to %v", *schema.Maximum)
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for index, item := range typedValue {
+				fieldErrors = append(fieldErrors, validateJsonValue(schema.Items, item, fmt.Sprintf("%s[%d]", field, index))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, required := range schema.Required {
+			if _, exists := typedValue[required]; !exists {
+				fieldErrors = append(fieldErrors, schemas.OptionFieldError{
+					Field:   joinJsonField(field, required),
+					Message: "is required",
+				})
+			}
+		}
+		names := []string{}
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propertyValue, exists := typedValue[name]; exists {
+				fieldErrors = append(fieldErrors, validateJsonValue(schema.Properties[name], propertyValue, joinJsonField(field, name))...)
+			}
+		}
+	}
+	return fieldErrors
+}
+
+func jsonTypeMatches(expectedType string, value interface{}) bool {
+	switch expectedType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := number.Int64()
+		return err == nil
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonFormatMatches(format string, value string) bool {
+	switch format {
+	case "uri":
+		parsedUrl, err := url.ParseRequestURI(value)
+		return err == nil && parsedUrl.Scheme != "" && parsedUrl.Host != ""
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04", value)
+		return err == nil
+	}
+	return true
+}
+
+func joinJsonField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,96 @@
+package toolbox
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginThrottle slows down password guessing for one kind of key (an IP or a
+// username). The first failures are free, each next one doubles the wait
+// before another attempt is allowed, and too many lock the key out.
+type LoginThrottle struct {
+	freeFailures int
+	maxFailures  int
+	maxDelay     time.Duration
+	lockout      time.Duration
+	mutex        sync.Mutex
+	entries      map[string]*loginThrottleEntry
+}
+
+type loginThrottleEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLoginThrottle(freeFailures int, maxFailures int, maxDelay time.Duration, lockout time.Duration) *LoginThrottle {
+	return &LoginThrottle{
+		freeFailures: freeFailures,
+		maxFailures:  maxFailures,
+		maxDelay:     maxDelay,
+		lockout:      lockout,
+		entries:      map[string]*loginThrottleEntry{},
+	}
+}
+
+func (throttle *LoginThrottle) delay(failures int) time.Duration {
+	if failures <= throttle.freeFailures {
+		return 0
+	}
+	delay := time.Second
+	for index := throttle.freeFailures + 1; index < failures && delay < throttle.maxDelay; index++ {
+		delay *= 2
+	}
+	return min(delay, throttle.maxDelay)
+}
+
+// RetryAfter is how long key has to wait before its next attempt, zero when
+// it may try now.
+func (throttle *LoginThrottle) RetryAfter(key string) time.Duration {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	now := time.Now()
+	throttle.forgetExpired(now)
+	entry, exists := throttle.entries[key]
+	if !exists {
+		return 0
+	}
+	if now.Before(entry.lockedUntil) {
+		return entry.lockedUntil.Sub(now)
+	}
+	return max(entry.lastFailure.Add(throttle.delay(entry.failures)).Sub(now), 0)
+}
+
+func (throttle *LoginThrottle) Failure(key string) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	now := time.Now()
+	entry, exists := throttle.entries[key]
+	if !exists {
+		entry = &loginThrottleEntry{}
+		throttle.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+	if entry.failures >= throttle.maxFailures {
+		entry.lockedUntil = now.Add(throttle.lockout)
+	}
+}
+
+func (throttle *LoginThrottle) Success(key string) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	delete(throttle.entries, key)
+}
+
+// forgetExpired drops the keys without failure for a lockout duration.
+func (throttle *LoginThrottle) forgetExpired(now time.Time) {
+	for key, entry := range throttle.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.lastFailure) >= throttle.lockout {
+			delete(throttle.entries, key)
+		}
+	}
+}
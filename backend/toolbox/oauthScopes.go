@@ -0,0 +1,18 @@
+package toolbox
+
+import "strings"
+
+// MissingScopes returns the required scopes absent from granted, the space
+// separated scope list an OAuth provider answers with.
+func MissingScopes(granted string, required []string) (missing []string) {
+	grantedScopes := map[string]bool{}
+	for _, scope := range strings.Fields(granted) {
+		grantedScopes[scope] = true
+	}
+	for _, scope := range required {
+		if !grantedScopes[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
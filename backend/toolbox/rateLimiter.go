@@ -0,0 +1,49 @@
+package toolbox
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter counts hits per key over a fixed window, e.g. the requests
+// received by one webhook during the current minute.
+type RateLimiter struct {
+	window  time.Duration
+	mutex   sync.Mutex
+	entries map[string]*rateLimitEntry
+}
+
+type rateLimitEntry struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		window:  window,
+		entries: map[string]*rateLimitEntry{},
+	}
+}
+
+// Allow records a hit for key and reports whether it stays within limit.
+func (limiter *RateLimiter) Allow(key string, limit int) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	for entryKey, entry := range limiter.entries {
+		if now.Sub(entry.start) >= limiter.window {
+			delete(limiter.entries, entryKey)
+		}
+	}
+	entry, exists := limiter.entries[key]
+	if !exists {
+		entry = &rateLimitEntry{start: now}
+		limiter.entries[key] = entry
+	}
+	if entry.count >= limit {
+		return false
+	}
+	entry.count++
+	return true
+}
@@ -0,0 +1,60 @@
+package toolbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+type SecretCipher interface {
+	EncryptSecret(plaintext string) (string, error)
+	DecryptSecret(ciphertext string) (string, error)
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(GetInEnv("SECRETS_KEY")))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret seals plaintext with AES-GCM under SECRETS_KEY and returns
+// the base64 encoded nonce followed by the ciphertext.
+func EncryptSecret(plaintext string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", fmt.Errorf("unable to create cipher: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("unable to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptSecret(ciphertext string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", fmt.Errorf("unable to create cipher: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("secret is too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
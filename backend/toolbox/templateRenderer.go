@@ -0,0 +1,71 @@
+package toolbox
+
+import (
+	"encoding/json"
+	htmlTemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"area51/schemas"
+)
+
+type TemplateRenderer interface {
+	RenderTemplate(text string, data map[string]interface{}) (string, error)
+	WorkflowTemplateData(workflow schemas.Workflow) map[string]interface{}
+	RenderHtmlTemplate(text string, data map[string]interface{}) (string, error)
+}
+
+// RenderTemplate executes a Go text/template found in a reaction option.
+// Strings without any action are returned untouched.
+func RenderTemplate(text string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	parsedTemplate, err := template.New("option").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	err = parsedTemplate.Execute(&result, data)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// WorkflowTemplateData is the data reaction templates are rendered with,
+// e.g. {{.workflow.name}}, {{.now}} or {{.event.some_field}} for the payload
+// of the last event the action received.
+func WorkflowTemplateData(workflow schemas.Workflow) map[string]interface{} {
+	var event interface{}
+	if len(workflow.Event) != 0 {
+		_ = json.Unmarshal(workflow.Event, &event)
+	}
+	return map[string]interface{}{
+		"workflow": map[string]interface{}{
+			"id":   workflow.Id,
+			"name": workflow.Name,
+		},
+		"event": event,
+		"now":   time.Now().Format(time.RFC3339),
+	}
+}
+
+// RenderHtmlTemplate is RenderTemplate for HTML contents: values coming from
+// the data, such as event fields, are escaped.
+func RenderHtmlTemplate(text string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	parsedTemplate, err := htmlTemplate.New("option").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	err = parsedTemplate.Execute(&result, data)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
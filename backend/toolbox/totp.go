@@ -0,0 +1,89 @@
+package toolbox
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP of RFC 6238 with the parameters every authenticator app supports:
+// SHA-1, 6 digits and 30 second steps.
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// Codes of the previous and next steps are accepted for clock drift.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateTotpSecret() (string, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TotpUri builds the otpauth:// URI shown as a QR code by the clients.
+func TotpUri(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func TotpStep(now time.Time) int64 {
+	return now.Unix() / totpPeriod
+}
+
+func TotpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for index := 0; index < totpDigits; index++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo), nil
+}
+
+// ValidateTotp checks code around now and returns the step it matched, so
+// callers can refuse a code that was already used.
+func ValidateTotp(secret string, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := TotpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := TotpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}